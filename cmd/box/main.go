@@ -0,0 +1,13 @@
+package main
+
+import (
+	"os"
+
+	"github.com/amarantec/box/internal/cli"
+)
+
+func main() {
+	if err := cli.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
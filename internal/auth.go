@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"slices"
+	"time"
+)
+
+// AuthMethod tells how a principal proved who they are.
+type AuthMethod string
+
+const (
+	AuthJWT    AuthMethod = "jwt"
+	AuthAPIKey AuthMethod = "api_key"
+)
+
+// APIKeyPrefix starts every API key, telling them apart from JWTs sent in
+// the same Authorization header.
+const APIKeyPrefix = "box_"
+
+// Role is what a principal is allowed to do. Readers list, get and search
+// the catalog, librarians also export it, read its history, maintain it and
+// run the circulation desk, and admins also purge deleted books and assign
+// roles.
+type Role string
+
+const (
+	RoleReader    Role = "reader"
+	RoleLibrarian Role = "librarian"
+	RoleAdmin     Role = "admin"
+)
+
+var Roles = []Role{RoleReader, RoleLibrarian, RoleAdmin}
+
+// Permission is checked by a route before its handler runs.
+type Permission string
+
+const (
+	PermCatalogRead    Permission = "catalog:read"
+	PermCatalogExport  Permission = "catalog:export"
+	PermCatalogHistory Permission = "catalog:history"
+	PermCatalogWrite   Permission = "catalog:write"
+	PermCatalogPurge   Permission = "catalog:purge"
+	PermCirculation    Permission = "circulation"
+	PermManageRoles    Permission = "roles:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleReader:    {PermCatalogRead},
+	RoleLibrarian: {PermCatalogRead, PermCatalogExport, PermCatalogHistory, PermCatalogWrite, PermCirculation},
+	RoleAdmin:     {PermCatalogRead, PermCatalogExport, PermCatalogHistory, PermCatalogWrite, PermCirculation, PermCatalogPurge, PermManageRoles},
+}
+
+// Can reports whether r grants p.
+func (r Role) Can(p Permission) bool {
+	return slices.Contains(rolePermissions[r], p)
+}
+
+// RoleAssignment gives Role to the principal whose Actor is Subject.
+// Principals without one are readers.
+type RoleAssignment struct {
+	Subject    string
+	Role       Role
+	AssignedBy string
+	AssignedAt time.Time
+}
+
+// Principal is the client behind an authenticated request. Subject is the
+// sub claim of a JWT, or the name of an API key; APIKeyID is zero for JWTs.
+type Principal struct {
+	Subject  string
+	Method   AuthMethod
+	APIKeyID int64
+	Role     Role
+}
+
+// Actor is the name recorded for the changes made by p, such as in the book
+// history. API keys are told apart from JWT subjects of the same name.
+func (p Principal) Actor() string {
+	if p.Method == AuthAPIKey {
+		return "key:" + p.Subject
+	}
+	return p.Subject
+}
+
+// APIKey is a long-lived credential for scripts and services. Only the
+// SHA-256 Hash of the key is stored: the key itself is shown once, when it is
+// created. Prefix is its first characters, enough to recognize it in a list.
+type APIKey struct {
+	ID        int64
+	Name      string
+	Prefix    string
+	Hash      string `json:"-"`
+	CreatedBy string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// IssuedAPIKey is a new API key along with its secret Key, which cannot be
+// read back later.
+type IssuedAPIKey struct {
+	APIKey APIKey
+	Key    string
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IAPIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, k internal.APIKey) (int64, error)
+	ListAPIKeys(ctx context.Context) ([]internal.APIKey, error)
+	GetAPIKeyById(ctx context.Context, keyId int64) (internal.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (internal.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyId int64, now time.Time) (bool, error)
+}
+
+// apiKeysNameKey is the unique index on the names of the active keys.
+const apiKeysNameKey = "api_keys_name_key"
+
+const apiKeyColumns = `id, name, prefix, hash, created_by, created_at, revoked_at`
+
+func scanAPIKey(row pgx.Row) (internal.APIKey, error) {
+	var k internal.APIKey
+	if err := row.Scan(
+		&k.ID,
+		&k.Name,
+		&k.Prefix,
+		&k.Hash,
+		&k.CreatedBy,
+		&k.CreatedAt,
+		&k.RevokedAt,
+	); err != nil {
+		return internal.APIKey{}, err
+	}
+	return k, nil
+}
+
+type apiKeyRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(conn *pgxpool.Pool) IAPIKeyRepository {
+	return &apiKeyRepository{Conn: conn}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, k internal.APIKey) (int64, error) {
+	err :=
+		r.Conn.QueryRow(
+			ctx,
+			`INSERT INTO api_keys (name, prefix, hash, created_by, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id;`,
+			k.Name, k.Prefix, k.Hash, k.CreatedBy, k.CreatedAt).Scan(&k.ID)
+
+	if err != nil {
+		if database.IsUniqueViolationOf(err, apiKeysNameKey) {
+			return internal.ZERO, internal.ErrAPIKeyNameTaken
+		}
+		return internal.ZERO, err
+	}
+
+	log.Printf("API key with ID %d created.\n", k.ID)
+	return k.ID, nil
+}
+
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context) ([]internal.APIKey, error) {
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			`SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id;`)
+
+	if err != nil {
+		return []internal.APIKey{}, err
+	}
+
+	defer rows.Close()
+
+	keys := []internal.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return []internal.APIKey{}, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepository) GetAPIKeyById(ctx context.Context, keyId int64) (internal.APIKey, error) {
+	k, err := scanAPIKey(
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1;`, keyId))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.APIKey{}, internal.ErrAPIKeyNotFound
+		}
+		return internal.APIKey{}, err
+	}
+
+	return k, nil
+}
+
+func (r *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (internal.APIKey, error) {
+	k, err := scanAPIKey(
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT `+apiKeyColumns+` FROM api_keys WHERE hash = $1;`, hash))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.APIKey{}, internal.ErrAPIKeyNotFound
+		}
+		return internal.APIKey{}, err
+	}
+
+	return k, nil
+}
+
+// RevokeAPIKey stops a key from authenticating from now on.
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, keyId int64, now time.Time) (bool, error) {
+	tag, err :=
+		r.Conn.Exec(
+			ctx,
+			`UPDATE api_keys SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL;`, keyId, now)
+
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == internal.ZERO {
+		if _, err := r.GetAPIKeyById(ctx, keyId); err != nil {
+			return false, err
+		}
+		return false, internal.ErrAPIKeyRevoked
+	}
+
+	log.Printf("API key with ID %d revoked.\n", keyId)
+	return true, nil
+}
@@ -0,0 +1,105 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/auth"
+	"github.com/amarantec/box/internal/database/databasetest"
+)
+
+// repositories are the API key and role repositories under test.
+type repositories struct {
+	apiKeys auth.IAPIKeyRepository
+	roles   auth.IRoleRepository
+}
+
+// runRepositories runs test against the in-memory repository and, when
+// BOX_TEST_DATABASE_URL is set, against PostgreSQL.
+func runRepositories(t *testing.T, test func(t *testing.T, r repositories)) {
+	t.Run("Memory", func(t *testing.T) {
+		test(t, repositories{apiKeys: auth.NewMemoryAPIKeyRepository(), roles: auth.NewMemoryRoleRepository()})
+	})
+	t.Run("PostgreSQL", func(t *testing.T) {
+		conn := databasetest.Open(t, "auth_test")
+		databasetest.Truncate(t, conn)
+		test(t, repositories{apiKeys: auth.NewAPIKeyRepository(conn), roles: auth.NewRoleRepository(conn)})
+	})
+}
+
+func newAPIKey(name, key string) internal.APIKey {
+	return internal.APIKey{
+		Name:      name,
+		Prefix:    key[:12],
+		Hash:      auth.HashAPIKey(key),
+		CreatedBy: "cli",
+		CreatedAt: time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	runRepositories(t, testAPIKeys)
+}
+
+func testAPIKeys(t *testing.T, r repositories) {
+	ctx := context.Background()
+	const key, otherKey = "box_first-secret-key-0000000000", "box_second-secret-key-000000000"
+
+	keyId, err := r.apiKeys.CreateAPIKey(ctx, newAPIKey("importer", key))
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if _, err := r.apiKeys.CreateAPIKey(ctx, newAPIKey("importer", otherKey)); !errors.Is(err, internal.ErrAPIKeyNameTaken) {
+		t.Fatalf("CreateAPIKey(same name) error = %v, want %v", err, internal.ErrAPIKeyNameTaken)
+	}
+
+	got, err := r.apiKeys.GetAPIKeyByHash(ctx, auth.HashAPIKey(key))
+	if err != nil {
+		t.Fatalf("GetAPIKeyByHash: %v", err)
+	}
+	want := newAPIKey("importer", key)
+	if got.ID != keyId || got.Name != want.Name || got.Prefix != want.Prefix || got.Hash != want.Hash ||
+		got.CreatedBy != want.CreatedBy || !got.CreatedAt.Equal(want.CreatedAt) || got.RevokedAt != nil {
+		t.Fatalf("GetAPIKeyByHash = %+v, want %+v with ID %d", got, want, keyId)
+	}
+	if _, err := r.apiKeys.GetAPIKeyByHash(ctx, auth.HashAPIKey(otherKey)); !errors.Is(err, internal.ErrAPIKeyNotFound) {
+		t.Fatalf("GetAPIKeyByHash(unknown) error = %v, want %v", err, internal.ErrAPIKeyNotFound)
+	}
+
+	revokedAt := time.Date(2026, time.March, 3, 10, 0, 0, 0, time.UTC)
+	if _, err := r.apiKeys.RevokeAPIKey(ctx, keyId, revokedAt); err != nil {
+		t.Fatalf("RevokeAPIKey(%d): %v", keyId, err)
+	}
+	if _, err := r.apiKeys.RevokeAPIKey(ctx, keyId, revokedAt); !errors.Is(err, internal.ErrAPIKeyRevoked) {
+		t.Fatalf("RevokeAPIKey(revoked) error = %v, want %v", err, internal.ErrAPIKeyRevoked)
+	}
+	if _, err := r.apiKeys.RevokeAPIKey(ctx, 9999, revokedAt); !errors.Is(err, internal.ErrAPIKeyNotFound) {
+		t.Fatalf("RevokeAPIKey(missing) error = %v, want %v", err, internal.ErrAPIKeyNotFound)
+	}
+	got, err = r.apiKeys.GetAPIKeyById(ctx, keyId)
+	if err != nil || got.RevokedAt == nil || !got.RevokedAt.Equal(revokedAt) {
+		t.Fatalf("GetAPIKeyById(%d) = %+v, %v, want revoked at %v", keyId, got, err, revokedAt)
+	}
+
+	// A revoked key frees its name.
+	otherId, err := r.apiKeys.CreateAPIKey(ctx, newAPIKey("importer", otherKey))
+	if err != nil {
+		t.Fatalf("CreateAPIKey(name of a revoked key): %v", err)
+	}
+
+	keys, err := r.apiKeys.ListAPIKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListAPIKeys: %v", err)
+	}
+	var ids []int64
+	for _, k := range keys {
+		ids = append(ids, k.ID)
+	}
+	if !slices.Equal(ids, []int64{keyId, otherId}) {
+		t.Fatalf("ListAPIKeys = %v, want [%d %d]", ids, keyId, otherId)
+	}
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+	"unicode/utf8"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/utils"
+)
+
+const (
+	maxKeyNameLength = 100
+
+	// keySecretBytes is how much randomness goes into a key.
+	keySecretBytes = 32
+	// keyPrefixLength is how much of a key is kept in the clear to tell it
+	// apart in a list.
+	keyPrefixLength = 12
+)
+
+type IAPIKeyService interface {
+	CreateAPIKey(ctx context.Context, name string) (internal.Response[internal.IssuedAPIKey], error)
+	ListAPIKeys(ctx context.Context) (internal.Response[[]internal.APIKey], error)
+	RevokeAPIKey(ctx context.Context, keyId int64) (internal.Response[bool], error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo IAPIKeyRepository
+}
+
+func NewAPIKeyService(repository IAPIKeyRepository) IAPIKeyService {
+	return &apiKeyService{apiKeyRepo: repository}
+}
+
+// CreateAPIKey issues a new key under name, which no other active key may
+// have. The key is only ever returned here.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, name string) (internal.Response[internal.IssuedAPIKey], error) {
+	var response internal.Response[internal.IssuedAPIKey]
+
+	name = utils.NormalizeName(name)
+	if err := validateKeyName(name); err != nil {
+		response.Data = internal.IssuedAPIKey{}
+		response.Success = false
+		return response, err
+	}
+
+	key := newKey()
+	k := internal.APIKey{
+		Name:      name,
+		Prefix:    key[:keyPrefixLength],
+		Hash:      HashAPIKey(key),
+		CreatedBy: internal.ActorFromContext(ctx),
+		CreatedAt: time.Now(),
+	}
+
+	keyId, err := s.apiKeyRepo.CreateAPIKey(ctx, k)
+	var data internal.APIKey
+	if err == nil {
+		data, err = s.apiKeyRepo.GetAPIKeyById(ctx, keyId)
+	}
+	if err != nil {
+		response.Data = internal.IssuedAPIKey{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = internal.IssuedAPIKey{APIKey: data, Key: key}
+	response.Success = true
+	response.Message = "API key created. Store the key now: it cannot be shown again."
+	return response, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) (internal.Response[[]internal.APIKey], error) {
+	var response internal.Response[[]internal.APIKey]
+
+	data, err := s.apiKeyRepo.ListAPIKeys(ctx)
+	if err != nil {
+		response.Data = []internal.APIKey{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "All API keys, revoked ones included."
+	return response, nil
+}
+
+// RevokeAPIKey stops a key from authenticating. Requests already under way
+// with it are not interrupted.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, keyId int64) (internal.Response[bool], error) {
+	var response internal.Response[bool]
+
+	data, err := s.apiKeyRepo.RevokeAPIKey(ctx, keyId, time.Now())
+	if err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "API key revoked successfully."
+	return response, nil
+}
+
+// HashAPIKey is the hash under which key is stored. Keys are long random
+// strings, so a fast hash is enough to keep them from being read back.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func newKey() string {
+	b := make([]byte, keySecretBytes)
+	rand.Read(b)
+	return internal.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+}
+
+func validateKeyName(name string) error {
+	v := &internal.ValidationError{}
+
+	v.Check(name != internal.EMPTY, "Name", "must not be empty")
+	v.Check(utf8.RuneCountInString(name) <= maxKeyNameLength,
+		"Name", "must be at most %d characters", maxKeyNameLength)
+
+	return v.Err()
+}
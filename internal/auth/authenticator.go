@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtLeeway absorbs clock drift between the token issuer and the server.
+const jwtLeeway = 30 * time.Second
+
+// JWTConfig sets which JWTs are accepted. Tokens signed with HS256, HS384
+// or HS512 are checked against HMACSecret, and tokens signed with RS256,
+// RS384 or RS512 against RSAPublicKey; leaving one out turns its algorithms
+// off. A non-empty Issuer or Audience must match the iss or aud claim.
+type JWTConfig struct {
+	HMACSecret   []byte
+	RSAPublicKey *rsa.PublicKey
+	Issuer       string
+	Audience     string
+}
+
+// Authenticator tells who is behind a credential: a JWT, which must carry a
+// subject and an expiry, or an API key. It also tells their role: admin for
+// the admins it is given, so that roles can be assigned on a new install,
+// and otherwise the one assigned to them, or reader.
+type Authenticator struct {
+	apiKeyRepo IAPIKeyRepository
+	roleRepo   IRoleRepository
+	admins     []string
+	jwt        JWTConfig
+	parser     *jwt.Parser
+}
+
+func NewAuthenticator(apiKeys IAPIKeyRepository, roles IRoleRepository, admins []string, cfg JWTConfig) *Authenticator {
+	var methods []string
+	if len(cfg.HMACSecret) > internal.ZERO {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if cfg.RSAPublicKey != nil {
+		methods = append(methods, "RS256", "RS384", "RS512")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(jwtLeeway),
+	}
+	if cfg.Issuer != internal.EMPTY {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != internal.EMPTY {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &Authenticator{
+		apiKeyRepo: apiKeys,
+		roleRepo:   roles,
+		admins:     admins,
+		jwt:        cfg,
+		parser:     jwt.NewParser(options...),
+	}
+}
+
+// AcceptsJWT reports whether a key to check JWTs against is configured.
+func (a *Authenticator) AcceptsJWT() bool {
+	return len(a.jwt.HMACSecret) > internal.ZERO || a.jwt.RSAPublicKey != nil
+}
+
+// Authenticate returns the principal behind credential. Credentials that do
+// not check out are reported as ErrInvalidCredentials, without saying why.
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (internal.Principal, error) {
+	var p internal.Principal
+	var err error
+	if strings.HasPrefix(credential, internal.APIKeyPrefix) {
+		p, err = a.authenticateAPIKey(ctx, credential)
+	} else {
+		p, err = a.authenticateJWT(credential)
+	}
+	if err != nil {
+		return internal.Principal{}, err
+	}
+
+	p.Role, err = a.roleOf(ctx, p)
+	if err != nil {
+		return internal.Principal{}, err
+	}
+	return p, nil
+}
+
+// roleOf looks up the role of p by the name its changes are recorded under,
+// so that an API key never shares the role of a JWT subject of the same name.
+func (a *Authenticator) roleOf(ctx context.Context, p internal.Principal) (internal.Role, error) {
+	if slices.Contains(a.admins, p.Actor()) {
+		return internal.RoleAdmin, nil
+	}
+
+	assignment, err := a.roleRepo.GetRoleAssignment(ctx, p.Actor())
+	if errors.Is(err, internal.ErrRoleAssignmentNotFound) {
+		return internal.RoleReader, nil
+	}
+	if err != nil {
+		return internal.EMPTY, err
+	}
+	return assignment.Role, nil
+}
+
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, key string) (internal.Principal, error) {
+	k, err := a.apiKeyRepo.GetAPIKeyByHash(ctx, HashAPIKey(key))
+	if errors.Is(err, internal.ErrAPIKeyNotFound) || (err == nil && k.RevokedAt != nil) {
+		return internal.Principal{}, internal.ErrInvalidCredentials
+	}
+	if err != nil {
+		return internal.Principal{}, err
+	}
+
+	return internal.Principal{Subject: k.Name, Method: internal.AuthAPIKey, APIKeyID: k.ID}, nil
+}
+
+func (a *Authenticator) authenticateJWT(token string) (internal.Principal, error) {
+	if !a.AcceptsJWT() {
+		return internal.Principal{}, internal.ErrInvalidCredentials
+	}
+
+	var claims jwt.RegisteredClaims
+	if _, err := a.parser.ParseWithClaims(token, &claims, a.verificationKey); err != nil {
+		return internal.Principal{}, internal.ErrInvalidCredentials
+	}
+	if claims.Subject == internal.EMPTY {
+		return internal.Principal{}, internal.ErrInvalidCredentials
+	}
+
+	return internal.Principal{Subject: claims.Subject, Method: internal.AuthJWT}, nil
+}
+
+// verificationKey picks the key matching the algorithm of t. The parser has
+// already refused the algorithms without one.
+func (a *Authenticator) verificationKey(t *jwt.Token) (any, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return a.jwt.HMACSecret, nil
+	case *jwt.SigningMethodRSA:
+		return a.jwt.RSAPublicKey, nil
+	}
+	return nil, jwt.ErrTokenUnverifiable
+}
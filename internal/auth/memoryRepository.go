@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/amarantec/box/internal"
+)
+
+// memoryAPIKeyRepository is an IAPIKeyRepository kept in process memory.
+type memoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys []internal.APIKey
+}
+
+func NewMemoryAPIKeyRepository() IAPIKeyRepository {
+	return &memoryAPIKeyRepository{}
+}
+
+func (r *memoryAPIKeyRepository) CreateAPIKey(ctx context.Context, k internal.APIKey) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.keys {
+		if existing.RevokedAt == nil && existing.Name == k.Name {
+			return internal.ZERO, internal.ErrAPIKeyNameTaken
+		}
+	}
+
+	k.ID = int64(len(r.keys)) + 1
+	k.RevokedAt = nil
+	r.keys = append(r.keys, k)
+
+	return k.ID, nil
+}
+
+func (r *memoryAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]internal.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return []internal.APIKey{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]internal.APIKey{}, r.keys...), nil
+}
+
+func (r *memoryAPIKeyRepository) GetAPIKeyById(ctx context.Context, keyId int64) (internal.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.APIKey{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if keyId < 1 || keyId > int64(len(r.keys)) {
+		return internal.APIKey{}, internal.ErrAPIKeyNotFound
+	}
+	return r.keys[keyId-1], nil
+}
+
+func (r *memoryAPIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (internal.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.APIKey{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+	return internal.APIKey{}, internal.ErrAPIKeyNotFound
+}
+
+func (r *memoryAPIKeyRepository) RevokeAPIKey(ctx context.Context, keyId int64, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if keyId < 1 || keyId > int64(len(r.keys)) {
+		return false, internal.ErrAPIKeyNotFound
+	}
+	k := &r.keys[keyId-1]
+	if k.RevokedAt != nil {
+		return false, internal.ErrAPIKeyRevoked
+	}
+	k.RevokedAt = &now
+
+	return true, nil
+}
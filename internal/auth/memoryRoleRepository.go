@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/amarantec/box/internal"
+)
+
+// memoryRoleRepository is an IRoleRepository kept in process memory.
+type memoryRoleRepository struct {
+	mu          sync.RWMutex
+	assignments map[string]internal.RoleAssignment
+}
+
+func NewMemoryRoleRepository() IRoleRepository {
+	return &memoryRoleRepository{assignments: map[string]internal.RoleAssignment{}}
+}
+
+func (r *memoryRoleRepository) AssignRole(ctx context.Context, a internal.RoleAssignment) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.assignments[a.Subject] = a
+	return true, nil
+}
+
+func (r *memoryRoleRepository) ListRoleAssignments(ctx context.Context) ([]internal.RoleAssignment, error) {
+	if err := ctx.Err(); err != nil {
+		return []internal.RoleAssignment{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	assignments := []internal.RoleAssignment{}
+	for _, a := range r.assignments {
+		assignments = append(assignments, a)
+	}
+
+	slices.SortFunc(assignments, func(a, b internal.RoleAssignment) int {
+		return cmp.Compare(a.Subject, b.Subject)
+	})
+	return assignments, nil
+}
+
+func (r *memoryRoleRepository) GetRoleAssignment(ctx context.Context, subject string) (internal.RoleAssignment, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.RoleAssignment{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.assignments[subject]
+	if !ok {
+		return internal.RoleAssignment{}, internal.ErrRoleAssignmentNotFound
+	}
+	return a, nil
+}
+
+func (r *memoryRoleRepository) RemoveRoleAssignment(ctx context.Context, subject string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.assignments[subject]; !ok {
+		return false, internal.ErrRoleAssignmentNotFound
+	}
+	delete(r.assignments, subject)
+	return true, nil
+}
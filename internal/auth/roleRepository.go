@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"log"
+
+	"github.com/amarantec/box/internal"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IRoleRepository interface {
+	AssignRole(ctx context.Context, a internal.RoleAssignment) (bool, error)
+	ListRoleAssignments(ctx context.Context) ([]internal.RoleAssignment, error)
+	GetRoleAssignment(ctx context.Context, subject string) (internal.RoleAssignment, error)
+	RemoveRoleAssignment(ctx context.Context, subject string) (bool, error)
+}
+
+const roleAssignmentColumns = `subject, role, assigned_by, assigned_at`
+
+func scanRoleAssignment(row pgx.Row) (internal.RoleAssignment, error) {
+	var a internal.RoleAssignment
+	if err := row.Scan(
+		&a.Subject,
+		&a.Role,
+		&a.AssignedBy,
+		&a.AssignedAt,
+	); err != nil {
+		return internal.RoleAssignment{}, err
+	}
+	return a, nil
+}
+
+type roleRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewRoleRepository(conn *pgxpool.Pool) IRoleRepository {
+	return &roleRepository{Conn: conn}
+}
+
+// AssignRole gives a role to a subject, replacing the one they had.
+func (r *roleRepository) AssignRole(ctx context.Context, a internal.RoleAssignment) (bool, error) {
+	_, err :=
+		r.Conn.Exec(
+			ctx,
+			`INSERT INTO role_assignments (subject, role, assigned_by, assigned_at) VALUES ($1, $2, $3, $4)
+            ON CONFLICT (subject) DO UPDATE SET role = EXCLUDED.role, assigned_by = EXCLUDED.assigned_by, assigned_at = EXCLUDED.assigned_at;`,
+			a.Subject, a.Role, a.AssignedBy, a.AssignedAt)
+
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("Role %s assigned to %s.\n", a.Role, a.Subject)
+	return true, nil
+}
+
+func (r *roleRepository) ListRoleAssignments(ctx context.Context) ([]internal.RoleAssignment, error) {
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			`SELECT `+roleAssignmentColumns+` FROM role_assignments ORDER BY subject;`)
+
+	if err != nil {
+		return []internal.RoleAssignment{}, err
+	}
+
+	defer rows.Close()
+
+	assignments := []internal.RoleAssignment{}
+	for rows.Next() {
+		a, err := scanRoleAssignment(rows)
+		if err != nil {
+			return []internal.RoleAssignment{}, err
+		}
+		assignments = append(assignments, a)
+	}
+
+	return assignments, rows.Err()
+}
+
+func (r *roleRepository) GetRoleAssignment(ctx context.Context, subject string) (internal.RoleAssignment, error) {
+	a, err := scanRoleAssignment(
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT `+roleAssignmentColumns+` FROM role_assignments WHERE subject = $1;`, subject))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.RoleAssignment{}, internal.ErrRoleAssignmentNotFound
+		}
+		return internal.RoleAssignment{}, err
+	}
+
+	return a, nil
+}
+
+// RemoveRoleAssignment takes the role of a subject away, leaving them a
+// reader.
+func (r *roleRepository) RemoveRoleAssignment(ctx context.Context, subject string) (bool, error) {
+	tag, err :=
+		r.Conn.Exec(
+			ctx,
+			`DELETE FROM role_assignments WHERE subject = $1;`, subject)
+
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == internal.ZERO {
+		return false, internal.ErrRoleAssignmentNotFound
+	}
+
+	log.Printf("Role of %s removed.\n", subject)
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/auth"
+)
+
+func TestRoles(t *testing.T) {
+	runRepositories(t, testRoles)
+}
+
+func testRoles(t *testing.T, r repositories) {
+	ctx := context.Background()
+	assignedAt := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+	assign := func(subject string, role internal.Role) {
+		t.Helper()
+		a := internal.RoleAssignment{Subject: subject, Role: role, AssignedBy: "root", AssignedAt: assignedAt}
+		if _, err := r.roles.AssignRole(ctx, a); err != nil {
+			t.Fatalf("AssignRole(%s, %s): %v", subject, role, err)
+		}
+	}
+
+	assign("zoe", internal.RoleLibrarian)
+	assign("key:scanner", internal.RoleLibrarian)
+	// Assigning again replaces the role.
+	assign("zoe", internal.RoleAdmin)
+
+	got, err := r.roles.GetRoleAssignment(ctx, "zoe")
+	if err != nil || got.Role != internal.RoleAdmin || got.AssignedBy != "root" || !got.AssignedAt.Equal(assignedAt) {
+		t.Fatalf("GetRoleAssignment(zoe) = %+v, %v, want admin assigned by root at %v", got, err, assignedAt)
+	}
+	if _, err := r.roles.GetRoleAssignment(ctx, "nobody"); !errors.Is(err, internal.ErrRoleAssignmentNotFound) {
+		t.Fatalf("GetRoleAssignment(unknown) error = %v, want %v", err, internal.ErrRoleAssignmentNotFound)
+	}
+
+	assignments, err := r.roles.ListRoleAssignments(ctx)
+	if err != nil {
+		t.Fatalf("ListRoleAssignments: %v", err)
+	}
+	var subjects []string
+	for _, a := range assignments {
+		subjects = append(subjects, a.Subject)
+	}
+	if !slices.Equal(subjects, []string{"key:scanner", "zoe"}) {
+		t.Fatalf("ListRoleAssignments = %v, want [key:scanner zoe]", subjects)
+	}
+
+	// An API key gets the role of "key:" and its name, not that of a JWT
+	// subject named like it; the admins given to the authenticator are
+	// admins whatever their assignment.
+	const key = "box_scanner-secret-key-00000000"
+	if _, err := r.apiKeys.CreateAPIKey(ctx, newAPIKey("scanner", key)); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	authenticator := auth.NewAuthenticator(r.apiKeys, r.roles, nil, auth.JWTConfig{})
+	if p, err := authenticator.Authenticate(ctx, key); err != nil || p.Role != internal.RoleLibrarian {
+		t.Fatalf("Authenticate(scanner key) = %+v, %v, want a librarian", p, err)
+	}
+	authenticator = auth.NewAuthenticator(r.apiKeys, r.roles, []string{"key:scanner"}, auth.JWTConfig{})
+	if p, err := authenticator.Authenticate(ctx, key); err != nil || p.Role != internal.RoleAdmin {
+		t.Fatalf("Authenticate(scanner key, admin) = %+v, %v, want an admin", p, err)
+	}
+
+	if _, err := r.roles.RemoveRoleAssignment(ctx, "key:scanner"); err != nil {
+		t.Fatalf("RemoveRoleAssignment(key:scanner): %v", err)
+	}
+	if _, err := r.roles.RemoveRoleAssignment(ctx, "key:scanner"); !errors.Is(err, internal.ErrRoleAssignmentNotFound) {
+		t.Fatalf("RemoveRoleAssignment(removed) error = %v, want %v", err, internal.ErrRoleAssignmentNotFound)
+	}
+	authenticator = auth.NewAuthenticator(r.apiKeys, r.roles, nil, auth.JWTConfig{})
+	if p, err := authenticator.Authenticate(ctx, key); err != nil || p.Role != internal.RoleReader {
+		t.Fatalf("Authenticate(scanner key, unassigned) = %+v, %v, want a reader", p, err)
+	}
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/amarantec/box/internal"
+)
+
+type IRoleService interface {
+	AssignRole(ctx context.Context, subject string, role internal.Role) (internal.Response[internal.RoleAssignment], error)
+	ListRoleAssignments(ctx context.Context) (internal.Response[[]internal.RoleAssignment], error)
+	RemoveRoleAssignment(ctx context.Context, subject string) (internal.Response[bool], error)
+}
+
+type roleService struct {
+	roleRepo IRoleRepository
+}
+
+func NewRoleService(repository IRoleRepository) IRoleService {
+	return &roleService{roleRepo: repository}
+}
+
+// AssignRole gives role to subject, the actor name of a principal: the sub
+// claim of their JWTs, or "key:" and the name of an API key. It replaces the
+// role they had, and takes effect with their next request.
+func (s *roleService) AssignRole(ctx context.Context, subject string, role internal.Role) (internal.Response[internal.RoleAssignment], error) {
+	var response internal.Response[internal.RoleAssignment]
+
+	subject = strings.TrimSpace(subject)
+	role = internal.Role(strings.ToLower(strings.TrimSpace(string(role))))
+	err := validateAssignment(subject, role)
+	if err == nil {
+		err = checkNotOwnRole(ctx, subject)
+	}
+	if err != nil {
+		response.Data = internal.RoleAssignment{}
+		response.Success = false
+		return response, err
+	}
+
+	a := internal.RoleAssignment{
+		Subject:    subject,
+		Role:       role,
+		AssignedBy: internal.ActorFromContext(ctx),
+		AssignedAt: time.Now(),
+	}
+	if _, err := s.roleRepo.AssignRole(ctx, a); err != nil {
+		response.Data = internal.RoleAssignment{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = a
+	response.Success = true
+	response.Message = "Role assigned successfully."
+	return response, nil
+}
+
+func (s *roleService) ListRoleAssignments(ctx context.Context) (internal.Response[[]internal.RoleAssignment], error) {
+	var response internal.Response[[]internal.RoleAssignment]
+
+	data, err := s.roleRepo.ListRoleAssignments(ctx)
+	if err != nil {
+		response.Data = []internal.RoleAssignment{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Role assignments, by subject. Everyone else is a reader."
+	return response, nil
+}
+
+// RemoveRoleAssignment takes the role of subject away, leaving them a reader.
+func (s *roleService) RemoveRoleAssignment(ctx context.Context, subject string) (internal.Response[bool], error) {
+	var response internal.Response[bool]
+
+	subject = strings.TrimSpace(subject)
+	if err := checkNotOwnRole(ctx, subject); err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.roleRepo.RemoveRoleAssignment(ctx, subject)
+	if err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Role assignment removed successfully."
+	return response, nil
+}
+
+// checkNotOwnRole keeps admins from changing their own role, so that the
+// last of them cannot lock everyone out by mistake. Changes made outside of
+// a request, such as from the command line, are not checked.
+func checkNotOwnRole(ctx context.Context, subject string) error {
+	if p, ok := internal.PrincipalFromContext(ctx); ok && p.Actor() == subject {
+		return internal.ErrOwnRoleChange
+	}
+	return nil
+}
+
+func validateAssignment(subject string, role internal.Role) error {
+	v := &internal.ValidationError{}
+
+	v.Check(subject != internal.EMPTY, "Subject", "must not be empty")
+	v.Check(utf8.RuneCountInString(subject) <= internal.MaxNameLength,
+		"Subject", "must be at most %d characters", internal.MaxNameLength)
+	v.Check(slices.Contains(internal.Roles, role),
+		"Role", "must be one of reader, librarian or admin")
+
+	return v.Err()
+}
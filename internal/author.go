@@ -0,0 +1,6 @@
+package internal
+
+type Author struct {
+	ID   int64
+	Name string
+}
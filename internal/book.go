@@ -2,16 +2,49 @@ package internal
 
 import "time"
 
+// Book references its authors, genres and publisher. When registering or
+// updating a book each reference is given either by ID or by Name; unknown
+// names are created on the fly. Version is incremented on every write and is
+// used for optimistic concurrency: a write carrying a non-zero Version only
+// succeeds if it still matches the stored one.
+//
+// ISBN10 and ISBN13 are optional and may be sent with hyphens. Given either
+// one, the other is filled in; a book whose ISBN-13 does not start with 978
+// has no ISBN-10.
 type Book struct {
 	ID          int64
 	Title       string
+	ISBN10      string
+	ISBN13      string
 	Description string
-	Genre       []string
-	Author      []string
+	Genres      []Genre
+	Authors     []Author
 	PublishDate time.Time
-	Publisher   string
+	Publisher   Publisher
 	Pages       int
 	CreatedAt   time.Time
 	UpdatedAt   *time.Time
 	DeletedAt   *time.Time
+	Version     int64
 }
+
+// Media types accepted when patching a book.
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// BookField names a part of a book that can be written on its own by a
+// partial update.
+type BookField string
+
+const (
+	BookFieldTitle       BookField = "title"
+	BookFieldISBN        BookField = "isbn"
+	BookFieldDescription BookField = "description"
+	BookFieldPublishDate BookField = "publish_date"
+	BookFieldPages       BookField = "pages"
+	BookFieldPublisher   BookField = "publisher"
+	BookFieldGenres      BookField = "genres"
+	BookFieldAuthors     BookField = "authors"
+)
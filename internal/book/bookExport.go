@@ -0,0 +1,331 @@
+package book
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/xuri/excelize/v2"
+)
+
+// exportColumns are the columns of a CSV or XLSX export: the ones a CSV
+// import reads, surrounded by the server managed fields.
+var exportColumns = slices.Concat([]string{"id"}, CSVColumns, CSVOptionalColumns, []string{"created_at", "updated_at", "version"})
+
+const exportSheet = "Books"
+
+// bookWriter writes an export one book at a time. finish completes the
+// document; release frees what the writer holds, whether or not it finished.
+type bookWriter interface {
+	write(b internal.Book) error
+	finish() error
+	release()
+}
+
+func newBookWriter(w io.Writer, format string) (bookWriter, error) {
+	switch format {
+	case internal.ExportFormatCSV:
+		return newCSVBookWriter(w)
+	case internal.ExportFormatNDJSON:
+		return &ndjsonBookWriter{encoder: json.NewEncoder(w)}, nil
+	case internal.ExportFormatXLSX:
+		return newXLSXBookWriter(w)
+	}
+	return nil, internal.ErrUnsupportedExport
+}
+
+// ExportBooks writes every book matching q to w in the given format, in q's
+// order. Pagination fields of q are ignored. The books are streamed from the
+// repository, so an export error may come after part of the document was
+// written.
+func (s *bookService) ExportBooks(ctx context.Context, q internal.BookQuery, format string, w io.Writer) (internal.Response[int64], error) {
+	var response internal.Response[int64]
+
+	q.PageSize, q.Offset, q.Cursor = internal.ZERO, internal.ZERO, internal.EMPTY
+	if err := q.Normalize(); err != nil {
+		response.Data = internal.ZERO
+		response.Success = false
+		return response, err
+	}
+
+	out, err := newBookWriter(w, format)
+	if err != nil {
+		response.Data = internal.ZERO
+		response.Success = false
+		return response, err
+	}
+
+	defer out.release()
+
+	var count int64
+	err = s.bookRepo.ExportBooks(ctx, q, func(b internal.Book) error {
+		count++
+		return out.write(b)
+	})
+	if err == nil {
+		err = out.finish()
+	}
+	if err != nil {
+		response.Data = count
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = count
+	response.Success = true
+	response.Message = "Books exported."
+	return response, nil
+}
+
+func genreNames(b internal.Book) []string {
+	names := make([]string, 0, len(b.Genres))
+	for _, g := range b.Genres {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+func authorNames(b internal.Book) []string {
+	names := make([]string, 0, len(b.Authors))
+	for _, a := range b.Authors {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+type csvBookWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVBookWriter(w io.Writer) (*csvBookWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportColumns); err != nil {
+		return nil, err
+	}
+	return &csvBookWriter{writer: writer}, nil
+}
+
+func (c *csvBookWriter) write(b internal.Book) error {
+	updatedAt := internal.EMPTY
+	if b.UpdatedAt != nil {
+		updatedAt = b.UpdatedAt.Format(time.RFC3339)
+	}
+
+	return c.writer.Write([]string{
+		strconv.FormatInt(b.ID, 10),
+		b.Title,
+		b.Description,
+		b.PublishDate.Format(time.DateOnly),
+		strconv.Itoa(b.Pages),
+		b.Publisher.Name,
+		strings.Join(genreNames(b), CSVListSeparator),
+		strings.Join(authorNames(b), CSVListSeparator),
+		b.ISBN13,
+		b.ISBN10,
+		b.CreatedAt.Format(time.RFC3339),
+		updatedAt,
+		strconv.FormatInt(b.Version, 10),
+	})
+}
+
+func (c *csvBookWriter) finish() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvBookWriter) release() {}
+
+type ndjsonBookWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonBookWriter) write(b internal.Book) error {
+	return n.encoder.Encode(b)
+}
+
+func (n *ndjsonBookWriter) finish() error {
+	return nil
+}
+
+func (n *ndjsonBookWriter) release() {}
+
+// xlsxSheetPart is the zip entry holding the rows of the exported sheet.
+const xlsxSheetPart = "xl/worksheets/sheet1.xml"
+
+// xlsxBookWriter writes a single sheet workbook without holding it in memory.
+// The parts around the sheet, such as its styles, come from an empty
+// workbook made by excelize; the sheet itself is written to w row by row.
+// Nothing is written before the first book, or before finish for an empty
+// export, so that an early error can still be reported.
+type xlsxBookWriter struct {
+	w         io.Writer
+	skeleton  []byte
+	zip       *zip.Writer
+	sheet     io.Writer
+	row       int
+	dateStyle int
+	timeStyle int
+}
+
+func newXLSXBookWriter(w io.Writer) (*xlsxBookWriter, error) {
+	file := excelize.NewFile()
+	defer file.Close()
+	if err := file.SetSheetName("Sheet1", exportSheet); err != nil {
+		return nil, err
+	}
+
+	x := &xlsxBookWriter{w: w, row: 1}
+
+	var err error
+	dateFormat, timeFormat := "yyyy-mm-dd", "yyyy-mm-dd hh:mm:ss"
+	if x.dateStyle, err = file.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat}); err != nil {
+		return nil, err
+	}
+	if x.timeStyle, err = file.NewStyle(&excelize.Style{CustomNumFmt: &timeFormat}); err != nil {
+		return nil, err
+	}
+
+	skeleton, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	x.skeleton = skeleton.Bytes()
+	return x, nil
+}
+
+// start writes the parts of the skeleton but its sheet, then opens the sheet
+// and writes the header row.
+func (x *xlsxBookWriter) start() error {
+	skeleton, err := zip.NewReader(bytes.NewReader(x.skeleton), int64(len(x.skeleton)))
+	if err != nil {
+		return err
+	}
+
+	x.zip = zip.NewWriter(x.w)
+	for _, part := range skeleton.File {
+		if part.Name == xlsxSheetPart {
+			continue
+		}
+		if err := x.zip.Copy(part); err != nil {
+			return err
+		}
+	}
+
+	if x.sheet, err = x.zip.Create(xlsxSheetPart); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(x.sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+
+	header := make([]any, 0, len(exportColumns))
+	for _, name := range exportColumns {
+		header = append(header, name)
+	}
+	return x.setRow(header)
+}
+
+// setRow writes a row of strings, integers and excelize.Cell dates; nil
+// values are left empty.
+func (x *xlsxBookWriter) setRow(values []any) error {
+	var row bytes.Buffer
+	fmt.Fprintf(&row, `<row r="%d">`, x.row)
+
+	for n, value := range values {
+		cell, err := excelize.CoordinatesToCellName(n+1, x.row)
+		if err != nil {
+			return err
+		}
+
+		switch v := value.(type) {
+		case nil:
+		case string:
+			fmt.Fprintf(&row, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, cell)
+			if err := xml.EscapeText(&row, []byte(v)); err != nil {
+				return err
+			}
+			row.WriteString(`</t></is></c>`)
+		case int:
+			fmt.Fprintf(&row, `<c r="%s"><v>%d</v></c>`, cell, v)
+		case int64:
+			fmt.Fprintf(&row, `<c r="%s"><v>%d</v></c>`, cell, v)
+		case excelize.Cell:
+			t, ok := v.Value.(time.Time)
+			if !ok {
+				return fmt.Errorf("xlsx export: unsupported cell value %T", v.Value)
+			}
+			fmt.Fprintf(&row, `<c r="%s" s="%d"><v>%s</v></c>`, cell, v.StyleID, strconv.FormatFloat(excelTime(t), 'f', -1, 64))
+		default:
+			return fmt.Errorf("xlsx export: unsupported value %T", value)
+		}
+	}
+
+	row.WriteString(`</row>`)
+	x.row++
+	_, err := x.sheet.Write(row.Bytes())
+	return err
+}
+
+// excelEpoch is day zero of the 1900 date system, as counted by Excel for
+// every date after February 1900.
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// excelTime returns the serial number Excel stores for the wall clock time
+// of t.
+func excelTime(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return float64(wall.Sub(excelEpoch)) / float64(24*time.Hour)
+}
+
+func (x *xlsxBookWriter) write(b internal.Book) error {
+	if x.zip == nil {
+		if err := x.start(); err != nil {
+			return err
+		}
+	}
+
+	var updatedAt any
+	if b.UpdatedAt != nil {
+		updatedAt = excelize.Cell{StyleID: x.timeStyle, Value: *b.UpdatedAt}
+	}
+
+	return x.setRow([]any{
+		b.ID,
+		b.Title,
+		b.Description,
+		excelize.Cell{StyleID: x.dateStyle, Value: b.PublishDate},
+		b.Pages,
+		b.Publisher.Name,
+		strings.Join(genreNames(b), CSVListSeparator),
+		strings.Join(authorNames(b), CSVListSeparator),
+		b.ISBN13,
+		b.ISBN10,
+		excelize.Cell{StyleID: x.timeStyle, Value: b.CreatedAt},
+		updatedAt,
+		b.Version,
+	})
+}
+
+func (x *xlsxBookWriter) finish() error {
+	if x.zip == nil {
+		if err := x.start(); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+func (x *xlsxBookWriter) release() {}
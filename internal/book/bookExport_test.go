@@ -0,0 +1,90 @@
+package book_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/book"
+	"github.com/amarantec/box/internal/inventory"
+	"github.com/amarantec/box/internal/reference"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExportBooksXLSX(t *testing.T) {
+	ctx := context.Background()
+	copies := inventory.NewMemoryCopyRepository()
+	service := book.NewBookService(book.NewMemoryBookRepository(
+		reference.NewMemoryRepository(reference.Authors),
+		reference.NewMemoryRepository(reference.Genres),
+		reference.NewMemoryRepository(reference.Publishers),
+		copies,
+	), copies)
+
+	for _, title := range []string{"Dune", "Tales <&> Co"} {
+		if _, err := service.RegisterBook(ctx, internal.Book{
+			Title:       title,
+			Description: "A book.",
+			Genres:      []internal.Genre{{Name: "Fiction"}},
+			Authors:     []internal.Author{{Name: "Frank Herbert"}, {Name: "Brian Herbert"}},
+			PublishDate: time.Date(1965, time.August, 1, 0, 0, 0, 0, time.UTC),
+			Publisher:   internal.Publisher{Name: "Chilton"},
+			Pages:       412,
+		}); err != nil {
+			t.Fatalf("RegisterBook(%q): %v", title, err)
+		}
+	}
+
+	var out bytes.Buffer
+	response, err := service.ExportBooks(ctx, internal.BookQuery{SortBy: "title"}, internal.ExportFormatXLSX, &out)
+	if err != nil || response.Data != 2 {
+		t.Fatalf("ExportBooks = %d, %v, want 2 books", response.Data, err)
+	}
+
+	file, err := excelize.OpenReader(&out)
+	if err != nil {
+		t.Fatalf("open export: %v", err)
+	}
+	defer file.Close()
+
+	rows, err := file.GetRows("Books")
+	if err != nil {
+		t.Fatalf("GetRows: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("export has %d rows, want a header and 2 books: %v", len(rows), rows)
+	}
+	if rows[0][1] != "title" || rows[1][1] != "Dune" || rows[2][1] != "Tales <&> Co" {
+		t.Fatalf("titles = %q, %q, %q, want title, Dune, Tales <&> Co", rows[0][1], rows[1][1], rows[2][1])
+	}
+	if rows[1][3] != "1965-08-01" || rows[1][4] != "412" || rows[1][7] != "Frank Herbert|Brian Herbert" {
+		t.Fatalf("first book row = %q", rows[1])
+	}
+}
+
+func TestExportBooksXLSXEmpty(t *testing.T) {
+	copies := inventory.NewMemoryCopyRepository()
+	service := book.NewBookService(book.NewMemoryBookRepository(
+		reference.NewMemoryRepository(reference.Authors),
+		reference.NewMemoryRepository(reference.Genres),
+		reference.NewMemoryRepository(reference.Publishers),
+		copies,
+	), copies)
+
+	var out bytes.Buffer
+	if _, err := service.ExportBooks(context.Background(), internal.BookQuery{}, internal.ExportFormatXLSX, &out); err != nil {
+		t.Fatalf("ExportBooks: %v", err)
+	}
+
+	file, err := excelize.OpenReader(&out)
+	if err != nil {
+		t.Fatalf("open export: %v", err)
+	}
+	defer file.Close()
+
+	if rows, err := file.GetRows("Books"); err != nil || len(rows) != 1 {
+		t.Fatalf("GetRows = %v, %v, want the header only", rows, err)
+	}
+}
@@ -0,0 +1,141 @@
+package book
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+
+	"github.com/amarantec/box/internal"
+)
+
+// diffIgnoredFields change on every write and would only add noise to a diff.
+var diffIgnoredFields = []string{"UpdatedAt", "Version"}
+
+// diffBooks lists the top-level fields that differ between two snapshots,
+// compared in their JSON form. A nil snapshot has no fields.
+func diffBooks(from, to *internal.Book) ([]internal.FieldChange, error) {
+	fromFields, err := bookFields(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := bookFields(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range fromFields {
+		names = append(names, name)
+	}
+	for name := range toFields {
+		if _, ok := fromFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	changes := []internal.FieldChange{}
+	for _, name := range names {
+		if slices.Contains(diffIgnoredFields, name) || reflect.DeepEqual(fromFields[name], toFields[name]) {
+			continue
+		}
+		changes = append(changes, internal.FieldChange{Field: name, From: fromFields[name], To: toFields[name]})
+	}
+	return changes, nil
+}
+
+func bookFields(b *internal.Book) (map[string]any, error) {
+	fields := map[string]any{}
+	if b == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func withChanges(rev internal.BookRevision) (internal.BookRevision, error) {
+	changes, err := diffBooks(rev.Before, rev.After)
+	if err != nil {
+		return internal.BookRevision{}, err
+	}
+	rev.Changes = changes
+	return rev, nil
+}
+
+func (s *bookService) ListBookHistory(ctx context.Context, bookId int64) (internal.Response[[]internal.BookRevision], error) {
+	var response internal.Response[[]internal.BookRevision]
+
+	revisions, err := s.bookRepo.ListBookRevisions(ctx, bookId)
+	if err != nil {
+		response.Data = []internal.BookRevision{}
+		response.Success = false
+		return response, err
+	}
+
+	for i := range revisions {
+		if revisions[i], err = withChanges(revisions[i]); err != nil {
+			response.Data = []internal.BookRevision{}
+			response.Success = false
+			return response, err
+		}
+	}
+
+	response.Data = revisions
+	response.Success = true
+	response.Message = "Book history, oldest change first."
+	return response, nil
+}
+
+func (s *bookService) GetBookRevision(ctx context.Context, bookId int64, revisionId int64) (internal.Response[internal.BookRevision], error) {
+	var response internal.Response[internal.BookRevision]
+
+	rev, err := s.bookRepo.GetBookRevision(ctx, bookId, revisionId)
+	if err == nil {
+		rev, err = withChanges(rev)
+	}
+	if err != nil {
+		response.Data = internal.BookRevision{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = rev
+	response.Success = true
+	response.Message = "Book revision found successfully."
+	return response, nil
+}
+
+// DiffBookRevisions compares the book as stored after revision from with the
+// book as stored after revision to.
+func (s *bookService) DiffBookRevisions(ctx context.Context, bookId int64, from int64, to int64) (internal.Response[internal.BookDiff], error) {
+	var response internal.Response[internal.BookDiff]
+
+	diff := internal.BookDiff{BookID: bookId, From: from, To: to}
+
+	fromRev, err := s.bookRepo.GetBookRevision(ctx, bookId, from)
+	var toRev internal.BookRevision
+	if err == nil {
+		toRev, err = s.bookRepo.GetBookRevision(ctx, bookId, to)
+	}
+	if err == nil {
+		diff.Changes, err = diffBooks(fromRev.After, toRev.After)
+	}
+	if err != nil {
+		response.Data = internal.BookDiff{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = diff
+	response.Success = true
+	response.Message = "Changes between the two revisions."
+	return response, nil
+}
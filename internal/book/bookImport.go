@@ -0,0 +1,215 @@
+package book
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
+)
+
+// bookImportRun is the state of one ImportBooks call.
+type bookImportRun struct {
+	opts   internal.ImportOptions
+	tx     IBookImport
+	report internal.ImportReport
+
+	// batch holds the valid books not stored yet and rows the index of
+	// their row in the report.
+	batch []internal.Book
+	rows  []int
+}
+
+// ImportBooks registers every book read from r in a single transaction and
+// reports the outcome of each row. Rows are validated like RegisterBook and
+// stored in batches of opts.BatchSize. A failing row either rolls the whole
+// import back or, with opts.BestEffort, is left out. Server managed fields in
+// a row, such as ID, are ignored so that an export can be imported into
+// another catalog.
+//
+// Genres, authors and publishers referenced by name are registered within
+// the import transaction, so they are rolled back along with the books.
+func (s *bookService) ImportBooks(ctx context.Context, r io.Reader, opts internal.ImportOptions) (internal.Response[internal.ImportReport], error) {
+	var response internal.Response[internal.ImportReport]
+
+	if err := opts.Normalize(); err != nil {
+		response.Data = internal.ImportReport{}
+		response.Success = false
+		return response, err
+	}
+
+	rows, err := newImportReader(r, opts.Format)
+	if err != nil {
+		response.Data = internal.ImportReport{}
+		response.Success = false
+		return response, err
+	}
+
+	tx, err := s.bookRepo.BeginImport(ctx)
+	if err != nil {
+		response.Data = internal.ImportReport{}
+		response.Success = false
+		return response, err
+	}
+	defer tx.Rollback(ctx)
+
+	run := &bookImportRun{opts: opts, tx: tx, report: internal.ImportReport{Rows: []internal.ImportRow{}}}
+	if err := run.importRows(ctx, rows); err != nil {
+		response.Data = internal.ImportReport{}
+		response.Success = false
+		return response, err
+	}
+
+	report := run.report
+	if report.Failed > internal.ZERO && !opts.BestEffort {
+		for i := range report.Rows {
+			if report.Rows[i].Status == internal.ImportRowImported {
+				report.Rows[i].Status = internal.ImportRowRolledBack
+				report.Rows[i].BookID = internal.ZERO
+			}
+		}
+		report.Imported = internal.ZERO
+
+		response.Data = report
+		response.Success = false
+		response.Message = "Import rolled back, no books were imported."
+		return response, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		response.Data = internal.ImportReport{}
+		response.Success = false
+		return response, err
+	}
+	report.Committed = true
+
+	log.Printf("Imported %d books, %d rows failed.\n", report.Imported, report.Failed)
+
+	response.Data = report
+	response.Success = true
+	response.Message = "Books imported."
+	return response, nil
+}
+
+func (run *bookImportRun) importRows(ctx context.Context, rows importReader) error {
+	now := time.Now()
+
+	for {
+		record, err := rows.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		row := internal.ImportRow{Line: record.line}
+		b := record.book
+		b.ID, b.CreatedAt, b.UpdatedAt, b.DeletedAt, b.Version = internal.ZERO, time.Time{}, nil, nil, internal.ZERO
+
+		// Once an all-or-nothing import has failed, the remaining rows are
+		// still checked but no longer stored.
+		doomed := run.report.Failed > internal.ZERO && !run.opts.BestEffort
+
+		err = record.err
+		if err == nil {
+			normalizeBook(&b)
+			err = validateBook(b, false, now)
+		}
+
+		switch {
+		case err != nil:
+			if !isRowError(err) {
+				return err
+			}
+			run.fail(&row, err)
+		case doomed:
+			row.Status = internal.ImportRowRolledBack
+		default:
+			run.batch = append(run.batch, b)
+			run.rows = append(run.rows, len(run.report.Rows))
+		}
+		run.report.Rows = append(run.report.Rows, row)
+
+		if len(run.batch) >= run.opts.BatchSize {
+			if err := run.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return run.flush(ctx)
+}
+
+// flush stores the pending batch. When the batch is refused as a whole, its
+// books are stored one at a time to find the rows at fault.
+func (run *bookImportRun) flush(ctx context.Context) error {
+	batch, rows := run.batch, run.rows
+	run.batch, run.rows = nil, nil
+	if len(batch) == internal.ZERO {
+		return nil
+	}
+
+	if len(batch) > 1 {
+		bookIds, err := run.tx.InsertBooks(ctx, batch)
+		if err == nil {
+			for n, i := range rows {
+				run.imported(&run.report.Rows[i], bookIds[n])
+			}
+			return nil
+		}
+	}
+
+	for n, i := range rows {
+		bookIds, err := run.tx.InsertBooks(ctx, batch[n:n+1])
+		if err != nil {
+			if !isRowError(err) {
+				return err
+			}
+			run.fail(&run.report.Rows[i], err)
+			continue
+		}
+		run.imported(&run.report.Rows[i], bookIds[0])
+	}
+	return nil
+}
+
+func (run *bookImportRun) imported(row *internal.ImportRow, bookId int64) {
+	row.Status = internal.ImportRowImported
+	row.BookID = bookId
+	run.report.Imported++
+}
+
+func (run *bookImportRun) fail(row *internal.ImportRow, err error) {
+	row.Status = internal.ImportRowFailed
+	run.report.Failed++
+
+	var validationErr *internal.ValidationError
+	if errors.As(err, &validationErr) {
+		row.Code = "validation_failed"
+		row.Error = "One or more fields are invalid."
+		row.Errors = validationErr.Errors
+		return
+	}
+
+	var domainErr *internal.Error
+	if !errors.As(err, &domainErr) {
+		// The database error names tables and constraints; it is logged
+		// rather than reported.
+		log.Printf("Import row %d rejected: %v\n", row.Line, err)
+		err, domainErr = internal.ErrImportRowRejected, internal.ErrImportRowRejected
+	}
+	row.Code = domainErr.Code
+	row.Error = err.Error()
+}
+
+// isRowError reports whether err is about the row being imported rather
+// than the import as a whole, such as a lost database connection.
+func isRowError(err error) bool {
+	var validationErr *internal.ValidationError
+	var domainErr *internal.Error
+	return errors.As(err, &validationErr) || errors.As(err, &domainErr) || database.IsRejectedRow(err)
+}
@@ -0,0 +1,204 @@
+package book
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amarantec/box/internal"
+)
+
+// CSVListSeparator separates the genres and authors of a CSV row.
+const CSVListSeparator = "|"
+
+// CSVColumns are the columns a CSV import must have, in the order an export
+// writes them after the book ID. Other columns are ignored.
+var CSVColumns = []string{"title", "description", "publish_date", "pages", "publisher", "genres", "authors"}
+
+// CSVOptionalColumns are read by a CSV import when present and written by an
+// export after CSVColumns.
+var CSVOptionalColumns = []string{"isbn13", "isbn10"}
+
+// maxImportLine bounds an NDJSON line.
+const maxImportLine = 1 << 20
+
+// importRecord is a row read from an import. err is set when the row itself
+// could not be read; reading can carry on with the next row.
+type importRecord struct {
+	line int
+	book internal.Book
+	err  error
+}
+
+// importReader reads the rows of an import one at a time, returning io.EOF
+// after the last one. Any other error means the source cannot be read on.
+type importReader interface {
+	next() (importRecord, error)
+}
+
+func newImportReader(r io.Reader, format string) (importReader, error) {
+	switch format {
+	case internal.ImportFormatCSV:
+		return newCSVImportReader(r)
+	case internal.ImportFormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+		return &ndjsonImportReader{scanner: scanner}, nil
+	}
+	return nil, internal.ErrUnsupportedImport
+}
+
+type csvImportReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: the CSV has no header row", internal.ErrInvalidImport)
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, fmt.Errorf("%w: %v", internal.ErrInvalidImport, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		if i == internal.ZERO {
+			// Spreadsheets often start the file with a byte order mark.
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	var missing []string
+	for _, name := range CSVColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > internal.ZERO {
+		return nil, fmt.Errorf("%w: missing CSV columns %s", internal.ErrInvalidImport, strings.Join(missing, ", "))
+	}
+
+	return &csvImportReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvImportReader) next() (importRecord, error) {
+	fields, err := c.reader.Read()
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return importRecord{line: parseErr.StartLine, err: fmt.Errorf("%w: %v", internal.ErrInvalidImportRow, parseErr.Err)}, nil
+	}
+	if err != nil {
+		return importRecord{}, err
+	}
+
+	line, _ := c.reader.FieldPos(internal.ZERO)
+	b, err := c.book(fields)
+	return importRecord{line: line, book: b, err: err}, nil
+}
+
+func (c *csvImportReader) book(fields []string) (internal.Book, error) {
+	get := func(name string) string {
+		i, ok := c.columns[name]
+		if !ok {
+			return internal.EMPTY
+		}
+		return fields[i]
+	}
+
+	b := internal.Book{
+		Title:       get("title"),
+		ISBN10:      get("isbn10"),
+		ISBN13:      get("isbn13"),
+		Description: get("description"),
+		Publisher:   internal.Publisher{Name: get("publisher")},
+	}
+	for _, name := range splitCSVList(get("genres")) {
+		b.Genres = append(b.Genres, internal.Genre{Name: name})
+	}
+	for _, name := range splitCSVList(get("authors")) {
+		b.Authors = append(b.Authors, internal.Author{Name: name})
+	}
+
+	v := &internal.ValidationError{}
+
+	if pages := strings.TrimSpace(get("pages")); pages != internal.EMPTY {
+		var err error
+		if b.Pages, err = strconv.Atoi(pages); err != nil {
+			v.Add("Pages", "must be a whole number")
+		}
+	}
+
+	if date := strings.TrimSpace(get("publish_date")); date != internal.EMPTY {
+		var err error
+		if b.PublishDate, err = parseImportDate(date); err != nil {
+			v.Add("PublishDate", "must be a date such as 2006-01-02")
+		}
+	}
+
+	return b, v.Err()
+}
+
+func splitCSVList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, CSVListSeparator) {
+		if item = strings.TrimSpace(item); item != internal.EMPTY {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseImportDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (n *ndjsonImportReader) next() (importRecord, error) {
+	for n.scanner.Scan() {
+		n.line++
+
+		data := n.scanner.Bytes()
+		if len(bytes.TrimSpace(data)) == internal.ZERO {
+			continue
+		}
+
+		var b internal.Book
+		if err := json.Unmarshal(data, &b); err != nil {
+			return importRecord{line: n.line, err: fmt.Errorf("%w: %v", internal.ErrInvalidImportRow, err)}, nil
+		}
+		return importRecord{line: n.line, book: b}, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return importRecord{}, fmt.Errorf("%w: line %d is longer than %d bytes", internal.ErrInvalidImport, n.line+1, maxImportLine)
+		}
+		return importRecord{}, err
+	}
+	return importRecord{}, io.EOF
+}
@@ -0,0 +1,117 @@
+package book
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/reference"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// applyBookPatch applies a JSON Merge Patch (RFC 7396) or JSON Patch
+// (RFC 6902) document to the JSON form of current.
+func applyBookPatch(current internal.Book, contentType string, patch []byte) (internal.Book, error) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return internal.Book{}, err
+	}
+
+	var patched []byte
+	switch contentType {
+	case internal.MergePatchContentType:
+		patched, err = jsonpatch.MergePatch(doc, patch)
+	case internal.JSONPatchContentType:
+		var ops jsonpatch.Patch
+		if ops, err = jsonpatch.DecodePatch(patch); err == nil {
+			patched, err = ops.Apply(doc)
+		}
+	default:
+		return internal.Book{}, internal.ErrUnsupportedPatch
+	}
+
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return internal.Book{}, internal.ErrPatchTestFailed
+	}
+	if err != nil {
+		return internal.Book{}, fmt.Errorf("%w: %v", internal.ErrInvalidPatch, err)
+	}
+
+	var b internal.Book
+	if err := json.Unmarshal(patched, &b); err != nil {
+		return internal.Book{}, fmt.Errorf("%w: %v", internal.ErrInvalidPatch, err)
+	}
+	return b, nil
+}
+
+// checkManagedFields reports a patch that changes the ID or the timestamps
+// the server maintains.
+func checkManagedFields(current, patched internal.Book) error {
+	v := &internal.ValidationError{}
+
+	v.Check(patched.ID == current.ID, "ID", "must not be changed")
+	v.Check(patched.Version == current.Version, "Version", "must not be changed; send If-Match instead")
+	v.Check(patched.CreatedAt.Equal(current.CreatedAt), "CreatedAt", "is managed by the server and must not be changed")
+	v.Check(sameTime(patched.UpdatedAt, current.UpdatedAt), "UpdatedAt", "is managed by the server and must not be changed")
+	v.Check(sameTime(patched.DeletedAt, current.DeletedAt), "DeletedAt", "is managed by the server and must not be changed")
+
+	return v.Err()
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// dropStaleISBN clears the ISBN a patch left alone when it changed the other
+// one, so that it is derived again rather than contradict the new value.
+func dropStaleISBN(current internal.Book, patched *internal.Book) {
+	changed10 := internal.NormalizeISBN(patched.ISBN10) != current.ISBN10
+	changed13 := internal.NormalizeISBN(patched.ISBN13) != current.ISBN13
+
+	switch {
+	case changed13 && !changed10:
+		patched.ISBN10 = internal.EMPTY
+	case changed10 && !changed13:
+		patched.ISBN13 = internal.EMPTY
+	}
+}
+
+// changedBookFields lists the fields of patched that differ from current.
+// The references of patched may be given by ID or by name, those of current
+// must be resolved.
+func changedBookFields(current, patched internal.Book) []internal.BookField {
+	var fields []internal.BookField
+
+	if patched.Title != current.Title {
+		fields = append(fields, internal.BookFieldTitle)
+	}
+	if patched.ISBN10 != current.ISBN10 || patched.ISBN13 != current.ISBN13 {
+		fields = append(fields, internal.BookFieldISBN)
+	}
+	if patched.Description != current.Description {
+		fields = append(fields, internal.BookFieldDescription)
+	}
+	if !patched.PublishDate.Equal(current.PublishDate) {
+		fields = append(fields, internal.BookFieldPublishDate)
+	}
+	if patched.Pages != current.Pages {
+		fields = append(fields, internal.BookFieldPages)
+	}
+	if !reference.Same(patched.Publisher, current.Publisher) {
+		fields = append(fields, internal.BookFieldPublisher)
+	}
+	if !slices.EqualFunc(patched.Genres, current.Genres, reference.Same) {
+		fields = append(fields, internal.BookFieldGenres)
+	}
+	if !slices.EqualFunc(patched.Authors, current.Authors, reference.Same) {
+		fields = append(fields, internal.BookFieldAuthors)
+	}
+
+	return fields
+}
@@ -0,0 +1,142 @@
+package book
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amarantec/box/internal"
+)
+
+// queryBuilder accumulates WHERE conditions and their positional arguments.
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// arg registers v as the next positional argument and returns its placeholder.
+func (b *queryBuilder) arg(v any) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == internal.ZERO {
+		return internal.EMPTY
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+func bookFilters(q internal.BookQuery) *queryBuilder {
+	b := &queryBuilder{}
+	b.where("b.deleted_at IS NULL")
+
+	if q.Publisher != internal.EMPTY {
+		b.where("lower(p.name) = lower(" + b.arg(q.Publisher) + ")")
+	}
+	if q.PublishedFrom != nil {
+		b.where("b.publish_date >= " + b.arg(*q.PublishedFrom))
+	}
+	if q.PublishedTo != nil {
+		b.where("b.publish_date <= " + b.arg(*q.PublishedTo))
+	}
+	if q.MinPages != nil {
+		b.where("b.pages >= " + b.arg(*q.MinPages))
+	}
+	if q.MaxPages != nil {
+		b.where("b.pages <= " + b.arg(*q.MaxPages))
+	}
+	if q.Genre != internal.EMPTY {
+		b.where(`EXISTS (SELECT 1 FROM book_genres bg JOIN genres g ON g.id = bg.genre_id
+            WHERE bg.book_id = b.id AND lower(g.name) = lower(` + b.arg(q.Genre) + `))`)
+	}
+	if q.Author != internal.EMPTY {
+		b.where(`EXISTS (SELECT 1 FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = b.id AND lower(a.name) = lower(` + b.arg(q.Author) + `))`)
+	}
+
+	return b
+}
+
+// bookCursor points just past the last row of a page, for keyset pagination
+// on (sort column, id).
+type bookCursor struct {
+	SortBy string          `json:"s"`
+	Value  json.RawMessage `json:"v,omitempty"`
+	ID     int64           `json:"id"`
+}
+
+func encodeBookCursor(sortBy string, b internal.Book) (string, error) {
+	var value any
+	switch sortBy {
+	case "title":
+		value = b.Title
+	case "publish_date":
+		value = b.PublishDate
+	case "pages":
+		value = b.Pages
+	case "created_at":
+		value = b.CreatedAt
+	}
+
+	c := bookCursor{SortBy: sortBy, ID: b.ID}
+	if value != nil {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return internal.EMPTY, err
+		}
+		c.Value = raw
+	}
+
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return internal.EMPTY, err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeBookCursor returns the sort value stored in cursor, typed to match the
+// column it is compared against.
+func decodeBookCursor(cursor string, sortBy string) (any, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, internal.ZERO, fmt.Errorf("%w: malformed cursor", internal.ErrInvalidBookQuery)
+	}
+
+	var c bookCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, internal.ZERO, fmt.Errorf("%w: malformed cursor", internal.ErrInvalidBookQuery)
+	}
+
+	if c.SortBy != sortBy {
+		return nil, internal.ZERO, fmt.Errorf("%w: cursor was issued for sort %q", internal.ErrInvalidBookQuery, c.SortBy)
+	}
+
+	var value any
+	switch sortBy {
+	case "title":
+		var v string
+		err = json.Unmarshal(c.Value, &v)
+		value = v
+	case "publish_date", "created_at":
+		var v time.Time
+		err = json.Unmarshal(c.Value, &v)
+		value = v
+	case "pages":
+		var v int
+		err = json.Unmarshal(c.Value, &v)
+		value = v
+	}
+	if err != nil {
+		return nil, internal.ZERO, fmt.Errorf("%w: malformed cursor", internal.ErrInvalidBookQuery)
+	}
+
+	return value, c.ID, nil
+}
@@ -0,0 +1,86 @@
+package book
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/reference"
+	"github.com/jackc/pgx/v5"
+)
+
+// bookReferences finds the genres, authors and publisher a book refers to.
+type bookReferences struct {
+	genres     reference.Resolver[internal.Genre]
+	authors    reference.Resolver[internal.Author]
+	publishers reference.Resolver[internal.Publisher]
+}
+
+// txReferences resolves references within tx, so that the genres, authors
+// and publishers registered for a book are rolled back with it.
+func txReferences(tx pgx.Tx) bookReferences {
+	return bookReferences{
+		genres:     reference.NewTxResolver(tx, reference.Genres),
+		authors:    reference.NewTxResolver(tx, reference.Authors),
+		publishers: reference.NewTxResolver(tx, reference.Publishers),
+	}
+}
+
+// resolve replaces every genre, author and publisher reference in b with the
+// stored entity, dropping duplicates. References by ID must exist, and
+// unknown IDs are reported as a *internal.ValidationError before any name is
+// registered; references by name are matched case-insensitively and
+// registered when missing. b must already be normalized and validated.
+func (refs bookReferences) resolve(ctx context.Context, b *internal.Book) error {
+	if err := refs.lookup(ctx, b); err != nil {
+		return err
+	}
+	return refs.ensure(ctx, b)
+}
+
+// lookup replaces the references in b given by ID, leaving the ones given by
+// name to ensure.
+func (refs bookReferences) lookup(ctx context.Context, b *internal.Book) error {
+	v := &internal.ValidationError{}
+
+	publisher, missing, err := reference.Lookup(ctx, refs.publishers, []internal.Publisher{b.Publisher})
+	if err != nil {
+		return err
+	}
+	for range missing {
+		v.Add("Publisher.ID", "does not exist")
+	}
+	b.Publisher = publisher[0]
+
+	if b.Genres, missing, err = reference.Lookup(ctx, refs.genres, b.Genres); err != nil {
+		return err
+	}
+	for _, i := range missing {
+		v.Add(fmt.Sprintf("Genres[%d].ID", i), "does not exist")
+	}
+
+	if b.Authors, missing, err = reference.Lookup(ctx, refs.authors, b.Authors); err != nil {
+		return err
+	}
+	for _, i := range missing {
+		v.Add(fmt.Sprintf("Authors[%d].ID", i), "does not exist")
+	}
+
+	return v.Err()
+}
+
+// ensure replaces the references in b given by name, registering the missing
+// ones.
+func (refs bookReferences) ensure(ctx context.Context, b *internal.Book) error {
+	publisher, err := reference.EnsureAll(ctx, refs.publishers, []internal.Publisher{b.Publisher})
+	if err != nil {
+		return err
+	}
+	b.Publisher = publisher[0]
+
+	if b.Genres, err = reference.EnsureAll(ctx, refs.genres, b.Genres); err != nil {
+		return err
+	}
+	b.Authors, err = reference.EnsureAll(ctx, refs.authors, b.Authors)
+	return err
+}
@@ -2,22 +2,229 @@ package book
 
 import (
 	"context"
+	"errors"
+	"html"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// IBookRepository stores books. The genres, authors and publisher of a book
+// being written may be given by ID or by name; they are resolved as the book
+// is written and only stay registered if it is. A book with a copy on loan
+// can be neither deleted nor purged.
 type IBookRepository interface {
 	RegisterBook(ctx context.Context, b internal.Book) (int64, error)
-	ListBooks(ctx context.Context) ([]internal.Book, error)
+	ListBooks(ctx context.Context, q internal.BookQuery) ([]internal.Book, internal.Pagination, error)
 	GetBookById(ctx context.Context, bookId int64) (internal.Book, error)
+	GetBookByISBN(ctx context.Context, isbn13 string) (internal.Book, error)
 	UpdateBook(ctx context.Context, book internal.Book) (bool, error)
-	DeleteBook(ctx context.Context, bookId int64) (bool, error)
+	PatchBook(ctx context.Context, b internal.Book, fields []internal.BookField) (bool, error)
+	DeleteBook(ctx context.Context, bookId int64, version int64) (bool, error)
 	ListBooksByGenre(ctx context.Context, genre string) ([]internal.Book, error)
 	ListBooksByAuthor(ctx context.Context, author string) ([]internal.Book, error)
+	SearchBooks(ctx context.Context, q internal.BookSearchQuery) ([]internal.BookSearchResult, internal.Pagination, error)
+	ListBookRevisions(ctx context.Context, bookId int64) ([]internal.BookRevision, error)
+	GetBookRevision(ctx context.Context, bookId int64, revisionId int64) (internal.BookRevision, error)
+	ListDeletedBooks(ctx context.Context, q internal.DeletedBookQuery) ([]internal.Book, internal.Pagination, error)
+	RestoreBook(ctx context.Context, bookId int64, version int64) (bool, error)
+	PurgeBook(ctx context.Context, bookId int64) (bool, error)
+	PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time) (int64, error)
+	BeginImport(ctx context.Context) (IBookImport, error)
+	ExportBooks(ctx context.Context, q internal.BookQuery, fn func(internal.Book) error) error
+}
+
+// IBookImport registers books in bulk within one transaction. Each call to
+// InsertBooks stores all of its books or none of them, without affecting the
+// earlier calls. Nothing is visible to others until Commit; Rollback after
+// Commit does nothing.
+type IBookImport interface {
+	InsertBooks(ctx context.Context, books []internal.Book) ([]int64, error)
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// bookColumns selects a book with its publisher, genres and authors, in the
+// order read by scanBook. It expects books aliased as b and publishers as p.
+const bookColumns = `b.id, b.title, COALESCE(b.isbn10, ''), COALESCE(b.isbn13, ''), b.description, b.publish_date, b.pages, b.created_at, b.updated_at, b.version,
+    p.id, p.name,
+    COALESCE((SELECT json_agg(json_build_object('ID', g.id, 'Name', g.name) ORDER BY bg.position)
+        FROM book_genres bg JOIN genres g ON g.id = bg.genre_id WHERE bg.book_id = b.id), '[]'),
+    COALESCE((SELECT json_agg(json_build_object('ID', a.id, 'Name', a.name) ORDER BY ba.position)
+        FROM book_authors ba JOIN authors a ON a.id = ba.author_id WHERE ba.book_id = b.id), '[]')`
+
+const bookFrom = ` FROM books b JOIN publishers p ON p.id = b.publisher_id`
+
+// scanBook reads the columns listed in bookColumns, followed by extra.
+func scanBook(row pgx.Row, extra ...any) (internal.Book, error) {
+	var b internal.Book
+	dest := append([]any{
+		&b.ID,
+		&b.Title,
+		&b.ISBN10,
+		&b.ISBN13,
+		&b.Description,
+		&b.PublishDate,
+		&b.Pages,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+		&b.Version,
+		&b.Publisher.ID,
+		&b.Publisher.Name,
+		&b.Genres,
+		&b.Authors,
+	}, extra...)
+
+	if err := row.Scan(dest...); err != nil {
+		return internal.Book{}, err
+	}
+	return b, nil
+}
+
+func collectBooks(rows pgx.Rows) ([]internal.Book, error) {
+	defer rows.Close()
+
+	var books []internal.Book
+	for rows.Next() {
+		b, err := scanBook(rows)
+		if err != nil {
+			return []internal.Book{}, err
+		}
+		books = append(books, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []internal.Book{}, err
+	}
+	return books, nil
+}
+
+// insertBookReferences links a book to its genres and authors, keeping the
+// order they were given in.
+func insertBookReferences(ctx context.Context, tx pgx.Tx, b internal.Book) error {
+	if err := insertBookGenres(ctx, tx, b); err != nil {
+		return err
+	}
+	return insertBookAuthors(ctx, tx, b)
+}
+
+func insertBookGenres(ctx context.Context, tx pgx.Tx, b internal.Book) error {
+	for position, g := range b.Genres {
+		if _, err := tx.Exec(
+			ctx,
+			`INSERT INTO book_genres (book_id, genre_id, position) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING;`, b.ID, g.ID, position); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertBookAuthors(ctx context.Context, tx pgx.Tx, b internal.Book) error {
+	for position, a := range b.Authors {
+		if _, err := tx.Exec(
+			ctx,
+			`INSERT INTO book_authors (book_id, author_id, position) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING;`, b.ID, a.ID, position); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// booksISBNKey is the unique index keeping two live books from sharing an
+// ISBN.
+const booksISBNKey = "books_isbn13_key"
+
+// isbnArg stores a book without an ISBN as NULL, which the unique index
+// ignores.
+func isbnArg(isbn string) *string {
+	if isbn == internal.EMPTY {
+		return nil
+	}
+	return &isbn
+}
+
+// isbnConflict reports a write refused by booksISBNKey as
+// ErrISBNAlreadyExists.
+func isbnConflict(err error) error {
+	if database.IsUniqueViolationOf(err, booksISBNKey) {
+		return internal.ErrISBNAlreadyExists
+	}
+	return err
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// missingOrModified explains why a versioned write to bookId matched no row.
+func missingOrModified(ctx context.Context, q querier, bookId int64) error {
+	var exists bool
+	if err :=
+		q.QueryRow(
+			ctx,
+			`SELECT EXISTS (SELECT 1 FROM books WHERE id = $1 AND deleted_at IS NULL);`, bookId).Scan(&exists); err != nil {
+		return err
+	}
+
+	if exists {
+		return internal.ErrBookModified
+	}
+	return internal.ErrBookNotFound
+}
+
+// snapshotBook reads a book inside tx, whether deleted or not, and locks its
+// row until tx ends.
+func snapshotBook(ctx context.Context, tx pgx.Tx, bookId int64) (internal.Book, error) {
+	var deletedAt *time.Time
+	b, err := scanBook(
+		tx.QueryRow(
+			ctx,
+			`SELECT `+bookColumns+`, b.deleted_at`+bookFrom+` WHERE b.id = $1 FOR UPDATE OF b;`, bookId), &deletedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.Book{}, internal.ErrBookNotFound
+		}
+		return internal.Book{}, err
+	}
+
+	b.DeletedAt = deletedAt
+	return b, nil
+}
+
+// liveSnapshot is snapshotBook for a book that must not be deleted.
+func liveSnapshot(ctx context.Context, tx pgx.Tx, bookId int64) (internal.Book, error) {
+	b, err := snapshotBook(ctx, tx, bookId)
+	if err == nil && b.DeletedAt != nil {
+		return internal.Book{}, internal.ErrBookNotFound
+	}
+	return b, err
+}
+
+// recordRevision appends the change just made to bookId to its history,
+// with the actor and request ID carried by ctx.
+func recordRevision(ctx context.Context, tx pgx.Tx, action string, before *internal.Book, bookId int64) error {
+	after, err := snapshotBook(ctx, tx, bookId)
+	if err != nil {
+		return err
+	}
+
+	return insertRevision(ctx, tx, action, bookId, after.Version, before, &after)
+}
+
+func insertRevision(ctx context.Context, tx pgx.Tx, action string, bookId, version int64, before, after *internal.Book) error {
+	_, err :=
+		tx.Exec(
+			ctx,
+			`INSERT INTO book_revisions (book_id, version, action, actor, request_id, before, after) VALUES ($1, $2, $3, $4, $5, $6, $7);`,
+			bookId, version, action, internal.ActorFromContext(ctx), internal.RequestIDFromContext(ctx), before, after)
+	return err
 }
 
 type bookRepository struct {
@@ -29,60 +236,117 @@ func NewBookRepository(conn *pgxpool.Pool) IBookRepository {
 }
 
 func (r *bookRepository) RegisterBook(ctx context.Context, b internal.Book) (int64, error) {
-	err :=
-		r.Conn.QueryRow(
-			ctx,
-			`INSERT INTO books (title, description, genre, author, publish_date, publisher, pages) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;`, b.Title, b.Description, b.Genre, b.Author, b.PublishDate, b.Publisher, b.Pages).Scan(&b.ID)
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		if err := txReferences(tx).resolve(ctx, &b); err != nil {
+			return err
+		}
+
+		if err :=
+			tx.QueryRow(
+				ctx,
+				`INSERT INTO books (title, isbn10, isbn13, description, publish_date, publisher_id, pages) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;`, b.Title, isbnArg(b.ISBN10), isbnArg(b.ISBN13), b.Description, b.PublishDate, b.Publisher.ID, b.Pages).Scan(&b.ID); err != nil {
+			return err
+		}
+
+		if err := insertBookReferences(ctx, tx, b); err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, tx, internal.RevisionCreate, nil, b.ID)
+	})
 
 	if err != nil {
-		return internal.ZERO, err
+		return internal.ZERO, isbnConflict(err)
 	}
 
 	return b.ID, nil
 }
 
-func (r *bookRepository) ListBooks(ctx context.Context) ([]internal.Book, error) {
+func (r *bookRepository) ListBooks(ctx context.Context, q internal.BookQuery) ([]internal.Book, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+
+	filters := bookFilters(q)
+	if err :=
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT COUNT(*)`+bookFrom+filters.whereClause()+`;`, filters.args...).Scan(&pagination.TotalCount); err != nil {
+		return []internal.Book{}, pagination, err
+	}
+
+	page := bookFilters(q)
+	sortColumn := internal.BookSortFields[q.SortBy]
+	comparison := ">"
+	if q.SortDirection == internal.SortDesc {
+		comparison = "<"
+	}
+
+	if q.Cursor != internal.EMPTY {
+		value, id, err := decodeBookCursor(q.Cursor, q.SortBy)
+		if err != nil {
+			return []internal.Book{}, pagination, err
+		}
+		if q.SortBy == "id" {
+			page.where("b.id " + comparison + " " + page.arg(id))
+		} else {
+			page.where("(" + sortColumn + ", b.id) " + comparison + " (" + page.arg(value) + ", " + page.arg(id) + ")")
+		}
+	}
+
+	orderBy := bookOrderBy(q)
+
+	// Fetch one extra row to know whether there is a next page.
+	limit := " LIMIT " + page.arg(q.PageSize+1)
+	if q.Cursor == internal.EMPTY && q.Offset > internal.ZERO {
+		limit += " OFFSET " + page.arg(q.Offset)
+	}
+
 	rows, err :=
 		r.Conn.Query(
 			ctx,
-			`SELECT id, title, description, genre, author, publish_date, publisher, pages 
-                FROM books WHERE deleted_at IS NULL;`)
+			`SELECT `+bookColumns+bookFrom+page.whereClause()+orderBy+limit+`;`, page.args...)
 
 	if err != nil {
-		return []internal.Book{}, err
+		return []internal.Book{}, pagination, err
 	}
 
-	defer rows.Close()
+	books, err := collectBooks(rows)
+	if err != nil {
+		return []internal.Book{}, pagination, err
+	}
 
-	var books []internal.Book
-	for rows.Next() {
-		b := internal.Book{}
-		if err := rows.Scan(
-			&b.ID,
-			&b.Title,
-			&b.Description,
-			&b.Genre,
-			&b.Author,
-			&b.PublishDate,
-			&b.Publisher,
-			&b.Pages,
-		); err != nil {
-			return []internal.Book{}, err
+	if len(books) > q.PageSize {
+		books = books[:q.PageSize]
+		cursor, err := encodeBookCursor(q.SortBy, books[len(books)-1])
+		if err != nil {
+			return []internal.Book{}, pagination, err
 		}
-		books = append(books, b)
+		pagination.NextCursor = cursor
 	}
 
-	return books, nil
+	return books, pagination, nil
+}
+
+// bookOrderBy sorts books as requested by q, breaking ties by ID.
+func bookOrderBy(q internal.BookQuery) string {
+	direction := "ASC"
+	if q.SortDirection == internal.SortDesc {
+		direction = "DESC"
+	}
+
+	orderBy := " ORDER BY " + internal.BookSortFields[q.SortBy] + " " + direction
+	if q.SortBy != "id" {
+		orderBy += ", b.id " + direction
+	}
+	return orderBy
 }
 
 func (r *bookRepository) GetBookById(ctx context.Context, bookId int64) (internal.Book, error) {
-	var b internal.Book
-	if err :=
+	b, err := scanBook(
 		r.Conn.QueryRow(
 			ctx,
-			`SELECT title, description, genre, author, publish_date, publisher, pages 
-                FROM books WHERE id = $1 AND deleted_at IS NULL;`, bookId).Scan(&b.Title, &b.Description, &b.Genre, &b.Author, &b.PublishDate,
-			&b.Publisher, &b.Pages); err != nil {
+			`SELECT `+bookColumns+bookFrom+` WHERE b.id = $1 AND b.deleted_at IS NULL;`, bookId))
+
+	if err != nil {
 		if err == pgx.ErrNoRows {
 			return internal.Book{}, internal.ErrBookNotFound
 		}
@@ -92,105 +356,665 @@ func (r *bookRepository) GetBookById(ctx context.Context, bookId int64) (interna
 	return b, nil
 }
 
-func (r *bookRepository) UpdateBook(ctx context.Context, b internal.Book) (bool, error) {
-	result, err :=
-		r.Conn.Exec(
+func (r *bookRepository) GetBookByISBN(ctx context.Context, isbn13 string) (internal.Book, error) {
+	b, err := scanBook(
+		r.Conn.QueryRow(
 			ctx,
-			`UPDATE books SET title = $2, description = $3, genre = $4, author = $5, publish_date = $6, publisher = $7, pages = $8, updated_at = $9 WHERE id = $1 AND deleted_at IS NULL;`, b.ID, b.Title, b.Description, b.Genre, b.Author, b.PublishDate, b.Publisher, b.Pages, time.Now(),
-		)
+			`SELECT `+bookColumns+bookFrom+` WHERE b.isbn13 = $1 AND b.deleted_at IS NULL;`, isbn13))
 
 	if err != nil {
-		return false, err
+		if err == pgx.ErrNoRows {
+			return internal.Book{}, internal.ErrBookNotFound
+		}
+		return internal.Book{}, err
 	}
 
-	if result.RowsAffected() == internal.ZERO {
-		log.Printf("Book not found, %d rows affected.\n", result.RowsAffected())
-		return false, internal.ErrBookNotFound
-	} else {
-		log.Printf("Book with ID %d updated.\n", b.ID)
-		return true, nil
+	return b, nil
+}
+
+func (r *bookRepository) UpdateBook(ctx context.Context, b internal.Book) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		before, err := liveSnapshot(ctx, tx, b.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := txReferences(tx).resolve(ctx, &b); err != nil {
+			return err
+		}
+
+		result, err :=
+			tx.Exec(
+				ctx,
+				`UPDATE books SET title = $2, description = $3, publish_date = $4, publisher_id = $5, pages = $6, updated_at = $7, version = version + 1, isbn10 = $9, isbn13 = $10
+                WHERE id = $1 AND deleted_at IS NULL AND ($8::bigint = 0 OR version = $8);`, b.ID, b.Title, b.Description, b.PublishDate, b.Publisher.ID, b.Pages, time.Now(), b.Version, isbnArg(b.ISBN10), isbnArg(b.ISBN13),
+			)
+
+		if err != nil {
+			return err
+		}
+
+		if result.RowsAffected() == internal.ZERO {
+			log.Printf("Book not updated, %d rows affected.\n", result.RowsAffected())
+			return missingOrModified(ctx, tx, b.ID)
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM book_genres WHERE book_id = $1;`, b.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM book_authors WHERE book_id = $1;`, b.ID); err != nil {
+			return err
+		}
+
+		if err := insertBookReferences(ctx, tx, b); err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, tx, internal.RevisionUpdate, &before, b.ID)
+	})
+
+	if err != nil {
+		return false, isbnConflict(err)
 	}
+
+	log.Printf("Book with ID %d updated.\n", b.ID)
+	return true, nil
 }
 
-func (r *bookRepository) DeleteBook(ctx context.Context, bookId int64) (bool, error) {
-	result, err :=
-		r.Conn.Exec(
-			ctx,
-			"UPDATE books SET deleted_at = $2 WHERE id = $1;", bookId, time.Now())
+// PatchBook writes only the given fields of b, leaving every other column
+// and reference list untouched. The references of b are resolved within the
+// same transaction.
+func (r *bookRepository) PatchBook(ctx context.Context, b internal.Book, fields []internal.BookField) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		before, err := liveSnapshot(ctx, tx, b.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := txReferences(tx).resolve(ctx, &b); err != nil {
+			return err
+		}
+
+		q := &queryBuilder{}
+		id := q.arg(b.ID)
+		version := q.arg(b.Version)
+		set := []string{"updated_at = " + q.arg(time.Now()), "version = version + 1"}
+		var replaceGenres, replaceAuthors bool
+
+		for _, field := range fields {
+			switch field {
+			case internal.BookFieldTitle:
+				set = append(set, "title = "+q.arg(b.Title))
+			case internal.BookFieldISBN:
+				set = append(set, "isbn10 = "+q.arg(isbnArg(b.ISBN10)), "isbn13 = "+q.arg(isbnArg(b.ISBN13)))
+			case internal.BookFieldDescription:
+				set = append(set, "description = "+q.arg(b.Description))
+			case internal.BookFieldPublishDate:
+				set = append(set, "publish_date = "+q.arg(b.PublishDate))
+			case internal.BookFieldPages:
+				set = append(set, "pages = "+q.arg(b.Pages))
+			case internal.BookFieldPublisher:
+				set = append(set, "publisher_id = "+q.arg(b.Publisher.ID))
+			case internal.BookFieldGenres:
+				replaceGenres = true
+			case internal.BookFieldAuthors:
+				replaceAuthors = true
+			}
+		}
+
+		result, err :=
+			tx.Exec(
+				ctx,
+				`UPDATE books SET `+strings.Join(set, ", ")+`
+                WHERE id = `+id+` AND deleted_at IS NULL AND (`+version+`::bigint = 0 OR version = `+version+`);`, q.args...)
+
+		if err != nil {
+			return err
+		}
+
+		if result.RowsAffected() == internal.ZERO {
+			return missingOrModified(ctx, tx, b.ID)
+		}
+
+		if replaceGenres {
+			if _, err := tx.Exec(ctx, `DELETE FROM book_genres WHERE book_id = $1;`, b.ID); err != nil {
+				return err
+			}
+			if err := insertBookGenres(ctx, tx, b); err != nil {
+				return err
+			}
+		}
+		if replaceAuthors {
+			if _, err := tx.Exec(ctx, `DELETE FROM book_authors WHERE book_id = $1;`, b.ID); err != nil {
+				return err
+			}
+			if err := insertBookAuthors(ctx, tx, b); err != nil {
+				return err
+			}
+		}
+
+		return recordRevision(ctx, tx, internal.RevisionUpdate, &before, b.ID)
+	})
 
 	if err != nil {
-		return false, err
+		return false, isbnConflict(err)
 	}
 
-	if result.RowsAffected() == internal.ZERO {
-		log.Printf("Book not found, %d rows affected.\n", result.RowsAffected())
-		return false, internal.ErrBookNotFound
-	} else {
-		log.Printf("Book with ID %d deleted.\n", bookId)
-		return true, nil
+	log.Printf("Book with ID %d patched.\n", b.ID)
+	return true, nil
+}
+
+func (r *bookRepository) DeleteBook(ctx context.Context, bookId int64, version int64) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		before, err := liveSnapshot(ctx, tx, bookId)
+		if err != nil {
+			return err
+		}
+		if err := refuseOpenLoans(ctx, tx, bookId); err != nil {
+			return err
+		}
+
+		result, err :=
+			tx.Exec(
+				ctx,
+				"UPDATE books SET deleted_at = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($3::bigint = 0 OR version = $3);", bookId, time.Now(), version)
+
+		if err != nil {
+			return err
+		}
+
+		if result.RowsAffected() == internal.ZERO {
+			log.Printf("Book not deleted, %d rows affected.\n", result.RowsAffected())
+			return missingOrModified(ctx, tx, bookId)
+		}
+
+		return recordRevision(ctx, tx, internal.RevisionDelete, &before, bookId)
+	})
+
+	if err != nil {
+		return false, err
 	}
+
+	log.Printf("Book with ID %d deleted.\n", bookId)
+	return true, nil
 }
 
 func (r *bookRepository) ListBooksByGenre(ctx context.Context, genre string) ([]internal.Book, error) {
 	rows, err :=
 		r.Conn.Query(
 			ctx,
-			`SELECT id, title, description, author, publish_date, publisher, pages
-            FROM books WHERE genre = $1 AND deleted_at IS NULL;`, genre)
+			`SELECT `+bookColumns+bookFrom+`
+            WHERE EXISTS (SELECT 1 FROM book_genres bg JOIN genres g ON g.id = bg.genre_id
+                WHERE bg.book_id = b.id AND lower(g.name) = lower($1))
+            AND b.deleted_at IS NULL ORDER BY b.id;`, genre)
 
 	if err != nil {
 		return []internal.Book{}, err
 	}
 
+	return collectBooks(rows)
+}
+
+func (r *bookRepository) ListBooksByAuthor(ctx context.Context, author string) ([]internal.Book, error) {
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			`SELECT `+bookColumns+bookFrom+`
+            WHERE EXISTS (SELECT 1 FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+                WHERE ba.book_id = b.id AND lower(a.name) = lower($1))
+            AND b.deleted_at IS NULL ORDER BY b.id;`, author)
+
+	if err != nil {
+		return []internal.Book{}, err
+	}
+
+	return collectBooks(rows)
+}
+
+// ts_headline cannot escape the text it highlights, so it delimits matches
+// with private-use characters that markHighlights turns into <mark> tags
+// once the rest of the text is HTML-escaped.
+const (
+	startMark = "\uE000"
+	stopMark  = "\uE001"
+)
+
+func markHighlights(s string) string {
+	return strings.NewReplacer(startMark, "<mark>", stopMark, "</mark>").Replace(html.EscapeString(s))
+}
+
+func (r *bookRepository) SearchBooks(ctx context.Context, q internal.BookSearchQuery) ([]internal.BookSearchResult, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+
+	if err :=
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT COUNT(*) FROM books
+                WHERE search_vector @@ websearch_to_tsquery('english', $1) AND deleted_at IS NULL;`, q.Query).Scan(&pagination.TotalCount); err != nil {
+		return []internal.BookSearchResult{}, pagination, err
+	}
+
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			`SELECT `+bookColumns+`,
+                ts_rank(b.search_vector, query) AS rank,
+                ts_headline('english', b.title, query, 'StartSel=`+startMark+`, StopSel=`+stopMark+`, HighlightAll=true'),
+                ts_headline('english', b.description, query, 'StartSel=`+startMark+`, StopSel=`+stopMark+`, MaxFragments=2, MaxWords=30, MinWords=10')
+                `+bookFrom+`, websearch_to_tsquery('english', $1) AS query
+                WHERE b.search_vector @@ query AND b.deleted_at IS NULL
+                ORDER BY rank DESC, b.id ASC
+                LIMIT $2 OFFSET $3;`, q.Query, q.PageSize, q.Offset)
+
+	if err != nil {
+		return []internal.BookSearchResult{}, pagination, err
+	}
+
 	defer rows.Close()
 
-	var books []internal.Book
+	var results []internal.BookSearchResult
 	for rows.Next() {
-		b := internal.Book{}
-		if err := rows.Scan(
-			&b.ID,
-			&b.Title,
-			&b.Description,
-			&b.Author,
-			&b.PublishDate,
-			&b.Publisher,
-			&b.Pages); err != nil {
-			return []internal.Book{}, err
+		res := internal.BookSearchResult{}
+		b, err := scanBook(rows, &res.Rank, &res.TitleHighlight, &res.Snippet)
+		if err != nil {
+			return []internal.BookSearchResult{}, pagination, err
 		}
-		books = append(books, b)
+		res.Book = b
+		res.TitleHighlight = markHighlights(res.TitleHighlight)
+		res.Snippet = markHighlights(res.Snippet)
+		results = append(results, res)
 	}
 
-	return books, nil
+	return results, pagination, rows.Err()
 }
 
-func (r *bookRepository) ListBooksByAuthor(ctx context.Context, author string) ([]internal.Book, error) {
+func (r *bookRepository) ListBookRevisions(ctx context.Context, bookId int64) ([]internal.BookRevision, error) {
 	rows, err :=
 		r.Conn.Query(
 			ctx,
-			`SELECT id, title, description, genre, publish_date, publisher, pages
-            FROM books WHERE author = $1 AND deleted_at IS NULL;`, author)
+			`SELECT `+revisionColumns+` FROM book_revisions WHERE book_id = $1 ORDER BY id;`, bookId)
 
 	if err != nil {
-		return []internal.Book{}, err
+		return []internal.BookRevision{}, err
 	}
 
 	defer rows.Close()
 
-	var books []internal.Book
+	revisions := []internal.BookRevision{}
 	for rows.Next() {
-		b := internal.Book{}
-		if err := rows.Scan(
-			&b.ID,
-			&b.Title,
-			&b.Description,
-			&b.Genre,
-			&b.PublishDate,
-			&b.Publisher,
-			&b.Pages); err != nil {
-			return []internal.Book{}, err
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return []internal.BookRevision{}, err
 		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []internal.BookRevision{}, err
+	}
+
+	if len(revisions) == internal.ZERO {
+		// Books written before revisions were recorded have no history yet.
+		var exists bool
+		if err := r.Conn.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM books WHERE id = $1);`, bookId).Scan(&exists); err != nil {
+			return []internal.BookRevision{}, err
+		}
+		if !exists {
+			return []internal.BookRevision{}, internal.ErrBookNotFound
+		}
+	}
+
+	return revisions, nil
+}
+
+func (r *bookRepository) GetBookRevision(ctx context.Context, bookId int64, revisionId int64) (internal.BookRevision, error) {
+	rev, err := scanRevision(
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT `+revisionColumns+` FROM book_revisions WHERE book_id = $1 AND id = $2;`, bookId, revisionId))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.BookRevision{}, internal.ErrBookRevisionNotFound
+		}
+		return internal.BookRevision{}, err
+	}
+
+	return rev, nil
+}
+
+const revisionColumns = `id, book_id, version, action, actor, request_id, before, after, created_at`
+
+func scanRevision(row pgx.Row) (internal.BookRevision, error) {
+	var rev internal.BookRevision
+	if err := row.Scan(
+		&rev.ID,
+		&rev.BookID,
+		&rev.Version,
+		&rev.Action,
+		&rev.Actor,
+		&rev.RequestID,
+		&rev.Before,
+		&rev.After,
+		&rev.CreatedAt,
+	); err != nil {
+		return internal.BookRevision{}, err
+	}
+	return rev, nil
+}
+
+func (r *bookRepository) ListDeletedBooks(ctx context.Context, q internal.DeletedBookQuery) ([]internal.Book, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+
+	if err :=
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT COUNT(*) FROM books WHERE deleted_at IS NOT NULL;`).Scan(&pagination.TotalCount); err != nil {
+		return []internal.Book{}, pagination, err
+	}
+
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			`SELECT `+bookColumns+`, b.deleted_at`+bookFrom+`
+            WHERE b.deleted_at IS NOT NULL
+            ORDER BY b.deleted_at DESC, b.id DESC
+            LIMIT $1 OFFSET $2;`, q.PageSize, q.Offset)
+
+	if err != nil {
+		return []internal.Book{}, pagination, err
+	}
+
+	defer rows.Close()
+
+	books := []internal.Book{}
+	for rows.Next() {
+		var deletedAt *time.Time
+		b, err := scanBook(rows, &deletedAt)
+		if err != nil {
+			return []internal.Book{}, pagination, err
+		}
+		b.DeletedAt = deletedAt
 		books = append(books, b)
 	}
 
-	return books, nil
+	return books, pagination, rows.Err()
+}
+
+// deletedSnapshot is snapshotBook for a book that must be soft-deleted.
+func deletedSnapshot(ctx context.Context, tx pgx.Tx, bookId int64) (internal.Book, error) {
+	b, err := snapshotBook(ctx, tx, bookId)
+	if err == nil && b.DeletedAt == nil {
+		return internal.Book{}, internal.ErrBookNotDeleted
+	}
+	return b, err
+}
+
+func (r *bookRepository) RestoreBook(ctx context.Context, bookId int64, version int64) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		before, err := deletedSnapshot(ctx, tx, bookId)
+		if err != nil {
+			return err
+		}
+		if version != internal.ZERO && version != before.Version {
+			return internal.ErrBookModified
+		}
+
+		if _, err :=
+			tx.Exec(
+				ctx,
+				`UPDATE books SET deleted_at = NULL, updated_at = $2, version = version + 1 WHERE id = $1;`, bookId, time.Now()); err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, tx, internal.RevisionRestore, &before, bookId)
+	})
+
+	if err != nil {
+		return false, isbnConflict(err)
+	}
+
+	log.Printf("Book with ID %d restored.\n", bookId)
+	return true, nil
+}
+
+// refuseOpenLoans returns internal.ErrBookHasOpenLoans when a copy of
+// bookId is on loan. The copies are locked first, so that no checkout, which
+// locks its copy, can lend one until tx ends.
+func refuseOpenLoans(ctx context.Context, tx pgx.Tx, bookId int64) error {
+	if _, err := tx.Exec(ctx, `SELECT id FROM copies WHERE book_id = $1 FOR UPDATE;`, bookId); err != nil {
+		return err
+	}
+
+	var open bool
+	if err :=
+		tx.QueryRow(
+			ctx,
+			`SELECT EXISTS (SELECT 1 FROM loans WHERE book_id = $1 AND returned_at IS NULL AND lost_at IS NULL);`, bookId).Scan(&open); err != nil {
+		return err
+	}
+	if open {
+		return internal.ErrBookHasOpenLoans
+	}
+	return nil
+}
+
+// purgeBook removes a book locked by snapshotBook for good, unless a copy of
+// it is on loan. Its copies and its genre and author links go with it; its
+// history stays, ending with a purge revision.
+func purgeBook(ctx context.Context, tx pgx.Tx, before internal.Book) error {
+	if err := refuseOpenLoans(ctx, tx, before.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM books WHERE id = $1;`, before.ID); err != nil {
+		return err
+	}
+
+	return insertRevision(ctx, tx, internal.RevisionPurge, before.ID, before.Version, &before, nil)
+}
+
+func (r *bookRepository) PurgeBook(ctx context.Context, bookId int64) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		before, err := deletedSnapshot(ctx, tx, bookId)
+		if err != nil {
+			return err
+		}
+
+		return purgeBook(ctx, tx, before)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("Book with ID %d purged.\n", bookId)
+	return true, nil
+}
+
+// PurgeDeletedBooks purges every book deleted before deletedBefore, one
+// transaction per book so a large backlog does not hold locks for long. Books
+// with a copy on loan are skipped.
+func (r *bookRepository) PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			`SELECT id FROM books WHERE deleted_at < $1 ORDER BY id;`, deletedBefore)
+
+	if err != nil {
+		return internal.ZERO, err
+	}
+
+	bookIds, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return internal.ZERO, err
+	}
+
+	var purged int64
+	for _, bookId := range bookIds {
+		var done bool
+		err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+			before, err := snapshotBook(ctx, tx, bookId)
+			if err != nil {
+				return err
+			}
+			// Restored or deleted again since the books were listed.
+			if before.DeletedAt == nil || !before.DeletedAt.Before(deletedBefore) {
+				return nil
+			}
+
+			if err := purgeBook(ctx, tx, before); err != nil {
+				return err
+			}
+			done = true
+			return nil
+		})
+
+		if errors.Is(err, internal.ErrBookNotFound) {
+			// Purged by someone else in the meantime.
+			continue
+		}
+		if errors.Is(err, internal.ErrBookHasOpenLoans) {
+			log.Printf("Book with ID %d not purged, it has copies on loan.\n", bookId)
+			continue
+		}
+		if err != nil {
+			return purged, err
+		}
+		if done {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+type bookImport struct {
+	tx pgx.Tx
+}
+
+func (r *bookRepository) BeginImport(ctx context.Context) (IBookImport, error) {
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &bookImport{tx: tx}, nil
+}
+
+// InsertBooks resolves the references of books, then copies the books, their
+// genre and author links and their create revisions with CopyFrom. The IDs
+// are drawn from the books sequence up front since COPY cannot return them.
+func (i *bookImport) InsertBooks(ctx context.Context, books []internal.Book) ([]int64, error) {
+	var bookIds []int64
+
+	err := pgx.BeginFunc(ctx, i.tx, func(tx pgx.Tx) error {
+		rows, err :=
+			tx.Query(
+				ctx,
+				`SELECT nextval(pg_get_serial_sequence('books', 'id')) FROM generate_series(1, $1);`, len(books))
+
+		if err != nil {
+			return err
+		}
+
+		if bookIds, err = pgx.CollectRows(rows, pgx.RowTo[int64]); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		actor, requestId := internal.ActorFromContext(ctx), internal.RequestIDFromContext(ctx)
+		var bookRows, genreRows, authorRows, revisionRows [][]any
+
+		refs := txReferences(tx)
+		for n, b := range books {
+			if err := refs.resolve(ctx, &b); err != nil {
+				return err
+			}
+			b.ID, b.CreatedAt, b.Version = bookIds[n], now, 1
+
+			bookRows = append(bookRows, []any{b.ID, b.Title, isbnArg(b.ISBN10), isbnArg(b.ISBN13), b.Description, b.PublishDate, b.Publisher.ID, b.Pages, b.CreatedAt, b.Version})
+			for position, g := range b.Genres {
+				genreRows = append(genreRows, []any{b.ID, g.ID, position})
+			}
+			for position, a := range b.Authors {
+				authorRows = append(authorRows, []any{b.ID, a.ID, position})
+			}
+			revisionRows = append(revisionRows, []any{b.ID, b.Version, internal.RevisionCreate, actor, requestId, b})
+		}
+
+		copies := []struct {
+			table   string
+			columns []string
+			rows    [][]any
+		}{
+			{"books", []string{"id", "title", "isbn10", "isbn13", "description", "publish_date", "publisher_id", "pages", "created_at", "version"}, bookRows},
+			{"book_genres", []string{"book_id", "genre_id", "position"}, genreRows},
+			{"book_authors", []string{"book_id", "author_id", "position"}, authorRows},
+			{"book_revisions", []string{"book_id", "version", "action", "actor", "request_id", "after"}, revisionRows},
+		}
+
+		for _, c := range copies {
+			if _, err := tx.CopyFrom(ctx, pgx.Identifier{c.table}, c.columns, pgx.CopyFromRows(c.rows)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, isbnConflict(err)
+	}
+
+	return bookIds, nil
+}
+
+func (i *bookImport) Commit(ctx context.Context) error {
+	return i.tx.Commit(ctx)
+}
+
+func (i *bookImport) Rollback(ctx context.Context) error {
+	if err := i.tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		return err
+	}
+	return nil
+}
+
+// exportFetchSize is how many books ExportBooks fetches from its cursor at a
+// time.
+const exportFetchSize = 500
+
+// ExportBooks calls fn with every book matching q, in q's order, ignoring
+// its page. The books are read through a server-side cursor so that only
+// exportFetchSize of them are held in memory at once.
+func (r *bookRepository) ExportBooks(ctx context.Context, q internal.BookQuery, fn func(internal.Book) error) error {
+	return pgx.BeginTxFunc(ctx, r.Conn, pgx.TxOptions{AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		filters := bookFilters(q)
+		if _, err :=
+			tx.Exec(
+				ctx,
+				`DECLARE export_books NO SCROLL CURSOR FOR SELECT `+bookColumns+bookFrom+filters.whereClause()+bookOrderBy(q)+`;`, filters.args...); err != nil {
+			return err
+		}
+
+		for {
+			rows, err := tx.Query(ctx, `FETCH `+strconv.Itoa(exportFetchSize)+` FROM export_books;`)
+			if err != nil {
+				return err
+			}
+
+			books, err := collectBooks(rows)
+			if err != nil {
+				return err
+			}
+
+			for _, b := range books {
+				if err := fn(b); err != nil {
+					return err
+				}
+			}
+
+			if len(books) < exportFetchSize {
+				return nil
+			}
+		}
+	})
 }
@@ -0,0 +1,26 @@
+package book_test
+
+import (
+	"testing"
+
+	"github.com/amarantec/box/internal/book"
+	"github.com/amarantec/box/internal/book/booktest"
+	"github.com/amarantec/box/internal/database/databasetest"
+	"github.com/amarantec/box/internal/reference"
+)
+
+// TestBookRepository runs the repository contract against PostgreSQL. It is
+// skipped unless BOX_TEST_DATABASE_URL points at a disposable database.
+func TestBookRepository(t *testing.T) {
+	conn := databasetest.Open(t, "book_test")
+
+	booktest.RunRepositoryContract(t, func(t *testing.T) booktest.Repositories {
+		databasetest.Truncate(t, conn)
+		return booktest.Repositories{
+			Books:      book.NewBookRepository(conn),
+			Authors:    reference.NewRepository(conn, reference.Authors),
+			Genres:     reference.NewRepository(conn, reference.Genres),
+			Publishers: reference.NewRepository(conn, reference.Publishers),
+		}
+	})
+}
@@ -2,30 +2,56 @@ package book
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/inventory"
 )
 
 type IBookService interface {
 	RegisterBook(ctx context.Context, b internal.Book) (internal.Response[int64], error)
-	ListBooks(ctx context.Context) (internal.Response[[]internal.Book], error)
-	GetBookById(ctx context.Context, bookId int64) (internal.Response[internal.Book], error)
+	ListBooks(ctx context.Context, q internal.BookQuery) (internal.Response[[]internal.Book], error)
+	GetBookById(ctx context.Context, bookId int64) (internal.Response[internal.BookDetails], error)
+	GetBookByISBN(ctx context.Context, isbn string) (internal.Response[internal.BookDetails], error)
 	UpdateBook(ctx context.Context, book internal.Book) (internal.Response[bool], error)
-	DeleteBook(ctx context.Context, bookId int64) (internal.Response[bool], error)
+	PatchBook(ctx context.Context, bookId int64, version int64, contentType string, patch []byte) (internal.Response[internal.Book], error)
+	DeleteBook(ctx context.Context, bookId int64, version int64) (internal.Response[bool], error)
 	ListBooksByGenre(ctx context.Context, genre string) (internal.Response[[]internal.Book], error)
 	ListBooksByAuthor(ctx context.Context, author string) (internal.Response[[]internal.Book], error)
+	SearchBooks(ctx context.Context, q internal.BookSearchQuery) (internal.Response[[]internal.BookSearchResult], error)
+	ListBookHistory(ctx context.Context, bookId int64) (internal.Response[[]internal.BookRevision], error)
+	GetBookRevision(ctx context.Context, bookId int64, revisionId int64) (internal.Response[internal.BookRevision], error)
+	DiffBookRevisions(ctx context.Context, bookId int64, from int64, to int64) (internal.Response[internal.BookDiff], error)
+	ListDeletedBooks(ctx context.Context, q internal.DeletedBookQuery) (internal.Response[[]internal.Book], error)
+	RestoreBook(ctx context.Context, bookId int64, version int64) (internal.Response[internal.Book], error)
+	PurgeBook(ctx context.Context, bookId int64) (internal.Response[bool], error)
+	PurgeDeletedBooks(ctx context.Context, retention time.Duration) (internal.Response[int64], error)
+	ImportBooks(ctx context.Context, r io.Reader, opts internal.ImportOptions) (internal.Response[internal.ImportReport], error)
+	ExportBooks(ctx context.Context, q internal.BookQuery, format string, w io.Writer) (internal.Response[int64], error)
 }
 
 type bookService struct {
 	bookRepo IBookRepository
+	copyRepo inventory.ICopyRepository
 }
 
-func NewBookService(repository IBookRepository) IBookService {
-	return &bookService{bookRepo: repository}
+// NewBookService serves the books stored in repository. The repository
+// resolves the genres, authors and publisher of a book as it writes it.
+func NewBookService(repository IBookRepository, copyRepository inventory.ICopyRepository) IBookService {
+	return &bookService{bookRepo: repository, copyRepo: copyRepository}
 }
 
 func (s *bookService) RegisterBook(ctx context.Context, b internal.Book) (internal.Response[int64], error) {
 	var response internal.Response[int64]
+
+	normalizeBook(&b)
+	if err := validateBook(b, false, time.Now()); err != nil {
+		response.Data = internal.ZERO
+		response.Success = false
+		return response, err
+	}
+
 	data, err := s.bookRepo.RegisterBook(ctx, b)
 	if err != nil {
 		response.Data = internal.ZERO
@@ -39,10 +65,16 @@ func (s *bookService) RegisterBook(ctx context.Context, b internal.Book) (intern
 	return response, nil
 }
 
-func (s *bookService) ListBooks(ctx context.Context) (internal.Response[[]internal.Book], error) {
+func (s *bookService) ListBooks(ctx context.Context, q internal.BookQuery) (internal.Response[[]internal.Book], error) {
 	var response internal.Response[[]internal.Book]
 
-	data, err := s.bookRepo.ListBooks(ctx)
+	if err := q.Normalize(); err != nil {
+		response.Data = []internal.Book{}
+		response.Success = false
+		return response, err
+	}
+
+	data, pagination, err := s.bookRepo.ListBooks(ctx, q)
 	if err != nil {
 		response.Data = []internal.Book{}
 		response.Success = false
@@ -51,15 +83,55 @@ func (s *bookService) ListBooks(ctx context.Context) (internal.Response[[]intern
 	response.Data = data
 	response.Success = true
 	response.Message = "All books registered in the system."
+	response.Pagination = &pagination
 	return response, nil
 }
 
-func (s *bookService) GetBookById(ctx context.Context, id int64) (internal.Response[internal.Book], error) {
-	var response internal.Response[internal.Book]
+// GetBookById returns a book with the availability of its copies.
+func (s *bookService) GetBookById(ctx context.Context, id int64) (internal.Response[internal.BookDetails], error) {
+	var response internal.Response[internal.BookDetails]
 
-	data, err := s.bookRepo.GetBookById(ctx, id)
+	b, err := s.bookRepo.GetBookById(ctx, id)
 	if err != nil {
-		response.Data = internal.Book{}
+		response.Data = internal.BookDetails{}
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.withAvailability(ctx, b)
+	if err != nil {
+		response.Data = internal.BookDetails{}
+		response.Success = false
+		return response, err
+	}
+	response.Data = data
+	response.Success = true
+	response.Message = "Book found successfully."
+	return response, nil
+}
+
+// GetBookByISBN finds a book by either of its ISBNs, written with or without
+// hyphens, and returns it like GetBookById.
+func (s *bookService) GetBookByISBN(ctx context.Context, isbn string) (internal.Response[internal.BookDetails], error) {
+	var response internal.Response[internal.BookDetails]
+
+	isbn13, err := internal.ParseISBN(isbn)
+	if err != nil {
+		response.Data = internal.BookDetails{}
+		response.Success = false
+		return response, err
+	}
+
+	b, err := s.bookRepo.GetBookByISBN(ctx, isbn13)
+	if err != nil {
+		response.Data = internal.BookDetails{}
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.withAvailability(ctx, b)
+	if err != nil {
+		response.Data = internal.BookDetails{}
 		response.Success = false
 		return response, err
 	}
@@ -69,9 +141,24 @@ func (s *bookService) GetBookById(ctx context.Context, id int64) (internal.Respo
 	return response, nil
 }
 
+func (s *bookService) withAvailability(ctx context.Context, b internal.Book) (internal.BookDetails, error) {
+	availability, err := s.copyRepo.CountCopies(ctx, b.ID)
+	if err != nil {
+		return internal.BookDetails{}, err
+	}
+	return internal.BookDetails{Book: b, Availability: availability}, nil
+}
+
 func (s *bookService) UpdateBook(ctx context.Context, book internal.Book) (internal.Response[bool], error) {
 	var response internal.Response[bool]
 
+	normalizeBook(&book)
+	if err := validateBook(book, true, time.Now()); err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
 	data, err := s.bookRepo.UpdateBook(ctx, book)
 	if err != nil {
 		response.Data = false
@@ -85,10 +172,76 @@ func (s *bookService) UpdateBook(ctx context.Context, book internal.Book) (inter
 	return response, nil
 }
 
-func (s *bookService) DeleteBook(ctx context.Context, bookId int64) (internal.Response[bool], error) {
+// PatchBook applies a merge patch or JSON patch to the stored book and writes
+// only the fields it changed. The patched book is validated like a full
+// update. A non-zero version must match the stored book; either way the
+// write fails with ErrBookModified if the book changes while being patched.
+func (s *bookService) PatchBook(ctx context.Context, bookId int64, version int64, contentType string, patch []byte) (internal.Response[internal.Book], error) {
+	var response internal.Response[internal.Book]
+
+	current, err := s.bookRepo.GetBookById(ctx, bookId)
+	if err == nil && version != internal.ZERO && version != current.Version {
+		err = internal.ErrBookModified
+	}
+	if err != nil {
+		response.Data = internal.Book{}
+		response.Success = false
+		return response, err
+	}
+
+	patched, err := applyBookPatch(current, contentType, patch)
+	if err == nil {
+		err = checkManagedFields(current, patched)
+	}
+	if err != nil {
+		response.Data = internal.Book{}
+		response.Success = false
+		return response, err
+	}
+
+	// validateBook expects the server managed fields to be unset.
+	patched.CreatedAt, patched.UpdatedAt, patched.DeletedAt = time.Time{}, nil, nil
+
+	dropStaleISBN(current, &patched)
+	normalizeBook(&patched)
+	if err := validateBook(patched, true, time.Now()); err != nil {
+		response.Data = internal.Book{}
+		response.Success = false
+		return response, err
+	}
+
+	fields := changedBookFields(current, patched)
+	if len(fields) == internal.ZERO {
+		response.Data = current
+		response.Success = true
+		response.Message = "Book is unchanged."
+		return response, nil
+	}
+
+	patched.Version = current.Version
+	if _, err := s.bookRepo.PatchBook(ctx, patched, fields); err != nil {
+		response.Data = internal.Book{}
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.bookRepo.GetBookById(ctx, bookId)
+	if err != nil {
+		response.Data = internal.Book{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Book patched successfully."
+	return response, nil
+}
+
+func (s *bookService) DeleteBook(ctx context.Context, bookId int64, version int64) (internal.Response[bool], error) {
 	var response internal.Response[bool]
 
-	data, err := s.bookRepo.DeleteBook(ctx, bookId)
+	data, err := s.bookRepo.DeleteBook(ctx, bookId, version)
 	if err != nil {
 		response.Data = false
 		response.Success = false
@@ -120,7 +273,7 @@ func (s *bookService) ListBooksByGenre(ctx context.Context, genre string) (inter
 func (s *bookService) ListBooksByAuthor(ctx context.Context, author string) (internal.Response[[]internal.Book], error) {
 	var response internal.Response[[]internal.Book]
 
-	data, err := s.bookRepo.ListBooksByGenre(ctx, author)
+	data, err := s.bookRepo.ListBooksByAuthor(ctx, author)
 	if err != nil {
 		response.Data = []internal.Book{}
 		response.Success = false
@@ -132,3 +285,26 @@ func (s *bookService) ListBooksByAuthor(ctx context.Context, author string) (int
 	response.Message = "All books registered in the system listed by author."
 	return response, nil
 }
+
+func (s *bookService) SearchBooks(ctx context.Context, q internal.BookSearchQuery) (internal.Response[[]internal.BookSearchResult], error) {
+	var response internal.Response[[]internal.BookSearchResult]
+
+	if err := q.Normalize(); err != nil {
+		response.Data = []internal.BookSearchResult{}
+		response.Success = false
+		return response, err
+	}
+
+	data, pagination, err := s.bookRepo.SearchBooks(ctx, q)
+	if err != nil {
+		response.Data = []internal.BookSearchResult{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Books matching the search ranked by relevance."
+	response.Pagination = &pagination
+	return response, nil
+}
@@ -0,0 +1,120 @@
+package book
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/amarantec/box/internal"
+)
+
+// RetentionActor is recorded as the actor of the purges made by
+// RunTrashRetention.
+const RetentionActor = "retention"
+
+func (s *bookService) ListDeletedBooks(ctx context.Context, q internal.DeletedBookQuery) (internal.Response[[]internal.Book], error) {
+	var response internal.Response[[]internal.Book]
+
+	if err := q.Normalize(); err != nil {
+		response.Data = []internal.Book{}
+		response.Success = false
+		return response, err
+	}
+
+	data, pagination, err := s.bookRepo.ListDeletedBooks(ctx, q)
+	if err != nil {
+		response.Data = []internal.Book{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Deleted books, most recently deleted first."
+	response.Pagination = &pagination
+	return response, nil
+}
+
+// RestoreBook undeletes a soft-deleted book. A non-zero version must match
+// the deleted book.
+func (s *bookService) RestoreBook(ctx context.Context, bookId int64, version int64) (internal.Response[internal.Book], error) {
+	var response internal.Response[internal.Book]
+
+	_, err := s.bookRepo.RestoreBook(ctx, bookId, version)
+	var data internal.Book
+	if err == nil {
+		data, err = s.bookRepo.GetBookById(ctx, bookId)
+	}
+	if err != nil {
+		response.Data = internal.Book{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Book restored successfully."
+	return response, nil
+}
+
+// PurgeBook permanently removes a soft-deleted book. Live books must be
+// deleted first.
+func (s *bookService) PurgeBook(ctx context.Context, bookId int64) (internal.Response[bool], error) {
+	var response internal.Response[bool]
+
+	data, err := s.bookRepo.PurgeBook(ctx, bookId)
+	if err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Book purged successfully."
+	return response, nil
+}
+
+// PurgeDeletedBooks permanently removes the books deleted more than
+// retention ago, but for those with a copy on loan, and reports how many were
+// purged.
+func (s *bookService) PurgeDeletedBooks(ctx context.Context, retention time.Duration) (internal.Response[int64], error) {
+	var response internal.Response[int64]
+
+	data, err := s.bookRepo.PurgeDeletedBooks(ctx, time.Now().Add(-retention))
+	if err != nil {
+		response.Data = data
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Expired deleted books purged."
+	return response, nil
+}
+
+// RunTrashRetention purges the books deleted more than retention ago, once
+// right away and then every interval, until ctx is done. Failures are logged
+// and retried on the next tick.
+func RunTrashRetention(ctx context.Context, service IBookService, retention, interval time.Duration) {
+	ctx = internal.WithActor(ctx, RetentionActor)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		response, err := service.PurgeDeletedBooks(ctx, retention)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Could not purge expired deleted books. Error: %v", err)
+		} else if response.Data > internal.ZERO {
+			log.Printf("Purged %d books deleted more than %s ago.\n", response.Data, retention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
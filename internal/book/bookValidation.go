@@ -0,0 +1,110 @@
+package book
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/utils"
+)
+
+const maxTitleLength = 250
+
+// normalizeBook trims the title and every reference name, so validation and
+// name lookups see the value that will be stored. It also strips the ISBNs
+// and derives the missing one from a valid other.
+func normalizeBook(b *internal.Book) {
+	b.Title = strings.TrimSpace(b.Title)
+	b.Description = strings.TrimSpace(b.Description)
+	normalizeISBN(b)
+	b.Publisher.Name = utils.NormalizeName(b.Publisher.Name)
+	for i := range b.Genres {
+		b.Genres[i].Name = utils.NormalizeName(b.Genres[i].Name)
+	}
+	for i := range b.Authors {
+		b.Authors[i].Name = utils.NormalizeName(b.Authors[i].Name)
+	}
+}
+
+func normalizeISBN(b *internal.Book) {
+	b.ISBN10 = internal.NormalizeISBN(b.ISBN10)
+	b.ISBN13 = internal.NormalizeISBN(b.ISBN13)
+
+	switch {
+	case b.ISBN13 == internal.EMPTY && internal.ValidISBN10(b.ISBN10):
+		b.ISBN13 = internal.ISBN10To13(b.ISBN10)
+	case b.ISBN10 == internal.EMPTY && internal.ValidISBN13(b.ISBN13):
+		b.ISBN10, _ = internal.ISBN13To10(b.ISBN13)
+	}
+}
+
+// validateBook checks a normalized book sent by a client. Server managed
+// fields must be left unset; ID is required only when updating.
+func validateBook(b internal.Book, isUpdate bool, now time.Time) error {
+	v := &internal.ValidationError{}
+
+	if isUpdate {
+		v.Check(b.ID > internal.ZERO, "ID", "is required")
+	} else {
+		v.Check(b.ID == internal.ZERO, "ID", "is assigned by the server and must not be set")
+	}
+	v.Check(b.CreatedAt.IsZero(), "CreatedAt", "is managed by the server and must not be set")
+	v.Check(b.UpdatedAt == nil, "UpdatedAt", "is managed by the server and must not be set")
+	v.Check(b.DeletedAt == nil, "DeletedAt", "is managed by the server and must not be set")
+
+	v.Check(b.Title != internal.EMPTY, "Title", "must not be empty")
+	v.Check(utf8.RuneCountInString(b.Title) <= maxTitleLength,
+		"Title", "must be at most %d characters", maxTitleLength)
+	v.Check(b.Description != internal.EMPTY, "Description", "must not be empty")
+	validateISBN(v, b)
+	v.Check(b.Pages > internal.ZERO, "Pages", "must be greater than zero")
+
+	if b.PublishDate.IsZero() {
+		v.Add("PublishDate", "is required")
+	} else {
+		v.Check(!b.PublishDate.After(now), "PublishDate", "must not be in the future")
+	}
+
+	validateReference(v, "Publisher", b.Publisher.ID, b.Publisher.Name)
+
+	v.Check(len(b.Genres) > internal.ZERO, "Genres", "must list at least one genre")
+	for i, g := range b.Genres {
+		validateReference(v, fmt.Sprintf("Genres[%d]", i), g.ID, g.Name)
+	}
+
+	v.Check(len(b.Authors) > internal.ZERO, "Authors", "must list at least one author")
+	for i, a := range b.Authors {
+		validateReference(v, fmt.Sprintf("Authors[%d]", i), a.ID, a.Name)
+	}
+
+	return v.Err()
+}
+
+// validateISBN checks the check digits of a normalized book's ISBNs and that
+// both denote the same book.
+func validateISBN(v *internal.ValidationError, b internal.Book) {
+	valid10 := b.ISBN10 == internal.EMPTY || internal.ValidISBN10(b.ISBN10)
+	valid13 := b.ISBN13 == internal.EMPTY || internal.ValidISBN13(b.ISBN13)
+
+	v.Check(valid10, "ISBN10", "must be 10 digits, the last possibly X, with a valid check digit")
+	v.Check(valid13, "ISBN13", "must be 13 digits with a valid check digit")
+
+	if valid10 && valid13 && b.ISBN10 != internal.EMPTY {
+		v.Check(internal.ISBN10To13(b.ISBN10) == b.ISBN13, "ISBN10", "must be the same book as ISBN13")
+	}
+}
+
+// validateReference checks a genre, author or publisher reference, which must
+// carry either an ID or a Name.
+func validateReference(v *internal.ValidationError, field string, id int64, name string) {
+	switch {
+	case id < internal.ZERO:
+		v.Add(field+".ID", "must be a positive number")
+	case id == internal.ZERO && name == internal.EMPTY:
+		v.Add(field, "must have an ID or a Name")
+	case utf8.RuneCountInString(name) > internal.MaxNameLength:
+		v.Add(field+".Name", "must be at most %d characters", internal.MaxNameLength)
+	}
+}
@@ -0,0 +1,1014 @@
+// Package booktest holds the contract every book.IBookRepository
+// implementation must satisfy.
+package booktest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/book"
+	"github.com/amarantec/box/internal/reference"
+)
+
+// Repositories are the book repository under test and the repositories
+// holding the genres, authors and publishers its books reference.
+type Repositories struct {
+	Books      book.IBookRepository
+	Authors    reference.IRepository[internal.Author]
+	Genres     reference.IRepository[internal.Genre]
+	Publishers reference.IRepository[internal.Publisher]
+}
+
+// RunRepositoryContract runs the shared repository contract. newRepositories
+// must return empty repositories each time it is called.
+func RunRepositoryContract(t *testing.T, newRepositories func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, r Repositories)
+	}{
+		{"RegisterAndGet", testRegisterAndGet},
+		{"References", testReferences},
+		{"GetMissing", testGetMissing},
+		{"Update", testUpdate},
+		{"UpdateMissing", testUpdateMissing},
+		{"Patch", testPatch},
+		{"VersionConflict", testVersionConflict},
+		{"History", testHistory},
+		{"Delete", testDelete},
+		{"RestoreAndPurge", testRestoreAndPurge},
+		{"ISBN", testISBN},
+		{"Import", testImport},
+		{"Export", testExport},
+		{"ListByGenreAndAuthor", testListByGenreAndAuthor},
+		{"ListFilters", testListFilters},
+		{"ListSortAndOffset", testListSortAndOffset},
+		{"ListCursor", testListCursor},
+		{"ListInvalidCursor", testListInvalidCursor},
+		{"Search", testSearch},
+		{"ConcurrentRegister", testConcurrentRegister},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepositories(t))
+		})
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func newBook(title string) internal.Book {
+	return internal.Book{
+		Title:       title,
+		Description: "A book called " + title + ".",
+		Genres:      genres("Fiction"),
+		Authors:     authors("Jane Doe"),
+		PublishDate: date(2001, time.March, 4),
+		Publisher:   internal.Publisher{Name: "Acme"},
+		Pages:       100,
+	}
+}
+
+func genres(names ...string) []internal.Genre {
+	out := make([]internal.Genre, 0, len(names))
+	for _, name := range names {
+		out = append(out, internal.Genre{Name: name})
+	}
+	return out
+}
+
+func authors(names ...string) []internal.Author {
+	out := make([]internal.Author, 0, len(names))
+	for _, name := range names {
+		out = append(out, internal.Author{Name: name})
+	}
+	return out
+}
+
+// resolve stores the genres, authors and publisher b references by name and
+// sets their IDs, as the book repository does while writing a book.
+func resolve(t *testing.T, r Repositories, b *internal.Book) {
+	t.Helper()
+	ctx := context.Background()
+
+	var err error
+	if b.Genres, err = reference.EnsureAll(ctx, r.Genres, b.Genres); err != nil {
+		t.Fatalf("resolve genres: %v", err)
+	}
+	if b.Authors, err = reference.EnsureAll(ctx, r.Authors, b.Authors); err != nil {
+		t.Fatalf("resolve authors: %v", err)
+	}
+	if b.Publisher, err = r.Publishers.Ensure(ctx, b.Publisher.Name); err != nil {
+		t.Fatalf("resolve publisher %q: %v", b.Publisher.Name, err)
+	}
+}
+
+// register stores b and returns its new ID.
+func register(t *testing.T, r Repositories, b internal.Book) int64 {
+	t.Helper()
+
+	id, err := r.Books.RegisterBook(context.Background(), b)
+	if err != nil {
+		t.Fatalf("RegisterBook(%q): %v", b.Title, err)
+	}
+	if id <= internal.ZERO {
+		t.Fatalf("RegisterBook(%q) returned id %d, want a positive id", b.Title, id)
+	}
+	return id
+}
+
+func listQuery(q internal.BookQuery) internal.BookQuery {
+	if err := q.Normalize(); err != nil {
+		panic(err)
+	}
+	return q
+}
+
+func ids(books []internal.Book) []int64 {
+	out := make([]int64, 0, len(books))
+	for _, b := range books {
+		out = append(out, b.ID)
+	}
+	return out
+}
+
+func assertSameBook(t *testing.T, got, want internal.Book) {
+	t.Helper()
+
+	if got.ID != want.ID ||
+		got.Title != want.Title ||
+		got.ISBN10 != want.ISBN10 ||
+		got.ISBN13 != want.ISBN13 ||
+		got.Description != want.Description ||
+		!slices.Equal(got.Genres, want.Genres) ||
+		!slices.Equal(got.Authors, want.Authors) ||
+		!got.PublishDate.Equal(want.PublishDate) ||
+		got.Publisher != want.Publisher ||
+		got.Pages != want.Pages {
+		t.Fatalf("got book %+v, want %+v", got, want)
+	}
+}
+
+func testRegisterAndGet(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	want := newBook("Dune")
+	want.Genres = genres("Science Fiction", "Fiction")
+	want.Authors = authors("Frank Herbert", "Brian Herbert")
+	resolve(t, r, &want)
+	want.ID = register(t, r, want)
+
+	other := register(t, r, newBook("Emma"))
+	if other == want.ID {
+		t.Fatalf("two books registered with the same id %d", other)
+	}
+
+	got, err := r.Books.GetBookById(ctx, want.ID)
+	if err != nil {
+		t.Fatalf("GetBookById: %v", err)
+	}
+	assertSameBook(t, got, want)
+
+	if got.CreatedAt.IsZero() {
+		t.Fatalf("CreatedAt was not set")
+	}
+	if got.DeletedAt != nil {
+		t.Fatalf("DeletedAt = %v, want nil", got.DeletedAt)
+	}
+}
+
+func testReferences(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	b := newBook("Named")
+	b.Genres = genres("Poetry", "poetry")
+	b.Authors = authors("New Author")
+	b.Publisher = internal.Publisher{Name: "New House"}
+	id := register(t, r, b)
+
+	got, err := r.Books.GetBookById(ctx, id)
+	if err != nil {
+		t.Fatalf("GetBookById: %v", err)
+	}
+	if len(got.Genres) != 1 || got.Genres[0].ID == internal.ZERO {
+		t.Fatalf("Genres = %+v, want the one genre registered for both names", got.Genres)
+	}
+	author, err := r.Authors.GetByName(ctx, "new author")
+	if err != nil || len(got.Authors) != 1 || got.Authors[0] != author {
+		t.Fatalf("Authors = %+v, want the registered %+v (%v)", got.Authors, author, err)
+	}
+
+	refused := newBook("Refused")
+	refused.Genres = []internal.Genre{{ID: 4242}}
+	refused.Authors = authors("Unwritten Author")
+	refused.Publisher = internal.Publisher{Name: "Unwritten House"}
+	var validationErr *internal.ValidationError
+	if _, err := r.Books.RegisterBook(ctx, refused); !errors.As(err, &validationErr) {
+		t.Fatalf("RegisterBook(missing genre ID) error = %v, want a validation error", err)
+	}
+	if _, err := r.Authors.GetByName(ctx, "Unwritten Author"); !errors.Is(err, internal.ErrAuthorNotFound) {
+		t.Fatalf("author of a refused book: error = %v, want %v", err, internal.ErrAuthorNotFound)
+	}
+	if _, err := r.Publishers.GetByName(ctx, "Unwritten House"); !errors.Is(err, internal.ErrPublisherNotFound) {
+		t.Fatalf("publisher of a refused book: error = %v, want %v", err, internal.ErrPublisherNotFound)
+	}
+
+	tx, err := r.Books.BeginImport(ctx)
+	if err != nil {
+		t.Fatalf("BeginImport: %v", err)
+	}
+	imported := newBook("Imported")
+	imported.Genres = genres("Rolled Back")
+	if _, err := tx.InsertBooks(ctx, []internal.Book{imported}); err != nil {
+		t.Fatalf("InsertBooks: %v", err)
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if _, err := r.Genres.GetByName(ctx, "Rolled Back"); !errors.Is(err, internal.ErrGenreNotFound) {
+		t.Fatalf("genre of a rolled back import: error = %v, want %v", err, internal.ErrGenreNotFound)
+	}
+}
+
+func testGetMissing(t *testing.T, r Repositories) {
+	if _, err := r.Books.GetBookById(context.Background(), 4242); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("GetBookById(missing) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+}
+
+func testUpdate(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	b := newBook("Draft")
+	b.ID = register(t, r, b)
+
+	b.Title = "Final"
+	b.Pages = 321
+	b.Authors = authors("John Roe", "Jane Doe")
+	b.Genres = genres("Essay")
+	b.Publisher = internal.Publisher{Name: "Other House"}
+	resolve(t, r, &b)
+
+	ok, err := r.Books.UpdateBook(ctx, b)
+	if err != nil || !ok {
+		t.Fatalf("UpdateBook = %v, %v, want true, nil", ok, err)
+	}
+
+	got, err := r.Books.GetBookById(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("GetBookById: %v", err)
+	}
+	assertSameBook(t, got, b)
+
+	if got.UpdatedAt == nil {
+		t.Fatalf("UpdatedAt was not set")
+	}
+}
+
+func testUpdateMissing(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	missing := newBook("Missing")
+	resolve(t, r, &missing)
+	missing.ID = 4242
+	if _, err := r.Books.UpdateBook(ctx, missing); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("UpdateBook(missing) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+
+	deleted := newBook("Deleted")
+	resolve(t, r, &deleted)
+	deleted.ID = register(t, r, deleted)
+	if _, err := r.Books.DeleteBook(ctx, deleted.ID, internal.ZERO); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+	if _, err := r.Books.UpdateBook(ctx, deleted); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("UpdateBook(deleted) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+}
+
+func testPatch(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	want := newBook("Draft")
+	resolve(t, r, &want)
+	want.ID = register(t, r, want)
+
+	// Only the listed fields are written; the rest of the argument is ignored.
+	patch := internal.Book{ID: want.ID, Title: "Ignored", Pages: 512, Authors: authors("John Roe")}
+	resolve(t, r, &patch)
+	want.Pages = patch.Pages
+	want.Authors = patch.Authors
+
+	ok, err := r.Books.PatchBook(ctx, patch, []internal.BookField{internal.BookFieldPages, internal.BookFieldAuthors})
+	if err != nil || !ok {
+		t.Fatalf("PatchBook = %v, %v, want true, nil", ok, err)
+	}
+
+	got, err := r.Books.GetBookById(ctx, want.ID)
+	if err != nil {
+		t.Fatalf("GetBookById: %v", err)
+	}
+	assertSameBook(t, got, want)
+	if got.UpdatedAt == nil {
+		t.Fatalf("UpdatedAt was not set")
+	}
+
+	patch.ID = 4242
+	if _, err := r.Books.PatchBook(ctx, patch, []internal.BookField{internal.BookFieldPages}); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("PatchBook(missing) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+}
+
+func testVersionConflict(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	b := newBook("Draft")
+	resolve(t, r, &b)
+	b.ID = register(t, r, b)
+
+	stored, err := r.Books.GetBookById(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("GetBookById: %v", err)
+	}
+	if stored.Version <= internal.ZERO {
+		t.Fatalf("registered book has version %d, want a positive version", stored.Version)
+	}
+
+	b.Title = "First edit"
+	b.Version = stored.Version
+	if _, err := r.Books.UpdateBook(ctx, b); err != nil {
+		t.Fatalf("UpdateBook(current version): %v", err)
+	}
+
+	updated, err := r.Books.GetBookById(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("GetBookById: %v", err)
+	}
+	if updated.Version <= stored.Version {
+		t.Fatalf("version after update = %d, want more than %d", updated.Version, stored.Version)
+	}
+
+	// A second writer still holding the first version must not overwrite.
+	b.Title = "Stale edit"
+	if _, err := r.Books.UpdateBook(ctx, b); !errors.Is(err, internal.ErrBookModified) {
+		t.Fatalf("UpdateBook(stale version) error = %v, want %v", err, internal.ErrBookModified)
+	}
+	if _, err := r.Books.PatchBook(ctx, b, []internal.BookField{internal.BookFieldTitle}); !errors.Is(err, internal.ErrBookModified) {
+		t.Fatalf("PatchBook(stale version) error = %v, want %v", err, internal.ErrBookModified)
+	}
+	if _, err := r.Books.DeleteBook(ctx, b.ID, stored.Version); !errors.Is(err, internal.ErrBookModified) {
+		t.Fatalf("DeleteBook(stale version) error = %v, want %v", err, internal.ErrBookModified)
+	}
+
+	got, err := r.Books.GetBookById(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("GetBookById: %v", err)
+	}
+	if got.Title != "First edit" {
+		t.Fatalf("title = %q after stale writes, want %q", got.Title, "First edit")
+	}
+
+	if _, err := r.Books.DeleteBook(ctx, b.ID, got.Version); err != nil {
+		t.Fatalf("DeleteBook(current version): %v", err)
+	}
+}
+
+func testHistory(t *testing.T, r Repositories) {
+	ctx := internal.WithRequestID(internal.WithActor(context.Background(), "editor"), "req-1")
+
+	b := newBook("Draft")
+	resolve(t, r, &b)
+	id, err := r.Books.RegisterBook(ctx, b)
+	if err != nil {
+		t.Fatalf("RegisterBook: %v", err)
+	}
+	b.ID = id
+
+	b.Title = "Final"
+	if _, err := r.Books.UpdateBook(ctx, b); err != nil {
+		t.Fatalf("UpdateBook: %v", err)
+	}
+	if _, err := r.Books.DeleteBook(ctx, b.ID, internal.ZERO); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+
+	revisions, err := r.Books.ListBookRevisions(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("ListBookRevisions: %v", err)
+	}
+
+	actions := []string{internal.RevisionCreate, internal.RevisionUpdate, internal.RevisionDelete}
+	if len(revisions) != len(actions) {
+		t.Fatalf("got %d revisions, want %d", len(revisions), len(actions))
+	}
+	for i, rev := range revisions {
+		if rev.Action != actions[i] || rev.BookID != b.ID || rev.Actor != "editor" || rev.RequestID != "req-1" || rev.After == nil {
+			t.Fatalf("revision %d = %+v, want a %s of book %d by editor in req-1", i, rev, actions[i], b.ID)
+		}
+		if i > 0 && rev.Version <= revisions[i-1].Version {
+			t.Fatalf("revision %d has version %d, not after %d", i, rev.Version, revisions[i-1].Version)
+		}
+	}
+	if revisions[0].Before != nil || revisions[0].After.Title != "Draft" {
+		t.Fatalf("create revision = %+v, want no before and a Draft after", revisions[0])
+	}
+	if revisions[1].Before.Title != "Draft" || revisions[1].After.Title != "Final" {
+		t.Fatalf("update revision titles = %q -> %q, want Draft -> Final", revisions[1].Before.Title, revisions[1].After.Title)
+	}
+	if revisions[2].After.DeletedAt == nil {
+		t.Fatalf("delete revision does not record the deletion")
+	}
+
+	got, err := r.Books.GetBookRevision(ctx, b.ID, revisions[1].ID)
+	if err != nil || got.ID != revisions[1].ID {
+		t.Fatalf("GetBookRevision = %+v, %v, want revision %d", got, err, revisions[1].ID)
+	}
+	if _, err := r.Books.GetBookRevision(ctx, b.ID+1, revisions[1].ID); !errors.Is(err, internal.ErrBookRevisionNotFound) {
+		t.Fatalf("GetBookRevision(other book) error = %v, want %v", err, internal.ErrBookRevisionNotFound)
+	}
+	if _, err := r.Books.ListBookRevisions(ctx, 4242); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("ListBookRevisions(missing) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+}
+
+func testDelete(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	kept := register(t, r, newBook("Kept"))
+	deleted := register(t, r, newBook("Deleted"))
+
+	ok, err := r.Books.DeleteBook(ctx, deleted, internal.ZERO)
+	if err != nil || !ok {
+		t.Fatalf("DeleteBook = %v, %v, want true, nil", ok, err)
+	}
+
+	if _, err := r.Books.GetBookById(ctx, deleted); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("GetBookById(deleted) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+
+	if _, err := r.Books.DeleteBook(ctx, deleted, internal.ZERO); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("DeleteBook(deleted twice) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+
+	if _, err := r.Books.DeleteBook(ctx, 4242, internal.ZERO); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("DeleteBook(missing) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+
+	books, pagination, err := r.Books.ListBooks(ctx, listQuery(internal.BookQuery{}))
+	if err != nil {
+		t.Fatalf("ListBooks: %v", err)
+	}
+	if got := ids(books); !slices.Equal(got, []int64{kept}) || pagination.TotalCount != 1 {
+		t.Fatalf("ListBooks after delete = %v (total %d), want [%d] (total 1)", got, pagination.TotalCount, kept)
+	}
+}
+
+func testRestoreAndPurge(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	live := register(t, r, newBook("Live"))
+	restored := register(t, r, newBook("Restored"))
+	purged := register(t, r, newBook("Purged"))
+	for _, id := range []int64{restored, purged} {
+		if _, err := r.Books.DeleteBook(ctx, id, internal.ZERO); err != nil {
+			t.Fatalf("DeleteBook(%d): %v", id, err)
+		}
+	}
+
+	trash, pagination, err := r.Books.ListDeletedBooks(ctx, internal.DeletedBookQuery{PageSize: internal.DefaultPageSize})
+	if err != nil {
+		t.Fatalf("ListDeletedBooks: %v", err)
+	}
+	if got := ids(trash); !slices.Equal(got, []int64{purged, restored}) || pagination.TotalCount != 2 {
+		t.Fatalf("ListDeletedBooks = %v (total %d), want [%d %d] (total 2)", got, pagination.TotalCount, purged, restored)
+	}
+	if trash[0].DeletedAt == nil {
+		t.Fatalf("ListDeletedBooks does not report when books were deleted")
+	}
+
+	if _, err := r.Books.RestoreBook(ctx, live, internal.ZERO); !errors.Is(err, internal.ErrBookNotDeleted) {
+		t.Fatalf("RestoreBook(live) error = %v, want %v", err, internal.ErrBookNotDeleted)
+	}
+	if _, err := r.Books.RestoreBook(ctx, restored, 1); !errors.Is(err, internal.ErrBookModified) {
+		t.Fatalf("RestoreBook(stale version) error = %v, want %v", err, internal.ErrBookModified)
+	}
+	if ok, err := r.Books.RestoreBook(ctx, restored, internal.ZERO); err != nil || !ok {
+		t.Fatalf("RestoreBook = %v, %v, want true, nil", ok, err)
+	}
+	got, err := r.Books.GetBookById(ctx, restored)
+	if err != nil || got.Version != 3 {
+		t.Fatalf("GetBookById(restored) = version %d, %v, want version 3", got.Version, err)
+	}
+
+	if _, err := r.Books.PurgeBook(ctx, live); !errors.Is(err, internal.ErrBookNotDeleted) {
+		t.Fatalf("PurgeBook(live) error = %v, want %v", err, internal.ErrBookNotDeleted)
+	}
+	if ok, err := r.Books.PurgeBook(ctx, purged); err != nil || !ok {
+		t.Fatalf("PurgeBook = %v, %v, want true, nil", ok, err)
+	}
+	if _, err := r.Books.PurgeBook(ctx, purged); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("PurgeBook(purged twice) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+	if _, err := r.Books.RestoreBook(ctx, purged, internal.ZERO); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("RestoreBook(purged) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+
+	revisions, err := r.Books.ListBookRevisions(ctx, purged)
+	if err != nil {
+		t.Fatalf("ListBookRevisions(purged): %v", err)
+	}
+	last := revisions[len(revisions)-1]
+	if last.Action != internal.RevisionPurge || last.Before == nil || last.After != nil {
+		t.Fatalf("last revision of a purged book = %+v, want a purge with only a before snapshot", last)
+	}
+
+	if _, err := r.Books.DeleteBook(ctx, live, internal.ZERO); err != nil {
+		t.Fatalf("DeleteBook(live): %v", err)
+	}
+	n, err := r.Books.PurgeDeletedBooks(ctx, time.Now().Add(-time.Hour))
+	if err != nil || n != 0 {
+		t.Fatalf("PurgeDeletedBooks(an hour ago) = %d, %v, want 0, nil", n, err)
+	}
+	n, err = r.Books.PurgeDeletedBooks(ctx, time.Now().Add(time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("PurgeDeletedBooks(in an hour) = %d, %v, want 1, nil", n, err)
+	}
+	if _, err := r.Books.RestoreBook(ctx, live, internal.ZERO); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("RestoreBook(expired) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+	if _, err := r.Books.GetBookById(ctx, restored); err != nil {
+		t.Fatalf("GetBookById(restored) after retention: %v", err)
+	}
+}
+
+func testISBN(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	want := newBook("Numbered")
+	want.ISBN10, want.ISBN13 = "0306406152", "9780306406157"
+	resolve(t, r, &want)
+	want.ID = register(t, r, want)
+
+	got, err := r.Books.GetBookByISBN(ctx, want.ISBN13)
+	if err != nil {
+		t.Fatalf("GetBookByISBN: %v", err)
+	}
+	assertSameBook(t, got, want)
+
+	if _, err := r.Books.GetBookByISBN(ctx, "9783161484100"); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("GetBookByISBN(missing) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+
+	// Books without an ISBN do not conflict with each other.
+	register(t, r, newBook("Plain"))
+	other := newBook("Other")
+	resolve(t, r, &other)
+	other.ID = register(t, r, other)
+
+	duplicate := newBook("Duplicate")
+	duplicate.ISBN13 = want.ISBN13
+	resolve(t, r, &duplicate)
+	if _, err := r.Books.RegisterBook(ctx, duplicate); !errors.Is(err, internal.ErrISBNAlreadyExists) {
+		t.Fatalf("RegisterBook(duplicate ISBN) error = %v, want %v", err, internal.ErrISBNAlreadyExists)
+	}
+
+	other.ISBN13 = want.ISBN13
+	if _, err := r.Books.UpdateBook(ctx, other); !errors.Is(err, internal.ErrISBNAlreadyExists) {
+		t.Fatalf("UpdateBook(duplicate ISBN) error = %v, want %v", err, internal.ErrISBNAlreadyExists)
+	}
+	if _, err := r.Books.PatchBook(ctx, other, []internal.BookField{internal.BookFieldISBN}); !errors.Is(err, internal.ErrISBNAlreadyExists) {
+		t.Fatalf("PatchBook(duplicate ISBN) error = %v, want %v", err, internal.ErrISBNAlreadyExists)
+	}
+
+	// A deleted book gives up its ISBN until it is restored.
+	if _, err := r.Books.DeleteBook(ctx, want.ID, internal.ZERO); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+	if _, err := r.Books.PatchBook(ctx, other, []internal.BookField{internal.BookFieldISBN}); err != nil {
+		t.Fatalf("PatchBook(ISBN of a deleted book): %v", err)
+	}
+	if _, err := r.Books.RestoreBook(ctx, want.ID, internal.ZERO); !errors.Is(err, internal.ErrISBNAlreadyExists) {
+		t.Fatalf("RestoreBook(ISBN taken) error = %v, want %v", err, internal.ErrISBNAlreadyExists)
+	}
+
+	tx, err := r.Books.BeginImport(ctx)
+	if err != nil {
+		t.Fatalf("BeginImport: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	fresh := newBook("Fresh")
+	fresh.ISBN10, fresh.ISBN13 = "316148410X", "9783161484100"
+	resolve(t, r, &fresh)
+	if _, err := tx.InsertBooks(ctx, []internal.Book{fresh}); err != nil {
+		t.Fatalf("InsertBooks: %v", err)
+	}
+	if _, err := tx.InsertBooks(ctx, []internal.Book{fresh}); !errors.Is(err, internal.ErrISBNAlreadyExists) {
+		t.Fatalf("InsertBooks(ISBN imported earlier) error = %v, want %v", err, internal.ErrISBNAlreadyExists)
+	}
+	if _, err := tx.InsertBooks(ctx, []internal.Book{duplicate}); !errors.Is(err, internal.ErrISBNAlreadyExists) {
+		t.Fatalf("InsertBooks(ISBN in the catalog) error = %v, want %v", err, internal.ErrISBNAlreadyExists)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if _, err := r.Books.GetBookByISBN(ctx, fresh.ISBN13); err != nil {
+		t.Fatalf("GetBookByISBN(imported): %v", err)
+	}
+}
+
+func testImport(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	first, second := newBook("First"), newBook("Second")
+	resolve(t, r, &first)
+	resolve(t, r, &second)
+
+	tx, err := r.Books.BeginImport(ctx)
+	if err != nil {
+		t.Fatalf("BeginImport: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	bookIds, err := tx.InsertBooks(ctx, []internal.Book{first, second})
+	if err != nil || len(bookIds) != 2 {
+		t.Fatalf("InsertBooks = %v, %v, want two IDs", bookIds, err)
+	}
+	if _, err := r.Books.GetBookById(ctx, bookIds[0]); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("GetBookById before commit error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	got, err := r.Books.GetBookById(ctx, bookIds[1])
+	if err != nil {
+		t.Fatalf("GetBookById(imported): %v", err)
+	}
+	second.ID = bookIds[1]
+	assertSameBook(t, got, second)
+	if got.Version != 1 {
+		t.Fatalf("imported book has version %d, want 1", got.Version)
+	}
+
+	revisions, err := r.Books.ListBookRevisions(ctx, bookIds[0])
+	if err != nil || len(revisions) != 1 || revisions[0].Action != internal.RevisionCreate {
+		t.Fatalf("ListBookRevisions(imported) = %+v, %v, want a single create", revisions, err)
+	}
+
+	tx, err = r.Books.BeginImport(ctx)
+	if err != nil {
+		t.Fatalf("BeginImport: %v", err)
+	}
+	dropped := first
+	dropped.Title = "Dropped"
+	bookIds, err = tx.InsertBooks(ctx, []internal.Book{dropped})
+	if err != nil {
+		t.Fatalf("InsertBooks: %v", err)
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if _, err := r.Books.GetBookById(ctx, bookIds[0]); !errors.Is(err, internal.ErrBookNotFound) {
+		t.Fatalf("GetBookById(rolled back) error = %v, want %v", err, internal.ErrBookNotFound)
+	}
+}
+
+func testExport(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	var registered []int64
+	for i, title := range []string{"Charlie", "Alpha", "Bravo", "Delta"} {
+		b := newBook(title)
+		b.Pages = 100 + i
+		if title == "Delta" {
+			b.Genres = genres("Poetry")
+		}
+		registered = append(registered, register(t, r, b))
+	}
+	charlie, alpha, bravo := registered[0], registered[1], registered[2]
+	if _, err := r.Books.DeleteBook(ctx, bravo, internal.ZERO); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+
+	// The page of the query must not limit an export.
+	q := listQuery(internal.BookQuery{SortBy: "title", SortDirection: internal.SortDesc, Genre: "fiction", PageSize: 1})
+
+	var exported []internal.Book
+	err := r.Books.ExportBooks(ctx, q, func(b internal.Book) error {
+		exported = append(exported, b)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportBooks: %v", err)
+	}
+	if got, want := ids(exported), []int64{charlie, alpha}; !slices.Equal(got, want) {
+		t.Fatalf("ExportBooks(genre=fiction, sort=-title) = %v, want %v", got, want)
+	}
+	if len(exported[0].Authors) != 1 || exported[0].Publisher.Name != "Acme" {
+		t.Fatalf("exported book = %+v, want its authors and publisher", exported[0])
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = r.Books.ExportBooks(ctx, q, func(b internal.Book) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Fatalf("ExportBooks with a failing callback = %v after %d calls, want %v after 1", err, calls, stop)
+	}
+}
+
+func testListByGenreAndAuthor(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	a := newBook("A")
+	a.Genres = genres("Horror", "Fiction")
+	a.Authors = authors("Ann")
+	aID := register(t, r, a)
+
+	b := newBook("B")
+	b.Genres = genres("Fiction")
+	b.Authors = authors("Ann", "Bob")
+	bID := register(t, r, b)
+
+	c := newBook("C")
+	c.Genres = genres("Horror")
+	c.Authors = authors("Bob")
+	cID := register(t, r, c)
+
+	if _, err := r.Books.DeleteBook(ctx, cID, internal.ZERO); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+
+	byGenre, err := r.Books.ListBooksByGenre(ctx, "horror")
+	if err != nil {
+		t.Fatalf("ListBooksByGenre: %v", err)
+	}
+	if got := ids(byGenre); !slices.Equal(got, []int64{aID}) {
+		t.Fatalf("ListBooksByGenre(horror) = %v, want [%d]", got, aID)
+	}
+
+	byAuthor, err := r.Books.ListBooksByAuthor(ctx, "Ann")
+	if err != nil {
+		t.Fatalf("ListBooksByAuthor: %v", err)
+	}
+	if got := ids(byAuthor); !slices.Equal(got, []int64{aID, bID}) {
+		t.Fatalf("ListBooksByAuthor(Ann) = %v, want [%d %d]", got, aID, bID)
+	}
+}
+
+func testListFilters(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	short := newBook("Short")
+	short.Pages = 50
+	short.Publisher = internal.Publisher{Name: "Small Press"}
+	short.PublishDate = date(1990, time.January, 1)
+	short.Genres = genres("Poetry")
+	shortID := register(t, r, short)
+
+	medium := newBook("Medium")
+	medium.Pages = 200
+	medium.PublishDate = date(2005, time.June, 15)
+	medium.Authors = authors("Max Mustermann")
+	mediumID := register(t, r, medium)
+
+	long := newBook("Long")
+	long.Pages = 900
+	long.PublishDate = date(2020, time.December, 31)
+	longID := register(t, r, long)
+
+	minPages, maxPages := 100, 500
+	from, to := date(2000, time.January, 1), date(2021, time.January, 1)
+
+	tests := []struct {
+		name  string
+		query internal.BookQuery
+		want  []int64
+	}{
+		{"All", internal.BookQuery{}, []int64{shortID, mediumID, longID}},
+		{"Publisher", internal.BookQuery{Publisher: "small press"}, []int64{shortID}},
+		{"PublishedRange", internal.BookQuery{PublishedFrom: &from, PublishedTo: &to}, []int64{mediumID, longID}},
+		{"PagesRange", internal.BookQuery{MinPages: &minPages, MaxPages: &maxPages}, []int64{mediumID}},
+		{"Genre", internal.BookQuery{Genre: "Poetry"}, []int64{shortID}},
+		{"Author", internal.BookQuery{Author: "MAX MUSTERMANN"}, []int64{mediumID}},
+		{"NoMatch", internal.BookQuery{Genre: "Cooking"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			books, pagination, err := r.Books.ListBooks(ctx, listQuery(tt.query))
+			if err != nil {
+				t.Fatalf("ListBooks: %v", err)
+			}
+			if got := ids(books); !slices.Equal(got, tt.want) {
+				t.Fatalf("ListBooks = %v, want %v", got, tt.want)
+			}
+			if pagination.TotalCount != int64(len(tt.want)) {
+				t.Fatalf("TotalCount = %d, want %d", pagination.TotalCount, len(tt.want))
+			}
+		})
+	}
+}
+
+func testListSortAndOffset(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	var registered []int64
+	for i, title := range []string{"Charlie", "Alpha", "Bravo", "Delta"} {
+		b := newBook(title)
+		b.Pages = 100 + i
+		registered = append(registered, register(t, r, b))
+	}
+	charlie, alpha, bravo, delta := registered[0], registered[1], registered[2], registered[3]
+
+	books, pagination, err := r.Books.ListBooks(ctx, listQuery(internal.BookQuery{SortBy: "title"}))
+	if err != nil {
+		t.Fatalf("ListBooks: %v", err)
+	}
+	if got, want := ids(books), []int64{alpha, bravo, charlie, delta}; !slices.Equal(got, want) {
+		t.Fatalf("ListBooks(sort=title) = %v, want %v", got, want)
+	}
+	if pagination.NextCursor != internal.EMPTY {
+		t.Fatalf("NextCursor = %q on the last page, want empty", pagination.NextCursor)
+	}
+
+	books, pagination, err = r.Books.ListBooks(ctx, listQuery(internal.BookQuery{
+		SortBy:        "pages",
+		SortDirection: internal.SortDesc,
+		PageSize:      2,
+		Offset:        1,
+	}))
+	if err != nil {
+		t.Fatalf("ListBooks: %v", err)
+	}
+	if got, want := ids(books), []int64{bravo, alpha}; !slices.Equal(got, want) {
+		t.Fatalf("ListBooks(sort=-pages, offset=1, size=2) = %v, want %v", got, want)
+	}
+	if pagination.TotalCount != 4 || pagination.PageSize != 2 {
+		t.Fatalf("pagination = %+v, want total 4 and page size 2", pagination)
+	}
+	if pagination.NextCursor == internal.EMPTY {
+		t.Fatalf("NextCursor is empty but one more book remains")
+	}
+}
+
+func testListCursor(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	var want []int64
+	for i, title := range []string{"E", "D", "C", "B", "A"} {
+		b := newBook(title)
+		// Two books share each page count so the id tie-breaker matters.
+		b.Pages = 10 * (i / 2)
+		want = append(want, register(t, r, b))
+	}
+
+	for _, sortBy := range []string{"id", "title", "pages", "publish_date", "created_at"} {
+		t.Run(sortBy, func(t *testing.T) {
+			all, _, err := r.Books.ListBooks(ctx, listQuery(internal.BookQuery{SortBy: sortBy, SortDirection: internal.SortDesc}))
+			if err != nil {
+				t.Fatalf("ListBooks: %v", err)
+			}
+
+			var paged []int64
+			q := listQuery(internal.BookQuery{SortBy: sortBy, SortDirection: internal.SortDesc, PageSize: 2})
+			for page := 0; ; page++ {
+				if page > len(want) {
+					t.Fatalf("cursor pagination did not terminate")
+				}
+
+				books, pagination, err := r.Books.ListBooks(ctx, q)
+				if err != nil {
+					t.Fatalf("ListBooks(page %d): %v", page, err)
+				}
+				paged = append(paged, ids(books)...)
+
+				if pagination.NextCursor == internal.EMPTY {
+					break
+				}
+				q.Cursor = pagination.NextCursor
+			}
+
+			if got := ids(all); !slices.Equal(paged, got) || len(paged) != len(want) {
+				t.Fatalf("paging with cursors = %v, want %v", paged, got)
+			}
+		})
+	}
+}
+
+func testListInvalidCursor(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	register(t, r, newBook("A"))
+	register(t, r, newBook("B"))
+
+	_, pagination, err := r.Books.ListBooks(ctx, listQuery(internal.BookQuery{SortBy: "title", PageSize: 1}))
+	if err != nil {
+		t.Fatalf("ListBooks: %v", err)
+	}
+
+	for name, q := range map[string]internal.BookQuery{
+		"Malformed":    {Cursor: "not a cursor"},
+		"OtherSortKey": {Cursor: pagination.NextCursor, SortBy: "pages"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := r.Books.ListBooks(ctx, listQuery(q)); !errors.Is(err, internal.ErrInvalidBookQuery) {
+				t.Fatalf("ListBooks error = %v, want %v", err, internal.ErrInvalidBookQuery)
+			}
+		})
+	}
+}
+
+func testSearch(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	inDescription := newBook("Sea Stories")
+	inDescription.Description = "A voyage to find the dragon of the deep."
+	inDescriptionID := register(t, r, inDescription)
+
+	inTitle := newBook("Dragon Tales & Co")
+	inTitle.Description = "Stories for children."
+	inTitleID := register(t, r, inTitle)
+
+	deleted := newBook("Dragon Deleted")
+	deletedID := register(t, r, deleted)
+	if _, err := r.Books.DeleteBook(ctx, deletedID, internal.ZERO); err != nil {
+		t.Fatalf("DeleteBook: %v", err)
+	}
+
+	register(t, r, newBook("Unrelated"))
+
+	q := internal.BookSearchQuery{Query: "dragon"}
+	if err := q.Normalize(); err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+
+	results, pagination, err := r.Books.SearchBooks(ctx, q)
+	if err != nil {
+		t.Fatalf("SearchBooks: %v", err)
+	}
+
+	var got []int64
+	for _, r := range results {
+		got = append(got, r.Book.ID)
+	}
+	if want := []int64{inTitleID, inDescriptionID}; !slices.Equal(got, want) {
+		t.Fatalf("SearchBooks(dragon) = %v, want %v (title match first)", got, want)
+	}
+	if pagination.TotalCount != 2 {
+		t.Fatalf("TotalCount = %d, want 2", pagination.TotalCount)
+	}
+	if want := "<mark>Dragon</mark> Tales &amp; Co"; results[0].TitleHighlight != want {
+		t.Fatalf("TitleHighlight = %q, want %q", results[0].TitleHighlight, want)
+	}
+}
+
+func testConcurrentRegister(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Books.RegisterBook(ctx, newBook(fmt.Sprintf("Book %02d", i))); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("RegisterBook: %v", err)
+	}
+
+	books, pagination, err := r.Books.ListBooks(ctx, listQuery(internal.BookQuery{PageSize: internal.MaxPageSize}))
+	if err != nil {
+		t.Fatalf("ListBooks: %v", err)
+	}
+	if len(books) != n || pagination.TotalCount != n {
+		t.Fatalf("ListBooks returned %d books (total %d), want %d", len(books), pagination.TotalCount, n)
+	}
+
+	seen := map[int64]bool{}
+	for _, b := range books {
+		if seen[b.ID] {
+			t.Fatalf("id %d was assigned twice", b.ID)
+		}
+		seen[b.ID] = true
+	}
+}
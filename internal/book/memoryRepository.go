@@ -0,0 +1,892 @@
+package book
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"html"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/inventory"
+	"github.com/amarantec/box/internal/reference"
+)
+
+// Search weights mirror PostgreSQL's default ts_rank weights for the A, B and
+// C labels used by the books search_vector.
+const (
+	titleWeight       = 1.0
+	authorWeight      = 0.4
+	descriptionWeight = 0.2
+)
+
+// memoryBookRepository is an IBookRepository kept in process memory. It has
+// the same soft-delete and not-found semantics as bookRepository and is meant
+// for tests and running the server without a database. Genre, author and
+// publisher names are stored as they were when the book was last written.
+//
+// References are resolved against the given repositories once every other
+// check has passed, and names are only registered once all IDs are known to
+// exist, so that a refused write registers nothing. copies tells which books
+// have a copy on loan and so cannot be deleted.
+type memoryBookRepository struct {
+	mu        sync.RWMutex
+	books     map[int64]internal.Book
+	nextID    int64
+	revisions []internal.BookRevision
+	refs      bookReferences
+	copies    inventory.ICopyRepository
+}
+
+func NewMemoryBookRepository(
+	authors reference.IRepository[internal.Author],
+	genres reference.IRepository[internal.Genre],
+	publishers reference.IRepository[internal.Publisher],
+	copies inventory.ICopyRepository,
+) IBookRepository {
+	return &memoryBookRepository{
+		books:  map[int64]internal.Book{},
+		refs:   bookReferences{genres: genres, authors: authors, publishers: publishers},
+		copies: copies,
+	}
+}
+
+func cloneBook(b internal.Book) internal.Book {
+	b.Genres = slices.Clone(b.Genres)
+	b.Authors = slices.Clone(b.Authors)
+	return b
+}
+
+// activeBooks returns copies of the books that are not soft-deleted, ordered
+// by ID. The caller must hold r.mu.
+func (r *memoryBookRepository) activeBooks() []internal.Book {
+	books := make([]internal.Book, 0, len(r.books))
+	for _, b := range r.books {
+		if b.DeletedAt == nil {
+			books = append(books, cloneBook(b))
+		}
+	}
+
+	slices.SortFunc(books, func(a, b internal.Book) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return books
+}
+
+// record appends a revision for the change just made to after. The caller
+// must hold r.mu for writing.
+func (r *memoryBookRepository) record(ctx context.Context, action string, before *internal.Book, after internal.Book) {
+	after = cloneBook(after)
+	r.appendRevision(ctx, action, after.ID, after.Version, before, &after)
+}
+
+func (r *memoryBookRepository) appendRevision(ctx context.Context, action string, bookId, version int64, before, after *internal.Book) {
+	if before != nil {
+		b := cloneBook(*before)
+		before = &b
+	}
+
+	r.revisions = append(r.revisions, internal.BookRevision{
+		ID:        int64(len(r.revisions) + 1),
+		BookID:    bookId,
+		Version:   version,
+		Action:    action,
+		Actor:     internal.ActorFromContext(ctx),
+		RequestID: internal.RequestIDFromContext(ctx),
+		Before:    before,
+		After:     after,
+		CreatedAt: time.Now(),
+	})
+}
+
+// current returns the stored book about to be written, checking it against
+// the expected version unless version is zero. The caller must hold r.mu.
+func (r *memoryBookRepository) current(bookId, version int64) (internal.Book, error) {
+	b, ok := r.books[bookId]
+	if !ok || b.DeletedAt != nil {
+		return internal.Book{}, internal.ErrBookNotFound
+	}
+	if version != internal.ZERO && version != b.Version {
+		return internal.Book{}, internal.ErrBookModified
+	}
+	return b, nil
+}
+
+// isbnTaken reports whether a live book other than bookId has isbn13, as the
+// unique index of bookRepository would. The caller must hold r.mu.
+func (r *memoryBookRepository) isbnTaken(isbn13 string, bookId int64) bool {
+	if isbn13 == internal.EMPTY {
+		return false
+	}
+	for _, b := range r.books {
+		if b.ID != bookId && b.DeletedAt == nil && b.ISBN13 == isbn13 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryBookRepository) RegisterBook(ctx context.Context, b internal.Book) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isbnTaken(b.ISBN13, internal.ZERO) {
+		return internal.ZERO, internal.ErrISBNAlreadyExists
+	}
+	if err := r.refs.resolve(ctx, &b); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.nextID++
+	b.ID = r.nextID
+	b.CreatedAt = time.Now()
+	b.UpdatedAt = nil
+	b.DeletedAt = nil
+	b.Version = 1
+	r.books[b.ID] = cloneBook(b)
+	r.record(ctx, internal.RevisionCreate, nil, b)
+
+	return b.ID, nil
+}
+
+func (r *memoryBookRepository) ListBooks(ctx context.Context, q internal.BookQuery) ([]internal.Book, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+	if err := ctx.Err(); err != nil {
+		return []internal.Book{}, pagination, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	books := r.queryBooks(q)
+	pagination.TotalCount = int64(len(books))
+	compare := bookOrder(q)
+
+	if q.Cursor != internal.EMPTY {
+		value, id, err := decodeBookCursor(q.Cursor, q.SortBy)
+		if err != nil {
+			return []internal.Book{}, pagination, err
+		}
+		after := cursorBook(q.SortBy, value, id)
+		start := len(books)
+		for i, b := range books {
+			if compare(b, after) > internal.ZERO {
+				start = i
+				break
+			}
+		}
+		books = books[start:]
+	} else if q.Offset > internal.ZERO {
+		books = books[min(q.Offset, len(books)):]
+	}
+
+	if len(books) > q.PageSize {
+		books = books[:q.PageSize]
+		cursor, err := encodeBookCursor(q.SortBy, books[len(books)-1])
+		if err != nil {
+			return []internal.Book{}, pagination, err
+		}
+		pagination.NextCursor = cursor
+	}
+
+	return books, pagination, nil
+}
+
+// queryBooks returns copies of the books matching q's filters, sorted by
+// bookOrder. The caller must hold r.mu.
+func (r *memoryBookRepository) queryBooks(q internal.BookQuery) []internal.Book {
+	var books []internal.Book
+	for _, b := range r.activeBooks() {
+		if matchesBookQuery(b, q) {
+			books = append(books, b)
+		}
+	}
+
+	slices.SortFunc(books, bookOrder(q))
+	return books
+}
+
+// bookOrder compares books in the order requested by q, breaking ties by ID.
+func bookOrder(q internal.BookQuery) func(a, b internal.Book) int {
+	desc := q.SortDirection == internal.SortDesc
+	return func(a, b internal.Book) int {
+		c := compareBookField(a, b, q.SortBy)
+		if c == internal.ZERO {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	}
+}
+
+func matchesBookQuery(b internal.Book, q internal.BookQuery) bool {
+	switch {
+	case q.Publisher != internal.EMPTY && !strings.EqualFold(b.Publisher.Name, q.Publisher):
+		return false
+	case q.PublishedFrom != nil && b.PublishDate.Before(*q.PublishedFrom):
+		return false
+	case q.PublishedTo != nil && b.PublishDate.After(*q.PublishedTo):
+		return false
+	case q.MinPages != nil && b.Pages < *q.MinPages:
+		return false
+	case q.MaxPages != nil && b.Pages > *q.MaxPages:
+		return false
+	case q.Genre != internal.EMPTY && !hasGenre(b, q.Genre):
+		return false
+	case q.Author != internal.EMPTY && !hasAuthor(b, q.Author):
+		return false
+	}
+	return true
+}
+
+func hasGenre(b internal.Book, name string) bool {
+	return slices.ContainsFunc(b.Genres, func(g internal.Genre) bool {
+		return strings.EqualFold(g.Name, name)
+	})
+}
+
+func hasAuthor(b internal.Book, name string) bool {
+	return slices.ContainsFunc(b.Authors, func(a internal.Author) bool {
+		return strings.EqualFold(a.Name, name)
+	})
+}
+
+func compareBookField(a, b internal.Book, field string) int {
+	switch field {
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	case "publish_date":
+		return a.PublishDate.Compare(b.PublishDate)
+	case "pages":
+		return cmp.Compare(a.Pages, b.Pages)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+	return internal.ZERO
+}
+
+// cursorBook builds a book holding only the fields a cursor points at, so it
+// can be compared with the same function used for sorting.
+func cursorBook(field string, value any, id int64) internal.Book {
+	b := internal.Book{ID: id}
+	switch field {
+	case "title":
+		b.Title = value.(string)
+	case "publish_date":
+		b.PublishDate = value.(time.Time)
+	case "pages":
+		b.Pages = value.(int)
+	case "created_at":
+		b.CreatedAt = value.(time.Time)
+	}
+	return b
+}
+
+func (r *memoryBookRepository) GetBookById(ctx context.Context, bookId int64) (internal.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.Book{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	b, ok := r.books[bookId]
+	if !ok || b.DeletedAt != nil {
+		return internal.Book{}, internal.ErrBookNotFound
+	}
+
+	return cloneBook(b), nil
+}
+
+func (r *memoryBookRepository) GetBookByISBN(ctx context.Context, isbn13 string) (internal.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.Book{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, b := range r.books {
+		if b.DeletedAt == nil && b.ISBN13 == isbn13 {
+			return cloneBook(b), nil
+		}
+	}
+	return internal.Book{}, internal.ErrBookNotFound
+}
+
+func (r *memoryBookRepository) UpdateBook(ctx context.Context, b internal.Book) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.current(b.ID, b.Version)
+	if err != nil {
+		return false, err
+	}
+	if r.isbnTaken(b.ISBN13, b.ID) {
+		return false, internal.ErrISBNAlreadyExists
+	}
+	if err := r.refs.resolve(ctx, &b); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	b.CreatedAt = current.CreatedAt
+	b.UpdatedAt = &now
+	b.DeletedAt = nil
+	b.Version = current.Version + 1
+	r.books[b.ID] = cloneBook(b)
+	r.record(ctx, internal.RevisionUpdate, &current, b)
+
+	return true, nil
+}
+
+func (r *memoryBookRepository) PatchBook(ctx context.Context, b internal.Book, fields []internal.BookField) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.current(b.ID, b.Version)
+	if err != nil {
+		return false, err
+	}
+	before := cloneBook(current)
+
+	isbn13 := current.ISBN13
+	if slices.Contains(fields, internal.BookFieldISBN) {
+		isbn13 = b.ISBN13
+	}
+	if r.isbnTaken(isbn13, b.ID) {
+		return false, internal.ErrISBNAlreadyExists
+	}
+	if err := r.refs.resolve(ctx, &b); err != nil {
+		return false, err
+	}
+
+	for _, field := range fields {
+		switch field {
+		case internal.BookFieldTitle:
+			current.Title = b.Title
+		case internal.BookFieldISBN:
+			current.ISBN10, current.ISBN13 = b.ISBN10, b.ISBN13
+		case internal.BookFieldDescription:
+			current.Description = b.Description
+		case internal.BookFieldPublishDate:
+			current.PublishDate = b.PublishDate
+		case internal.BookFieldPages:
+			current.Pages = b.Pages
+		case internal.BookFieldPublisher:
+			current.Publisher = b.Publisher
+		case internal.BookFieldGenres:
+			current.Genres = b.Genres
+		case internal.BookFieldAuthors:
+			current.Authors = b.Authors
+		}
+	}
+
+	now := time.Now()
+	current.UpdatedAt = &now
+	current.Version++
+	r.books[b.ID] = cloneBook(current)
+	r.record(ctx, internal.RevisionUpdate, &before, current)
+
+	return true, nil
+}
+
+func (r *memoryBookRepository) DeleteBook(ctx context.Context, bookId int64, version int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := r.current(bookId, version)
+	if err != nil {
+		return false, err
+	}
+	if err := r.refuseOpenLoans(ctx, bookId); err != nil {
+		return false, err
+	}
+	before := cloneBook(b)
+
+	now := time.Now()
+	b.DeletedAt = &now
+	b.Version++
+	r.books[bookId] = b
+	r.record(ctx, internal.RevisionDelete, &before, b)
+
+	return true, nil
+}
+
+func (r *memoryBookRepository) ListBooksByGenre(ctx context.Context, genre string) ([]internal.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return []internal.Book{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var books []internal.Book
+	for _, b := range r.activeBooks() {
+		if hasGenre(b, genre) {
+			books = append(books, b)
+		}
+	}
+	return books, nil
+}
+
+func (r *memoryBookRepository) ListBooksByAuthor(ctx context.Context, author string) ([]internal.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return []internal.Book{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var books []internal.Book
+	for _, b := range r.activeBooks() {
+		if hasAuthor(b, author) {
+			books = append(books, b)
+		}
+	}
+	return books, nil
+}
+
+func (r *memoryBookRepository) ListBookRevisions(ctx context.Context, bookId int64) ([]internal.BookRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return []internal.BookRevision{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	revisions := []internal.BookRevision{}
+	for _, rev := range r.revisions {
+		if rev.BookID == bookId {
+			revisions = append(revisions, rev)
+		}
+	}
+
+	// A purged book keeps its history.
+	if _, ok := r.books[bookId]; !ok && len(revisions) == internal.ZERO {
+		return []internal.BookRevision{}, internal.ErrBookNotFound
+	}
+	return revisions, nil
+}
+
+func (r *memoryBookRepository) GetBookRevision(ctx context.Context, bookId int64, revisionId int64) (internal.BookRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.BookRevision{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rev := range r.revisions {
+		if rev.ID == revisionId && rev.BookID == bookId {
+			return rev, nil
+		}
+	}
+	return internal.BookRevision{}, internal.ErrBookRevisionNotFound
+}
+
+func (r *memoryBookRepository) ListDeletedBooks(ctx context.Context, q internal.DeletedBookQuery) ([]internal.Book, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+	if err := ctx.Err(); err != nil {
+		return []internal.Book{}, pagination, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	books := []internal.Book{}
+	for _, b := range r.books {
+		if b.DeletedAt != nil {
+			books = append(books, cloneBook(b))
+		}
+	}
+
+	slices.SortFunc(books, func(a, b internal.Book) int {
+		if c := b.DeletedAt.Compare(*a.DeletedAt); c != internal.ZERO {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	pagination.TotalCount = int64(len(books))
+	books = books[min(q.Offset, len(books)):]
+	if len(books) > q.PageSize {
+		books = books[:q.PageSize]
+	}
+
+	return books, pagination, nil
+}
+
+// deleted returns the stored book, which must be soft-deleted. The caller
+// must hold r.mu.
+func (r *memoryBookRepository) deleted(bookId int64) (internal.Book, error) {
+	b, ok := r.books[bookId]
+	if !ok {
+		return internal.Book{}, internal.ErrBookNotFound
+	}
+	if b.DeletedAt == nil {
+		return internal.Book{}, internal.ErrBookNotDeleted
+	}
+	return b, nil
+}
+
+func (r *memoryBookRepository) RestoreBook(ctx context.Context, bookId int64, version int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := r.deleted(bookId)
+	if err != nil {
+		return false, err
+	}
+	if version != internal.ZERO && version != b.Version {
+		return false, internal.ErrBookModified
+	}
+	if r.isbnTaken(b.ISBN13, b.ID) {
+		return false, internal.ErrISBNAlreadyExists
+	}
+	before := cloneBook(b)
+
+	now := time.Now()
+	b.DeletedAt = nil
+	b.UpdatedAt = &now
+	b.Version++
+	r.books[bookId] = b
+	r.record(ctx, internal.RevisionRestore, &before, b)
+
+	return true, nil
+}
+
+// purge removes b for good, keeping its history. The caller must hold r.mu
+// for writing.
+// refuseOpenLoans returns internal.ErrBookHasOpenLoans when a copy of
+// bookId is on loan.
+func (r *memoryBookRepository) refuseOpenLoans(ctx context.Context, bookId int64) error {
+	copies, err := r.copies.ListCopies(ctx, bookId)
+	if err != nil {
+		return err
+	}
+	for _, c := range copies {
+		if c.Status == internal.CopyOnLoan {
+			return internal.ErrBookHasOpenLoans
+		}
+	}
+	return nil
+}
+
+func (r *memoryBookRepository) purge(ctx context.Context, b internal.Book) {
+	delete(r.books, b.ID)
+	r.appendRevision(ctx, internal.RevisionPurge, b.ID, b.Version, &b, nil)
+}
+
+func (r *memoryBookRepository) PurgeBook(ctx context.Context, bookId int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := r.deleted(bookId)
+	if err != nil {
+		return false, err
+	}
+	if err := r.refuseOpenLoans(ctx, bookId); err != nil {
+		return false, err
+	}
+	r.purge(ctx, b)
+
+	return true, nil
+}
+
+func (r *memoryBookRepository) PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired []internal.Book
+	for _, b := range r.books {
+		if b.DeletedAt != nil && b.DeletedAt.Before(deletedBefore) {
+			expired = append(expired, b)
+		}
+	}
+	slices.SortFunc(expired, func(a, b internal.Book) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	var purged int64
+	for _, b := range expired {
+		err := r.refuseOpenLoans(ctx, b.ID)
+		if errors.Is(err, internal.ErrBookHasOpenLoans) {
+			log.Printf("Book with ID %d not purged, it has copies on loan.\n", b.ID)
+			continue
+		}
+		if err != nil {
+			return purged, err
+		}
+		r.purge(ctx, b)
+		purged++
+	}
+	return purged, nil
+}
+
+// ExportBooks calls fn with every book matching q, in q's order, ignoring
+// its page. The books are copied first so fn runs without holding r.mu.
+func (r *memoryBookRepository) ExportBooks(ctx context.Context, q internal.BookQuery, fn func(internal.Book) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	books := r.queryBooks(q)
+	r.mu.RUnlock()
+
+	for _, b := range books {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var errImportClosed = errors.New("book import already committed or rolled back")
+
+type memoryBookImport struct {
+	repo    *memoryBookRepository
+	pending []internal.Book
+	closed  bool
+}
+
+func (r *memoryBookRepository) BeginImport(ctx context.Context) (IBookImport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &memoryBookImport{repo: r}, nil
+}
+
+// InsertBooks assigns IDs right away but keeps the books aside until Commit.
+// An ISBN already taken by a live book or by an earlier row of the import,
+// or a reference to an ID that does not exist, refuses the whole call.
+// References by name are only registered on Commit.
+func (i *memoryBookImport) InsertBooks(ctx context.Context, books []internal.Book) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if i.closed {
+		return nil, errImportClosed
+	}
+
+	i.repo.mu.Lock()
+	defer i.repo.mu.Unlock()
+
+	taken := map[string]bool{}
+	for _, b := range i.pending {
+		taken[b.ISBN13] = true
+	}
+	for _, b := range books {
+		if b.ISBN13 == internal.EMPTY {
+			continue
+		}
+		if taken[b.ISBN13] || i.repo.isbnTaken(b.ISBN13, internal.ZERO) {
+			return nil, internal.ErrISBNAlreadyExists
+		}
+		taken[b.ISBN13] = true
+	}
+
+	books = slices.Clone(books)
+	for n := range books {
+		if err := i.repo.refs.lookup(ctx, &books[n]); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	bookIds := make([]int64, 0, len(books))
+	for _, b := range books {
+		i.repo.nextID++
+		b.ID = i.repo.nextID
+		b.CreatedAt = now
+		b.UpdatedAt = nil
+		b.DeletedAt = nil
+		b.Version = 1
+		i.pending = append(i.pending, cloneBook(b))
+		bookIds = append(bookIds, b.ID)
+	}
+	return bookIds, nil
+}
+
+func (i *memoryBookImport) Commit(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if i.closed {
+		return errImportClosed
+	}
+	i.closed = true
+
+	i.repo.mu.Lock()
+	defer i.repo.mu.Unlock()
+
+	// Books registered since InsertBooks may have taken an ISBN.
+	for _, b := range i.pending {
+		if i.repo.isbnTaken(b.ISBN13, internal.ZERO) {
+			i.pending = nil
+			return internal.ErrISBNAlreadyExists
+		}
+	}
+
+	for n := range i.pending {
+		if err := i.repo.refs.ensure(ctx, &i.pending[n]); err != nil {
+			i.pending = nil
+			return err
+		}
+	}
+
+	for _, b := range i.pending {
+		i.repo.books[b.ID] = b
+		i.repo.record(ctx, internal.RevisionCreate, nil, b)
+	}
+	i.pending = nil
+	return nil
+}
+
+func (i *memoryBookImport) Rollback(ctx context.Context) error {
+	i.closed = true
+	i.pending = nil
+	return nil
+}
+
+// SearchBooks approximates the PostgreSQL full-text search: every query term
+// must appear as a word in the title, authors or description, and matches
+// are ranked with the same field weights.
+func (r *memoryBookRepository) SearchBooks(ctx context.Context, q internal.BookSearchQuery) ([]internal.BookSearchResult, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+	if err := ctx.Err(); err != nil {
+		return []internal.BookSearchResult{}, pagination, err
+	}
+
+	terms := searchWords(q.Query)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []internal.BookSearchResult
+	for _, b := range r.activeBooks() {
+		title := searchWords(b.Title)
+		var authors []string
+		for _, a := range b.Authors {
+			authors = append(authors, searchWords(a.Name)...)
+		}
+		description := searchWords(b.Description)
+
+		var rank float32
+		matched := true
+		for _, term := range terms {
+			var termRank float32
+			if slices.Contains(title, term) {
+				termRank += titleWeight
+			}
+			if slices.Contains(authors, term) {
+				termRank += authorWeight
+			}
+			if slices.Contains(description, term) {
+				termRank += descriptionWeight
+			}
+			if termRank == internal.ZERO {
+				matched = false
+				break
+			}
+			rank += termRank
+		}
+
+		if !matched || len(terms) == internal.ZERO {
+			continue
+		}
+
+		results = append(results, internal.BookSearchResult{
+			Book:           b,
+			Rank:           rank,
+			TitleHighlight: highlightWords(b.Title, terms),
+			Snippet:        highlightWords(b.Description, terms),
+		})
+	}
+
+	slices.SortStableFunc(results, func(a, b internal.BookSearchResult) int {
+		return cmp.Compare(b.Rank, a.Rank)
+	})
+
+	pagination.TotalCount = int64(len(results))
+	results = results[min(q.Offset, len(results)):]
+	if len(results) > q.PageSize {
+		results = results[:q.PageSize]
+	}
+
+	return results, pagination, nil
+}
+
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func highlightWords(s string, terms []string) string {
+	var sb strings.Builder
+	word := strings.Builder{}
+
+	flush := func() {
+		if word.Len() == internal.ZERO {
+			return
+		}
+		if slices.Contains(terms, strings.ToLower(word.String())) {
+			sb.WriteString("<mark>" + html.EscapeString(word.String()) + "</mark>")
+		} else {
+			sb.WriteString(html.EscapeString(word.String()))
+		}
+		word.Reset()
+	}
+
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+		sb.WriteString(html.EscapeString(string(r)))
+	}
+	flush()
+
+	return sb.String()
+}
@@ -0,0 +1,24 @@
+package book_test
+
+import (
+	"testing"
+
+	"github.com/amarantec/box/internal/book"
+	"github.com/amarantec/box/internal/book/booktest"
+	"github.com/amarantec/box/internal/inventory"
+	"github.com/amarantec/box/internal/reference"
+)
+
+func TestMemoryBookRepository(t *testing.T) {
+	booktest.RunRepositoryContract(t, func(t *testing.T) booktest.Repositories {
+		authors := reference.NewMemoryRepository(reference.Authors)
+		genres := reference.NewMemoryRepository(reference.Genres)
+		publishers := reference.NewMemoryRepository(reference.Publishers)
+		return booktest.Repositories{
+			Books:      book.NewMemoryBookRepository(authors, genres, publishers, inventory.NewMemoryCopyRepository()),
+			Authors:    authors,
+			Genres:     genres,
+			Publishers: publishers,
+		}
+	})
+}
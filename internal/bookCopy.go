@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CopyStatus tells where a physical copy of a book is. Copies are lent,
+// returned and set aside for holds through circulation; withdrawing a copy is
+// final.
+type CopyStatus string
+
+const (
+	CopyAvailable CopyStatus = "available"
+	CopyOnLoan    CopyStatus = "on-loan"
+	CopyOnHold    CopyStatus = "on-hold"
+	CopyLost      CopyStatus = "lost"
+	CopyWithdrawn CopyStatus = "withdrawn"
+)
+
+// CopyCondition grades the wear of a copy.
+type CopyCondition string
+
+const (
+	ConditionNew     CopyCondition = "new"
+	ConditionGood    CopyCondition = "good"
+	ConditionFair    CopyCondition = "fair"
+	ConditionPoor    CopyCondition = "poor"
+	ConditionDamaged CopyCondition = "damaged"
+)
+
+var CopyConditions = []CopyCondition{ConditionNew, ConditionGood, ConditionFair, ConditionPoor, ConditionDamaged}
+
+// ItemCategory groups copies lent on the same terms, such as the fines
+// charged when they come back late.
+type ItemCategory string
+
+const (
+	CategoryGeneral     ItemCategory = "general"
+	CategoryShortLoan   ItemCategory = "short-loan"
+	CategoryAudiovisual ItemCategory = "audiovisual"
+)
+
+var ItemCategories = []ItemCategory{CategoryGeneral, CategoryShortLoan, CategoryAudiovisual}
+
+// BookCopy is one physical copy of a book, identified by the barcode on its
+// label. Price is what the library paid for it, zero for a donation.
+type BookCopy struct {
+	ID          int64
+	BookID      int64
+	Barcode     string
+	Condition   CopyCondition
+	Category    ItemCategory
+	AcquiredOn  time.Time
+	Price       decimal.Decimal
+	Status      CopyStatus
+	CreatedAt   time.Time
+	UpdatedAt   *time.Time
+	WithdrawnAt *time.Time
+}
+
+// BookAvailability counts the copies of a book by status. Total counts the
+// copies the library still holds, that is every copy but the withdrawn ones.
+type BookAvailability struct {
+	Total     int
+	Available int
+	OnLoan    int
+	OnHold    int
+	Lost      int
+	Withdrawn int
+}
+
+// Add counts n more copies with the given status.
+func (a *BookAvailability) Add(status CopyStatus, n int) {
+	switch status {
+	case CopyAvailable:
+		a.Available += n
+	case CopyOnLoan:
+		a.OnLoan += n
+	case CopyOnHold:
+		a.OnHold += n
+	case CopyLost:
+		a.Lost += n
+	case CopyWithdrawn:
+		a.Withdrawn += n
+		return
+	}
+	a.Total += n
+}
+
+// BookDetails is a single book as it is looked up, with the availability of
+// its copies.
+type BookDetails struct {
+	Book
+	Availability BookAvailability
+}
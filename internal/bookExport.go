@@ -0,0 +1,8 @@
+package internal
+
+// Formats a catalog export can be written in.
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+)
@@ -0,0 +1,63 @@
+package internal
+
+import "fmt"
+
+// Formats accepted by a bulk import.
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+const (
+	DefaultImportBatchSize = 500
+	MaxImportBatchSize     = 5000
+)
+
+// ImportOptions control a bulk import. Unless BestEffort is set the import
+// is all-or-nothing: a single failing row rolls every other row back.
+type ImportOptions struct {
+	Format     string
+	BestEffort bool
+	BatchSize  int
+}
+
+func (o *ImportOptions) Normalize() error {
+	if o.Format != ImportFormatCSV && o.Format != ImportFormatNDJSON {
+		return ErrUnsupportedImport
+	}
+
+	if o.BatchSize == ZERO {
+		o.BatchSize = DefaultImportBatchSize
+	}
+	if o.BatchSize < 1 || o.BatchSize > MaxImportBatchSize {
+		return fmt.Errorf("%w: batch size must be between 1 and %d", ErrInvalidImport, MaxImportBatchSize)
+	}
+
+	return nil
+}
+
+// Outcomes of a row in an ImportReport.
+const (
+	ImportRowImported   = "imported"
+	ImportRowFailed     = "failed"
+	ImportRowRolledBack = "rolled_back"
+)
+
+// ImportRow reports what happened to one row of an import. Line is where the
+// row starts in the source. Rows that were valid but not kept because
+// another row failed an all-or-nothing import are rolled back.
+type ImportRow struct {
+	Line   int
+	Status string
+	BookID int64        `json:",omitempty"`
+	Code   string       `json:",omitempty"`
+	Error  string       `json:",omitempty"`
+	Errors []FieldError `json:",omitempty"`
+}
+
+type ImportReport struct {
+	Committed bool
+	Imported  int
+	Failed    int
+	Rows      []ImportRow
+}
@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// BookSortFields maps the sort names accepted by ListBooks to their columns.
+var BookSortFields = map[string]string{
+	"id":           "b.id",
+	"title":        "b.title",
+	"publish_date": "b.publish_date",
+	"pages":        "b.pages",
+	"created_at":   "b.created_at",
+}
+
+// BookQuery describes a page of books. Cursor takes precedence over Offset.
+type BookQuery struct {
+	PageSize      int
+	Offset        int
+	Cursor        string
+	SortBy        string
+	SortDirection string
+
+	Publisher     string
+	PublishedFrom *time.Time
+	PublishedTo   *time.Time
+	MinPages      *int
+	MaxPages      *int
+	Genre         string
+	Author        string
+}
+
+type Pagination struct {
+	TotalCount int64
+	PageSize   int
+	NextCursor string
+}
+
+// Normalize fills in defaults and reports the first invalid field, wrapping
+// ErrInvalidBookQuery.
+func (q *BookQuery) Normalize() error {
+	if q.PageSize == ZERO {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize < 1 || q.PageSize > MaxPageSize {
+		return fmt.Errorf("%w: page size must be between 1 and %d", ErrInvalidBookQuery, MaxPageSize)
+	}
+
+	if q.Offset < ZERO {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidBookQuery)
+	}
+
+	if q.SortBy == EMPTY {
+		q.SortBy = "id"
+	}
+	if _, ok := BookSortFields[q.SortBy]; !ok {
+		return fmt.Errorf("%w: cannot sort by %q", ErrInvalidBookQuery, q.SortBy)
+	}
+
+	if q.SortDirection == EMPTY {
+		q.SortDirection = SortAsc
+	}
+	if q.SortDirection != SortAsc && q.SortDirection != SortDesc {
+		return fmt.Errorf("%w: sort direction must be %q or %q", ErrInvalidBookQuery, SortAsc, SortDesc)
+	}
+
+	if q.PublishedFrom != nil && q.PublishedTo != nil && q.PublishedFrom.After(*q.PublishedTo) {
+		return fmt.Errorf("%w: published_from is after published_to", ErrInvalidBookQuery)
+	}
+
+	if q.MinPages != nil && q.MaxPages != nil && *q.MinPages > *q.MaxPages {
+		return fmt.Errorf("%w: min_pages is greater than max_pages", ErrInvalidBookQuery)
+	}
+
+	return nil
+}
+
+// BookSearchQuery is a full-text search over title, authors and description.
+type BookSearchQuery struct {
+	Query    string
+	PageSize int
+	Offset   int
+}
+
+// BookSearchResult is a matching book with its relevance and the matched
+// terms wrapped in <mark> tags. The rest of TitleHighlight and Snippet is
+// HTML-escaped, so both can be rendered as markup.
+type BookSearchResult struct {
+	Book           Book
+	Rank           float32
+	TitleHighlight string
+	Snippet        string
+}
+
+func (q *BookSearchQuery) Normalize() error {
+	q.Query = strings.TrimSpace(q.Query)
+	if q.Query == EMPTY {
+		return fmt.Errorf("%w: search query must not be empty", ErrInvalidBookQuery)
+	}
+
+	if q.PageSize == ZERO {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize < 1 || q.PageSize > MaxPageSize {
+		return fmt.Errorf("%w: page size must be between 1 and %d", ErrInvalidBookQuery, MaxPageSize)
+	}
+
+	if q.Offset < ZERO {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidBookQuery)
+	}
+
+	return nil
+}
+
+// DeletedBookQuery pages through the soft-deleted books, most recently
+// deleted first.
+type DeletedBookQuery struct {
+	PageSize int
+	Offset   int
+}
+
+func (q *DeletedBookQuery) Normalize() error {
+	if q.PageSize == ZERO {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize < 1 || q.PageSize > MaxPageSize {
+		return fmt.Errorf("%w: page size must be between 1 and %d", ErrInvalidBookQuery, MaxPageSize)
+	}
+
+	if q.Offset < ZERO {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidBookQuery)
+	}
+
+	return nil
+}
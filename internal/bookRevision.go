@@ -0,0 +1,43 @@
+package internal
+
+import "time"
+
+// Actions recorded in the book history.
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionPurge   = "purge"
+)
+
+// BookRevision is one entry of a book's append-only history. Before is nil
+// for a create; After holds the book as stored once the change was applied
+// and is nil for a purge.
+type BookRevision struct {
+	ID        int64
+	BookID    int64
+	Version   int64
+	Action    string
+	Actor     string
+	RequestID string
+	Before    *Book
+	After     *Book
+	CreatedAt time.Time
+
+	Changes []FieldChange `json:",omitempty"`
+}
+
+// FieldChange is a top-level book field that differs between two snapshots.
+type FieldChange struct {
+	Field string
+	From  any
+	To    any
+}
+
+type BookDiff struct {
+	BookID  int64
+	From    int64
+	To      int64
+	Changes []FieldChange
+}
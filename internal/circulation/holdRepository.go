@@ -0,0 +1,366 @@
+package circulation
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IHoldRepository interface {
+	PlaceHold(ctx context.Context, h internal.Hold) (int64, error)
+	ListHolds(ctx context.Context, q internal.HoldQuery) ([]internal.Hold, error)
+	GetHoldById(ctx context.Context, holdId int64) (internal.Hold, error)
+	CancelHold(ctx context.Context, holdId int64, policy internal.HoldPolicy, now time.Time) (bool, error)
+	ExpireHolds(ctx context.Context, policy internal.HoldPolicy, now time.Time) (int64, error)
+	AllocateCopy(ctx context.Context, copyId int64, policy internal.HoldPolicy, now time.Time) (bool, error)
+	FulfillHold(ctx context.Context, copyId, memberId int64, now time.Time) (bool, error)
+	CountWaitingHolds(ctx context.Context, bookId int64) (int, error)
+}
+
+// holdsMemberBookOpenKey is the unique index allowing a single open hold per
+// member and book.
+const holdsMemberBookOpenKey = "holds_member_book_open_key"
+
+const holdColumns = `h.id, h.book_id, h.member_id, COALESCE(h.copy_id, 0), h.status, h.position, h.placed_at, h.ready_at, h.expires_on, h.closed_at`
+
+// holdsFrom selects the holds of the books matching filter as h, numbering
+// the waiting holds of each book in queue order.
+func holdsFrom(filter string) string {
+	return ` FROM (
+            SELECT holds.*, CASE WHEN status = 'waiting'
+                THEN ROW_NUMBER() OVER (PARTITION BY book_id, status ORDER BY placed_at, id)
+                ELSE 0 END AS position
+            FROM holds WHERE ` + filter + `) h`
+}
+
+func scanHold(row pgx.Row) (internal.Hold, error) {
+	var h internal.Hold
+	if err := row.Scan(
+		&h.ID,
+		&h.BookID,
+		&h.MemberID,
+		&h.CopyID,
+		&h.Status,
+		&h.Position,
+		&h.PlacedAt,
+		&h.ReadyAt,
+		&h.ExpiresOn,
+		&h.ClosedAt,
+	); err != nil {
+		return internal.Hold{}, err
+	}
+	return h, nil
+}
+
+// lockHold reads a hold, without its position, inside tx and locks its row
+// until tx ends.
+func lockHold(ctx context.Context, tx pgx.Tx, holdId int64) (internal.Hold, error) {
+	h, err := scanHold(
+		tx.QueryRow(
+			ctx,
+			`SELECT id, book_id, member_id, COALESCE(copy_id, 0), status, 0, placed_at, ready_at, expires_on, closed_at
+            FROM holds WHERE id = $1 FOR UPDATE;`, holdId))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.Hold{}, internal.ErrHoldNotFound
+		}
+		return internal.Hold{}, err
+	}
+	return h, nil
+}
+
+// allocateCopy sets copyId aside for the first waiting hold on bookId, if
+// any, and reports whether there was one.
+func allocateCopy(ctx context.Context, tx pgx.Tx, bookId, copyId int64, policy internal.HoldPolicy, now time.Time) (bool, error) {
+	var holdId int64
+	err :=
+		tx.QueryRow(
+			ctx,
+			`SELECT id FROM holds WHERE book_id = $1 AND status = $2
+            ORDER BY placed_at, id LIMIT 1 FOR UPDATE SKIP LOCKED;`, bookId, internal.HoldWaiting).Scan(&holdId)
+
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err :=
+		tx.Exec(
+			ctx,
+			`UPDATE holds SET status = $2, copy_id = $3, ready_at = $4, expires_on = $5 WHERE id = $1;`,
+			holdId, internal.HoldReady, copyId, now, policy.PickupBy(now)); err != nil {
+		return false, err
+	}
+
+	_, err =
+		tx.Exec(
+			ctx,
+			`UPDATE copies SET status = $2, updated_at = $3 WHERE id = $1;`, copyId, internal.CopyOnHold, now)
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("Copy with ID %d set aside for hold with ID %d.\n", copyId, holdId)
+	return true, nil
+}
+
+// releaseCopy passes the copy set aside for a closed hold on to the next
+// waiting hold, or puts it back on the shelf.
+func releaseCopy(ctx context.Context, tx pgx.Tx, bookId, copyId int64, policy internal.HoldPolicy, now time.Time) error {
+	allocated, err := allocateCopy(ctx, tx, bookId, copyId, policy, now)
+	if err != nil || allocated {
+		return err
+	}
+
+	_, err =
+		tx.Exec(
+			ctx,
+			`UPDATE copies SET status = $2, updated_at = $4 WHERE id = $1 AND status = $3;`,
+			copyId, internal.CopyAvailable, internal.CopyOnHold, now)
+	return err
+}
+
+// fulfillHold closes the ready hold of memberId that copyId is set aside
+// for, and reports whether there was one.
+func fulfillHold(ctx context.Context, tx pgx.Tx, copyId, memberId int64, now time.Time) (bool, error) {
+	tag, err :=
+		tx.Exec(
+			ctx,
+			`UPDATE holds SET status = $4, closed_at = $5 WHERE copy_id = $1 AND member_id = $2 AND status = $3;`,
+			copyId, memberId, internal.HoldReady, internal.HoldFulfilled, now)
+
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > internal.ZERO, nil
+}
+
+type holdRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewHoldRepository(conn *pgxpool.Pool) IHoldRepository {
+	return &holdRepository{Conn: conn}
+}
+
+// PlaceHold queues a member for a book that is not deleted.
+func (r *holdRepository) PlaceHold(ctx context.Context, h internal.Hold) (int64, error) {
+	err :=
+		r.Conn.QueryRow(
+			ctx,
+			`INSERT INTO holds (book_id, member_id, status, placed_at, expires_on)
+            SELECT $1, $2, $3, $4, $5 WHERE EXISTS (SELECT 1 FROM books WHERE id = $1 AND deleted_at IS NULL)
+            RETURNING id;`, h.BookID, h.MemberID, internal.HoldWaiting, h.PlacedAt, h.ExpiresOn).Scan(&h.ID)
+
+	if err != nil {
+		switch {
+		case err == pgx.ErrNoRows:
+			return internal.ZERO, internal.ErrBookNotFound
+		case database.IsUniqueViolationOf(err, holdsMemberBookOpenKey):
+			return internal.ZERO, internal.ErrHoldAlreadyPlaced
+		case database.IsForeignKeyViolation(err):
+			return internal.ZERO, internal.ErrMemberNotFound
+		}
+		return internal.ZERO, err
+	}
+
+	log.Printf("Hold with ID %d placed on book with ID %d.\n", h.ID, h.BookID)
+	return h.ID, nil
+}
+
+// ListHolds lists the open holds of a book, ready ones first and then the
+// queue, or those of a member, oldest first.
+func (r *holdRepository) ListHolds(ctx context.Context, q internal.HoldQuery) ([]internal.Hold, error) {
+	var rows pgx.Rows
+	var err error
+	if q.BookID != internal.ZERO {
+		rows, err =
+			r.Conn.Query(
+				ctx,
+				`SELECT `+holdColumns+holdsFrom(`book_id = $1`)+`
+                WHERE h.status IN ('waiting', 'ready')
+                ORDER BY h.status = 'waiting', h.placed_at, h.id;`, q.BookID)
+	} else {
+		rows, err =
+			r.Conn.Query(
+				ctx,
+				`SELECT `+holdColumns+holdsFrom(`book_id IN (SELECT book_id FROM holds WHERE member_id = $1)`)+`
+                WHERE h.member_id = $1 AND h.status IN ('waiting', 'ready')
+                ORDER BY h.placed_at, h.id;`, q.MemberID)
+	}
+
+	if err != nil {
+		return []internal.Hold{}, err
+	}
+
+	defer rows.Close()
+
+	holds := []internal.Hold{}
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return []internal.Hold{}, err
+		}
+		holds = append(holds, h)
+	}
+
+	return holds, rows.Err()
+}
+
+func (r *holdRepository) GetHoldById(ctx context.Context, holdId int64) (internal.Hold, error) {
+	h, err := scanHold(
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT `+holdColumns+holdsFrom(`book_id = (SELECT book_id FROM holds WHERE id = $1)`)+`
+            WHERE h.id = $1;`, holdId))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.Hold{}, internal.ErrHoldNotFound
+		}
+		return internal.Hold{}, err
+	}
+
+	return h, nil
+}
+
+// CancelHold closes an open hold. The copy set aside for a ready hold goes to
+// the next member in the queue.
+func (r *holdRepository) CancelHold(ctx context.Context, holdId int64, policy internal.HoldPolicy, now time.Time) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		current, err := lockHold(ctx, tx, holdId)
+		if err != nil {
+			return err
+		}
+		if !current.Open() {
+			return internal.ErrHoldClosed
+		}
+
+		if _, err :=
+			tx.Exec(
+				ctx,
+				`UPDATE holds SET status = $2, closed_at = $3 WHERE id = $1;`, holdId, internal.HoldCancelled, now); err != nil {
+			return err
+		}
+
+		if current.Status == internal.HoldReady {
+			return releaseCopy(ctx, tx, current.BookID, current.CopyID, policy, now)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("Hold with ID %d cancelled.\n", holdId)
+	return true, nil
+}
+
+// ExpireHolds closes the open holds past their ExpiresOn day and passes the
+// copies set aside for them on. It reports how many holds expired.
+func (r *holdRepository) ExpireHolds(ctx context.Context, policy internal.HoldPolicy, now time.Time) (int64, error) {
+	var expired int64
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		rows, err :=
+			tx.Query(
+				ctx,
+				`UPDATE holds SET status = $3, closed_at = $4
+                WHERE status IN ($1, $2) AND expires_on < $5
+                RETURNING book_id, COALESCE(copy_id, 0);`,
+				internal.HoldWaiting, internal.HoldReady, internal.HoldExpired, now, internal.Day(now))
+		if err != nil {
+			return err
+		}
+
+		type setAside struct{ bookId, copyId int64 }
+		released, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (setAside, error) {
+			var s setAside
+			err := row.Scan(&s.bookId, &s.copyId)
+			return s, err
+		})
+		if err != nil {
+			return err
+		}
+
+		expired = int64(len(released))
+		for _, s := range released {
+			if s.copyId == internal.ZERO {
+				continue
+			}
+			if err := releaseCopy(ctx, tx, s.bookId, s.copyId, policy, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return internal.ZERO, err
+	}
+	return expired, nil
+}
+
+// AllocateCopy sets an available copy aside for the first waiting hold on
+// its book, and reports whether there was one.
+func (r *holdRepository) AllocateCopy(ctx context.Context, copyId int64, policy internal.HoldPolicy, now time.Time) (bool, error) {
+	var allocated bool
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		var bookId int64
+		var status internal.CopyStatus
+		if err :=
+			tx.QueryRow(
+				ctx,
+				`SELECT book_id, status FROM copies WHERE id = $1 FOR UPDATE;`, copyId).Scan(&bookId, &status); err != nil {
+			if err == pgx.ErrNoRows {
+				return internal.ErrCopyNotFound
+			}
+			return err
+		}
+		if status != internal.CopyAvailable {
+			return nil
+		}
+
+		var err error
+		allocated, err = allocateCopy(ctx, tx, bookId, copyId, policy, now)
+		return err
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return allocated, nil
+}
+
+func (r *holdRepository) FulfillHold(ctx context.Context, copyId, memberId int64, now time.Time) (bool, error) {
+	var fulfilled bool
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		var err error
+		fulfilled, err = fulfillHold(ctx, tx, copyId, memberId, now)
+		return err
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return fulfilled, nil
+}
+
+func (r *holdRepository) CountWaitingHolds(ctx context.Context, bookId int64) (int, error) {
+	var count int
+	if err :=
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT COUNT(*) FROM holds WHERE book_id = $1 AND status = $2;`, bookId, internal.HoldWaiting).Scan(&count); err != nil {
+		return internal.ZERO, err
+	}
+	return count, nil
+}
@@ -0,0 +1,218 @@
+package circulation_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database/fixturetest"
+	"github.com/amarantec/box/internal/inventory"
+)
+
+func placeHold(t *testing.T, r fixturetest.Repositories, bookId, memberId int64, placedAt time.Time) int64 {
+	t.Helper()
+
+	holdId, err := r.Holds.PlaceHold(context.Background(), internal.Hold{
+		BookID:    bookId,
+		MemberID:  memberId,
+		PlacedAt:  placedAt,
+		ExpiresOn: fixturetest.HoldPolicy.WaitUntil(placedAt),
+	})
+	if err != nil {
+		t.Fatalf("PlaceHold(member %d): %v", memberId, err)
+	}
+	return holdId
+}
+
+func assertHold(t *testing.T, r fixturetest.Repositories, holdId int64, status internal.HoldStatus, position int, copyId int64) internal.Hold {
+	t.Helper()
+
+	h, err := r.Holds.GetHoldById(context.Background(), holdId)
+	if err != nil {
+		t.Fatalf("GetHoldById(%d): %v", holdId, err)
+	}
+	if h.Status != status || h.Position != position || h.CopyID != copyId {
+		t.Fatalf("GetHoldById(%d) = %s at %d with copy %d, want %s at %d with copy %d",
+			holdId, h.Status, h.Position, h.CopyID, status, position, copyId)
+	}
+	return h
+}
+
+func TestHolds(t *testing.T) {
+	fixturetest.Run(t, "circulation_test", testHolds)
+}
+
+func testHolds(t *testing.T, r fixturetest.Repositories) {
+	ctx := context.Background()
+	policy := internal.LoanPolicy{MemberType: internal.MemberStandard, LoanDays: 14, MaxRenewals: 2, MaxLoans: 5}
+	start := time.Date(2026, time.April, 1, 10, 0, 0, 0, time.UTC)
+
+	bookId := fixturetest.RegisterBook(t, r, "Wanted")
+	copyId, err := r.Copies.AddCopy(ctx, fixturetest.NewCopy(bookId, "W-001"))
+	if err != nil {
+		t.Fatalf("AddCopy: %v", err)
+	}
+	var memberIds []int64
+	for _, card := range []string{"H-A", "H-B", "H-C", "H-D", "H-E"} {
+		memberIds = append(memberIds, fixturetest.RegisterMember(t, r, card))
+	}
+	a, b, c, d, e := memberIds[0], memberIds[1], memberIds[2], memberIds[3], memberIds[4]
+
+	loanId, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, a, start), policy.MaxLoans)
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+
+	holdB := placeHold(t, r, bookId, b, start.Add(time.Hour))
+	holdC := placeHold(t, r, bookId, c, start.Add(2*time.Hour))
+	if _, err := r.Holds.PlaceHold(ctx, internal.Hold{BookID: bookId, MemberID: b, PlacedAt: start, ExpiresOn: start}); !errors.Is(err, internal.ErrHoldAlreadyPlaced) {
+		t.Fatalf("PlaceHold(second hold) error = %v, want %v", err, internal.ErrHoldAlreadyPlaced)
+	}
+	assertHold(t, r, holdB, internal.HoldWaiting, 1, internal.ZERO)
+	assertHold(t, r, holdC, internal.HoldWaiting, 2, internal.ZERO)
+
+	if _, err := r.Loans.RenewLoan(ctx, loanId, policy, start); !errors.Is(err, internal.ErrRenewalBlocked) {
+		t.Fatalf("RenewLoan(book on hold) error = %v, want %v", err, internal.ErrRenewalBlocked)
+	}
+
+	// The returned copy is set aside for the first member in line.
+	returnedAt := start.AddDate(0, 0, 10)
+	if _, err := r.Loans.ReturnLoan(ctx, loanId, fixturetest.HoldPolicy, fixturetest.FineRules, returnedAt); err != nil {
+		t.Fatalf("ReturnLoan: %v", err)
+	}
+	fixturetest.AssertCopyStatus(t, r, copyId, internal.CopyOnHold)
+	ready := assertHold(t, r, holdB, internal.HoldReady, internal.ZERO, copyId)
+	if !ready.ExpiresOn.Equal(fixturetest.Date(2026, time.April, 18)) || ready.ReadyAt == nil {
+		t.Fatalf("ready hold = %+v, want it to expire on 2026-04-18", ready)
+	}
+	assertHold(t, r, holdC, internal.HoldWaiting, 1, internal.ZERO)
+
+	holds, err := r.Holds.ListHolds(ctx, internal.HoldQuery{BookID: bookId})
+	if err != nil {
+		t.Fatalf("ListHolds: %v", err)
+	}
+	if len(holds) != 2 || holds[0].ID != holdB || holds[1].ID != holdC || holds[1].Position != 1 {
+		t.Fatalf("ListHolds = %+v, want the ready hold then the queue", holds)
+	}
+
+	if _, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, c, returnedAt), policy.MaxLoans); !errors.Is(err, internal.ErrCopyOnHold) {
+		t.Fatalf("Checkout(copy set aside for another member) error = %v, want %v", err, internal.ErrCopyOnHold)
+	}
+
+	// Cancelling a ready hold passes the copy on.
+	if _, err := r.Holds.CancelHold(ctx, holdB, fixturetest.HoldPolicy, returnedAt); err != nil {
+		t.Fatalf("CancelHold: %v", err)
+	}
+	if _, err := r.Holds.CancelHold(ctx, holdB, fixturetest.HoldPolicy, returnedAt); !errors.Is(err, internal.ErrHoldClosed) {
+		t.Fatalf("CancelHold(cancelled) error = %v, want %v", err, internal.ErrHoldClosed)
+	}
+	assertHold(t, r, holdC, internal.HoldReady, internal.ZERO, copyId)
+
+	loanId, err = r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, c, returnedAt), policy.MaxLoans)
+	if err != nil {
+		t.Fatalf("Checkout(copy set aside for the member): %v", err)
+	}
+	assertHold(t, r, holdC, internal.HoldFulfilled, internal.ZERO, copyId)
+	fixturetest.AssertCopyStatus(t, r, copyId, internal.CopyOnLoan)
+
+	// A ready hold left uncollected expires and the copy goes on down the
+	// queue; a waiting hold expires once no longer wanted.
+	holdD := placeHold(t, r, bookId, d, returnedAt)
+	holdE := placeHold(t, r, bookId, e, returnedAt.Add(time.Hour))
+	if _, err := r.Loans.ReturnLoan(ctx, loanId, fixturetest.HoldPolicy, fixturetest.FineRules, returnedAt); err != nil {
+		t.Fatalf("ReturnLoan: %v", err)
+	}
+	assertHold(t, r, holdD, internal.HoldReady, internal.ZERO, copyId)
+
+	expired, err := r.Holds.ExpireHolds(ctx, fixturetest.HoldPolicy, returnedAt.AddDate(0, 0, fixturetest.HoldPolicy.PickupDays+1))
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireHolds = %d, %v, want 1", expired, err)
+	}
+	assertHold(t, r, holdD, internal.HoldExpired, internal.ZERO, copyId)
+	assertHold(t, r, holdE, internal.HoldReady, internal.ZERO, copyId)
+
+	if _, err := r.Holds.CancelHold(ctx, holdE, fixturetest.HoldPolicy, returnedAt); err != nil {
+		t.Fatalf("CancelHold: %v", err)
+	}
+	fixturetest.AssertCopyStatus(t, r, copyId, internal.CopyAvailable)
+
+	holdA := placeHold(t, r, bookId, a, returnedAt)
+	expired, err = r.Holds.ExpireHolds(ctx, fixturetest.HoldPolicy, returnedAt.AddDate(0, 0, fixturetest.HoldPolicy.MaxWaitDays+1))
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireHolds = %d, %v, want 1", expired, err)
+	}
+	assertHold(t, r, holdA, internal.HoldExpired, internal.ZERO, internal.ZERO)
+}
+
+func TestHoldAllocation(t *testing.T) {
+	fixturetest.Run(t, "circulation_test", testHoldAllocation)
+}
+
+func testHoldAllocation(t *testing.T, r fixturetest.Repositories) {
+	ctx := context.Background()
+	policy := internal.LoanPolicy{MemberType: internal.MemberStandard, LoanDays: 14, MaxRenewals: 2, MaxLoans: 5}
+	start := time.Date(2026, time.April, 1, 10, 0, 0, 0, time.UTC)
+	copies := inventory.NewCopyService(r.Copies, r.Books, r.Holds, fixturetest.HoldPolicy)
+
+	bookId := fixturetest.RegisterBook(t, r, "Scarce")
+	firstId, err := r.Copies.AddCopy(ctx, fixturetest.NewCopy(bookId, "S-001"))
+	if err != nil {
+		t.Fatalf("AddCopy: %v", err)
+	}
+	a, b, c := fixturetest.RegisterMember(t, r, "S-A"), fixturetest.RegisterMember(t, r, "S-B"), fixturetest.RegisterMember(t, r, "S-C")
+
+	loanId, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(firstId, a, start), policy.MaxLoans)
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	holdB := placeHold(t, r, bookId, b, start.Add(time.Hour))
+
+	// A new copy goes to the first member in line.
+	added, err := copies.AddCopy(ctx, fixturetest.NewCopy(bookId, "S-002"))
+	if err != nil {
+		t.Fatalf("AddCopy(service): %v", err)
+	}
+	secondId := added.Data
+	fixturetest.AssertCopyStatus(t, r, secondId, internal.CopyOnHold)
+	assertHold(t, r, holdB, internal.HoldReady, internal.ZERO, secondId)
+
+	// A failed checkout of a copy set aside for someone else leaves it so.
+	if _, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(secondId, c, start), policy.MaxLoans); !errors.Is(err, internal.ErrCopyOnHold) {
+		t.Fatalf("Checkout(copy set aside for another member) error = %v, want %v", err, internal.ErrCopyOnHold)
+	}
+	fixturetest.AssertCopyStatus(t, r, secondId, internal.CopyOnHold)
+	assertHold(t, r, holdB, internal.HoldReady, internal.ZERO, secondId)
+
+	// A loan is renewed while a free copy can serve every waiting hold.
+	holdC := placeHold(t, r, bookId, c, start.Add(2*time.Hour))
+	if _, err := r.Loans.RenewLoan(ctx, loanId, policy, start); !errors.Is(err, internal.ErrRenewalBlocked) {
+		t.Fatalf("RenewLoan(no copy free) error = %v, want %v", err, internal.ErrRenewalBlocked)
+	}
+	lost := fixturetest.NewCopy(bookId, "S-003")
+	thirdId, err := r.Copies.AddCopy(ctx, lost)
+	if err != nil {
+		t.Fatalf("AddCopy: %v", err)
+	}
+	if _, err := r.Loans.RenewLoan(ctx, loanId, policy, start); err != nil {
+		t.Fatalf("RenewLoan(copy free): %v", err)
+	}
+
+	// A copy found again goes to the next member in line.
+	lost.ID = thirdId
+	lost.Status = internal.CopyLost
+	if _, err := copies.UpdateCopy(ctx, lost); err != nil {
+		t.Fatalf("UpdateCopy(lost): %v", err)
+	}
+	fixturetest.AssertCopyStatus(t, r, thirdId, internal.CopyLost)
+	assertHold(t, r, holdC, internal.HoldWaiting, 1, internal.ZERO)
+
+	found := lost
+	found.Status = internal.CopyAvailable
+	if _, err := copies.UpdateCopy(ctx, found); err != nil {
+		t.Fatalf("UpdateCopy(available): %v", err)
+	}
+	fixturetest.AssertCopyStatus(t, r, thirdId, internal.CopyOnHold)
+	assertHold(t, r, holdC, internal.HoldReady, internal.ZERO, thirdId)
+}
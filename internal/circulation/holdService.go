@@ -0,0 +1,242 @@
+package circulation
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/book"
+	"github.com/amarantec/box/internal/inventory"
+	"github.com/amarantec/box/internal/member"
+)
+
+type IHoldService interface {
+	PlaceHold(ctx context.Context, h internal.Hold) (internal.Response[internal.Hold], error)
+	ListBookHolds(ctx context.Context, bookId int64) (internal.Response[[]internal.Hold], error)
+	ListMemberHolds(ctx context.Context, memberId int64) (internal.Response[[]internal.Hold], error)
+	GetHoldById(ctx context.Context, holdId int64) (internal.Response[internal.Hold], error)
+	CancelHold(ctx context.Context, holdId int64) (internal.Response[internal.Hold], error)
+	ExpireHolds(ctx context.Context) (internal.Response[int64], error)
+}
+
+type holdService struct {
+	holdRepo   IHoldRepository
+	memberRepo member.IMemberRepository
+	bookRepo   book.IBookRepository
+	copyRepo   inventory.ICopyRepository
+	policy     internal.HoldPolicy
+}
+
+// NewHoldService queues members for books under policy. A zero policy
+// stands for DefaultHoldPolicy.
+func NewHoldService(repository IHoldRepository, memberRepository member.IMemberRepository, bookRepository book.IBookRepository, copyRepository inventory.ICopyRepository, policy internal.HoldPolicy) IHoldService {
+	if policy == (internal.HoldPolicy{}) {
+		policy = DefaultHoldPolicy()
+	}
+
+	return &holdService{
+		holdRepo:   repository,
+		memberRepo: memberRepository,
+		bookRepo:   bookRepository,
+		copyRepo:   copyRepository,
+		policy:     policy,
+	}
+}
+
+// PlaceHold puts a member at the end of the queue for a book none of whose
+// copies is available. The hold expires after MaxWaitDays of the policy if
+// no copy is set aside for it by then. Suspended members and members whose
+// membership has expired cannot place holds.
+func (s *holdService) PlaceHold(ctx context.Context, h internal.Hold) (internal.Response[internal.Hold], error) {
+	var response internal.Response[internal.Hold]
+
+	if err := validateHold(h); err != nil {
+		response.Data = internal.Hold{}
+		response.Success = false
+		return response, err
+	}
+
+	now := time.Now()
+	m, err := s.memberRepo.GetMemberById(ctx, h.MemberID)
+	if err == nil {
+		err = m.CheckStanding(now)
+	}
+	if err == nil {
+		_, err = s.bookRepo.GetBookById(ctx, h.BookID)
+	}
+	var availability internal.BookAvailability
+	if err == nil {
+		availability, err = s.copyRepo.CountCopies(ctx, h.BookID)
+	}
+	if err == nil && availability.Available > internal.ZERO {
+		err = internal.ErrHoldNotNeeded
+	}
+	if err != nil {
+		response.Data = internal.Hold{}
+		response.Success = false
+		return response, err
+	}
+
+	h.PlacedAt = now
+	h.ExpiresOn = s.policy.WaitUntil(now)
+
+	holdId, err := s.holdRepo.PlaceHold(ctx, h)
+	var data internal.Hold
+	if err == nil {
+		data, err = s.holdRepo.GetHoldById(ctx, holdId)
+	}
+	if err != nil {
+		response.Data = internal.Hold{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Hold placed successfully."
+	return response, nil
+}
+
+// ListBookHolds lists the open holds of a book: the ones with a copy set
+// aside first, then the queue.
+func (s *holdService) ListBookHolds(ctx context.Context, bookId int64) (internal.Response[[]internal.Hold], error) {
+	var response internal.Response[[]internal.Hold]
+
+	if _, err := s.bookRepo.GetBookById(ctx, bookId); err != nil {
+		response.Data = []internal.Hold{}
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.holdRepo.ListHolds(ctx, internal.HoldQuery{BookID: bookId})
+	if err != nil {
+		response.Data = []internal.Hold{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Open holds of the book, in queue order."
+	return response, nil
+}
+
+func (s *holdService) ListMemberHolds(ctx context.Context, memberId int64) (internal.Response[[]internal.Hold], error) {
+	var response internal.Response[[]internal.Hold]
+
+	if _, err := s.memberRepo.GetMemberById(ctx, memberId); err != nil {
+		response.Data = []internal.Hold{}
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.holdRepo.ListHolds(ctx, internal.HoldQuery{MemberID: memberId})
+	if err != nil {
+		response.Data = []internal.Hold{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Open holds of the member, oldest first."
+	return response, nil
+}
+
+func (s *holdService) GetHoldById(ctx context.Context, holdId int64) (internal.Response[internal.Hold], error) {
+	var response internal.Response[internal.Hold]
+
+	data, err := s.holdRepo.GetHoldById(ctx, holdId)
+	if err != nil {
+		response.Data = internal.Hold{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Hold found successfully."
+	return response, nil
+}
+
+// CancelHold takes a member out of the queue. A copy set aside for the hold
+// goes to the next member in line.
+func (s *holdService) CancelHold(ctx context.Context, holdId int64) (internal.Response[internal.Hold], error) {
+	var response internal.Response[internal.Hold]
+
+	_, err := s.holdRepo.CancelHold(ctx, holdId, s.policy, time.Now())
+	var data internal.Hold
+	if err == nil {
+		data, err = s.holdRepo.GetHoldById(ctx, holdId)
+	}
+	if err != nil {
+		response.Data = internal.Hold{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Hold cancelled successfully."
+	return response, nil
+}
+
+// ExpireHolds closes the holds past their expiry day and reports how many
+// there were.
+func (s *holdService) ExpireHolds(ctx context.Context) (internal.Response[int64], error) {
+	var response internal.Response[int64]
+
+	data, err := s.holdRepo.ExpireHolds(ctx, s.policy, time.Now())
+	if err != nil {
+		response.Data = data
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Expired holds closed."
+	return response, nil
+}
+
+// RunHoldExpiry expires holds once right away and then every interval, until
+// ctx is done. Failures are logged and retried on the next tick.
+func RunHoldExpiry(ctx context.Context, service IHoldService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		response, err := service.ExpireHolds(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Could not expire holds. Error: %v", err)
+		} else if response.Data > internal.ZERO {
+			log.Printf("Expired %d holds.\n", response.Data)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// validateHold checks a hold sent by a client. Only the member is the
+// client's to set; the book comes from the route.
+func validateHold(h internal.Hold) error {
+	v := &internal.ValidationError{}
+
+	v.Check(h.ID == internal.ZERO, "ID", "is assigned by the server and must not be set")
+	v.Check(h.BookID > internal.ZERO, "BookID", "is required")
+	v.Check(h.MemberID > internal.ZERO, "MemberID", "is required")
+	v.Check(h.CopyID == internal.ZERO, "CopyID", "is set aside by the server and must not be set")
+	v.Check(h.Status == internal.EMPTY, "Status", "is managed by the server and must not be set")
+	v.Check(h.Position == internal.ZERO, "Position", "is managed by the server and must not be set")
+	v.Check(h.PlacedAt.IsZero(), "PlacedAt", "is managed by the server and must not be set")
+	v.Check(h.ReadyAt == nil, "ReadyAt", "is managed by the server and must not be set")
+	v.Check(h.ExpiresOn.IsZero(), "ExpiresOn", "is set by the hold policy and must not be set")
+	v.Check(h.ClosedAt == nil, "ClosedAt", "is managed by the server and must not be set")
+
+	return v.Err()
+}
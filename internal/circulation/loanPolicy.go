@@ -0,0 +1,36 @@
+package circulation
+
+import "github.com/amarantec/box/internal"
+
+// DefaultLoanPolicies are the loan policies of the member types a loan
+// policies file leaves out.
+func DefaultLoanPolicies() []internal.LoanPolicy {
+	return []internal.LoanPolicy{
+		{MemberType: internal.MemberStandard, LoanDays: 21, MaxRenewals: 2, MaxLoans: 10},
+		{MemberType: internal.MemberStudent, LoanDays: 14, MaxRenewals: 1, MaxLoans: 5},
+		{MemberType: internal.MemberStaff, LoanDays: 28, MaxRenewals: 3, MaxLoans: 20},
+	}
+}
+
+// DefaultHoldPolicy keeps a hold in the queue for about six months and a
+// copy set aside for a week.
+func DefaultHoldPolicy() internal.HoldPolicy {
+	return internal.HoldPolicy{MaxWaitDays: 180, PickupDays: 7}
+}
+
+var loanPoliciesFile = internal.ConfigList[internal.LoanPolicy, internal.MemberType]{
+	Name:  "loan policies",
+	Key:   "member type",
+	Entry: "policy",
+	Keys:  internal.MemberTypes,
+	KeyOf: func(p internal.LoanPolicy) internal.MemberType { return p.MemberType },
+}
+
+// LoadLoanPolicies reads a JSON array of loan policies from path, such as
+//
+//	[{"MemberType": "student", "LoanDays": 7, "MaxRenewals": 0, "MaxLoans": 3}]
+//
+// and checks each of them.
+func LoadLoanPolicies(path string) ([]internal.LoanPolicy, error) {
+	return loanPoliciesFile.Load(path)
+}
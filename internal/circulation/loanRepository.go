@@ -0,0 +1,411 @@
+package circulation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type ILoanRepository interface {
+	Checkout(ctx context.Context, l internal.Loan, maxLoans int) (int64, error)
+	ListLoans(ctx context.Context, q internal.LoanQuery) ([]internal.Loan, internal.Pagination, error)
+	GetLoanById(ctx context.Context, loanId int64) (internal.Loan, error)
+	RenewLoan(ctx context.Context, loanId int64, policy internal.LoanPolicy, now time.Time) (bool, error)
+	ReturnLoan(ctx context.Context, loanId int64, holds internal.HoldPolicy, fines internal.FineRules, now time.Time) (bool, error)
+	DeclareLost(ctx context.Context, loanId int64, fines internal.FineRules, now time.Time) (bool, error)
+}
+
+// loansCopyOpenKey is the unique index allowing a single open loan per copy.
+const loansCopyOpenKey = "loans_copy_open_key"
+
+const loanColumns = `id, copy_id, book_id, member_id, checked_out_at, due_date, renewals, returned_at, lost_at`
+
+// openLoan matches the loans neither returned nor lost.
+const openLoan = `returned_at IS NULL AND lost_at IS NULL`
+
+func scanLoan(row pgx.Row) (internal.Loan, error) {
+	var l internal.Loan
+	if err := row.Scan(
+		&l.ID,
+		&l.CopyID,
+		&l.BookID,
+		&l.MemberID,
+		&l.CheckedOutAt,
+		&l.DueDate,
+		&l.Renewals,
+		&l.ReturnedAt,
+		&l.LostAt,
+	); err != nil {
+		return internal.Loan{}, err
+	}
+	return l, nil
+}
+
+// lockLoan reads a loan inside tx and locks its row until tx ends.
+func lockLoan(ctx context.Context, tx pgx.Tx, loanId int64) (internal.Loan, error) {
+	l, err := scanLoan(
+		tx.QueryRow(
+			ctx,
+			`SELECT `+loanColumns+` FROM loans WHERE id = $1 FOR UPDATE;`, loanId))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.Loan{}, internal.ErrLoanNotFound
+		}
+		return internal.Loan{}, err
+	}
+	return l, nil
+}
+
+// copyTerms reads what decides the charges for the copy of a loan inside
+// tx: its category and its price. A copy purged since is charged as a
+// general one bought for nothing.
+func copyTerms(ctx context.Context, tx pgx.Tx, copyId int64) (internal.ItemCategory, decimal.Decimal, error) {
+	var category internal.ItemCategory
+	var price decimal.Decimal
+	if err :=
+		tx.QueryRow(
+			ctx,
+			`SELECT category, price FROM copies WHERE id = $1;`, copyId).Scan(&category, &price); err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.CategoryGeneral, decimal.Zero, nil
+		}
+		return internal.EMPTY, decimal.Zero, err
+	}
+	return category, price, nil
+}
+
+// chargeLoan charges the member of l amount for reason inside tx. Nothing is
+// charged for a zero amount.
+func chargeLoan(ctx context.Context, tx pgx.Tx, l internal.Loan, reason internal.ChargeReason, amount decimal.Decimal, now time.Time) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	_, err :=
+		tx.Exec(
+			ctx,
+			`INSERT INTO ledger_entries (member_id, loan_id, kind, reason, amount, recorded_by, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7);`,
+			l.MemberID, l.ID, internal.EntryCharge, reason, amount, internal.ActorFromContext(ctx), now)
+	return err
+}
+
+type loanRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewLoanRepository(conn *pgxpool.Pool) ILoanRepository {
+	return &loanRepository{Conn: conn}
+}
+
+// Checkout lends a copy to a member in a single transaction: the member must
+// be in good standing with fewer than maxLoans open loans, and the copy must
+// be available, or set aside for a hold of the member which it fulfills. The
+// copy is put on loan in the same transaction, so it cannot be lent twice.
+func (r *loanRepository) Checkout(ctx context.Context, l internal.Loan, maxLoans int) (int64, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		// Locking the member serializes their checkouts, so that two of
+		// them cannot both pass the loan limit, and keeps them from being
+		// suspended while borrowing.
+		var m internal.Member
+		if err :=
+			tx.QueryRow(
+				ctx,
+				`SELECT status, expires_on FROM members WHERE id = $1 FOR UPDATE;`, l.MemberID).Scan(&m.Status, &m.ExpiresOn); err != nil {
+			if err == pgx.ErrNoRows {
+				return internal.ErrMemberNotFound
+			}
+			return err
+		}
+		if err := m.CheckStanding(l.CheckedOutAt); err != nil {
+			return err
+		}
+
+		var status internal.CopyStatus
+		if err :=
+			tx.QueryRow(
+				ctx,
+				`SELECT book_id, status FROM copies WHERE id = $1 FOR UPDATE;`, l.CopyID).Scan(&l.BookID, &status); err != nil {
+			if err == pgx.ErrNoRows {
+				return internal.ErrCopyNotFound
+			}
+			return err
+		}
+		if status == internal.CopyOnHold {
+			fulfilled, err := fulfillHold(ctx, tx, l.CopyID, l.MemberID, l.CheckedOutAt)
+			if err != nil {
+				return err
+			}
+			if !fulfilled {
+				return internal.ErrCopyOnHold
+			}
+		} else if err := checkCheckout(status); err != nil {
+			return err
+		}
+
+		var open int
+		if err :=
+			tx.QueryRow(
+				ctx,
+				`SELECT COUNT(*) FROM loans WHERE member_id = $1 AND `+openLoan+`;`, l.MemberID).Scan(&open); err != nil {
+			return err
+		}
+		if open >= maxLoans {
+			return internal.ErrLoanLimitReached
+		}
+
+		if err :=
+			tx.QueryRow(
+				ctx,
+				`INSERT INTO loans (copy_id, book_id, member_id, checked_out_at, due_date)
+                VALUES ($1, $2, $3, $4, $5) RETURNING id;`,
+				l.CopyID, l.BookID, l.MemberID, l.CheckedOutAt, l.DueDate).Scan(&l.ID); err != nil {
+			return err
+		}
+
+		_, err :=
+			tx.Exec(
+				ctx,
+				`UPDATE copies SET status = $2, updated_at = $3 WHERE id = $1;`, l.CopyID, internal.CopyOnLoan, l.CheckedOutAt)
+		return err
+	})
+
+	if err != nil {
+		if database.IsUniqueViolationOf(err, loansCopyOpenKey) {
+			return internal.ZERO, internal.ErrCopyOnLoan
+		}
+		return internal.ZERO, err
+	}
+
+	log.Printf("Copy with ID %d lent to member with ID %d.\n", l.CopyID, l.MemberID)
+	return l.ID, nil
+}
+
+func (r *loanRepository) ListLoans(ctx context.Context, q internal.LoanQuery) ([]internal.Loan, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if q.MemberID != internal.ZERO {
+		where("member_id = $%d", q.MemberID)
+	}
+	switch q.State {
+	case internal.LoansOpen:
+		conditions = append(conditions, openLoan)
+	case internal.LoansOverdue:
+		where(openLoan+" AND due_date < $%d", q.Today)
+	case internal.LoansReturned:
+		conditions = append(conditions, "returned_at IS NOT NULL")
+	case internal.LoansLost:
+		conditions = append(conditions, "lost_at IS NOT NULL")
+	}
+
+	filter := internal.EMPTY
+	if len(conditions) > internal.ZERO {
+		filter = ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	if err :=
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT COUNT(*) FROM loans`+filter+`;`, args...).Scan(&pagination.TotalCount); err != nil {
+		return []internal.Loan{}, pagination, err
+	}
+
+	args = append(args, q.PageSize, q.Offset)
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			fmt.Sprintf(`SELECT `+loanColumns+` FROM loans`+filter+`
+            ORDER BY checked_out_at DESC, id DESC
+            LIMIT $%d OFFSET $%d;`, len(args)-1, len(args)), args...)
+
+	if err != nil {
+		return []internal.Loan{}, pagination, err
+	}
+
+	defer rows.Close()
+
+	loans := []internal.Loan{}
+	for rows.Next() {
+		l, err := scanLoan(rows)
+		if err != nil {
+			return []internal.Loan{}, pagination, err
+		}
+		loans = append(loans, l)
+	}
+
+	return loans, pagination, rows.Err()
+}
+
+func (r *loanRepository) GetLoanById(ctx context.Context, loanId int64) (internal.Loan, error) {
+	l, err := scanLoan(
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT `+loanColumns+` FROM loans WHERE id = $1;`, loanId))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.Loan{}, internal.ErrLoanNotFound
+		}
+		return internal.Loan{}, err
+	}
+
+	return l, nil
+}
+
+// RenewLoan extends an open loan under policy, unless more members are
+// waiting for its book than there are copies available.
+func (r *loanRepository) RenewLoan(ctx context.Context, loanId int64, policy internal.LoanPolicy, now time.Time) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		current, err := lockLoan(ctx, tx, loanId)
+		if err != nil {
+			return err
+		}
+		renewed, err := policy.Renew(current, now)
+		if err != nil {
+			return err
+		}
+
+		// Holds are only kept waiting by this loan if the free copies of
+		// the book cannot serve them all.
+		var blocked bool
+		if err :=
+			tx.QueryRow(
+				ctx,
+				`SELECT (SELECT count(*) FROM holds WHERE book_id = $1 AND status = $2) >
+					(SELECT count(*) FROM copies WHERE book_id = $1 AND status = $3);`,
+				current.BookID, internal.HoldWaiting, internal.CopyAvailable).Scan(&blocked); err != nil {
+			return err
+		}
+		if blocked {
+			return internal.ErrRenewalBlocked
+		}
+
+		_, err =
+			tx.Exec(
+				ctx,
+				`UPDATE loans SET due_date = $2, renewals = $3 WHERE id = $1;`, loanId, renewed.DueDate, renewed.Renewals)
+		return err
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("Loan with ID %d renewed.\n", loanId)
+	return true, nil
+}
+
+// ReturnLoan closes an open loan and, in the same transaction, charges its
+// member the overdue fine set by fines for the category of the copy, then
+// sets the copy aside for the first waiting hold on the book under holds or
+// makes it available again.
+func (r *loanRepository) ReturnLoan(ctx context.Context, loanId int64, holds internal.HoldPolicy, fines internal.FineRules, now time.Time) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		current, err := lockLoan(ctx, tx, loanId)
+		if err != nil {
+			return err
+		}
+		if err := current.CheckOpen(); err != nil {
+			return err
+		}
+
+		if _, err :=
+			tx.Exec(
+				ctx,
+				`UPDATE loans SET returned_at = $2 WHERE id = $1;`, loanId, now); err != nil {
+			return err
+		}
+
+		category, _, err := copyTerms(ctx, tx, current.CopyID)
+		if err != nil {
+			return err
+		}
+		if err := chargeLoan(ctx, tx, current, internal.ChargeOverdue, fines.For(category).OverdueFine(current.DueDate, now), now); err != nil {
+			return err
+		}
+
+		tag, err :=
+			tx.Exec(
+				ctx,
+				`UPDATE copies SET status = $2, updated_at = $4 WHERE id = $1 AND status = $3;`,
+				current.CopyID, internal.CopyAvailable, internal.CopyOnLoan, now)
+		if err != nil || tag.RowsAffected() == internal.ZERO {
+			return err
+		}
+
+		_, err = allocateCopy(ctx, tx, current.BookID, current.CopyID, holds, now)
+		return err
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("Loan with ID %d returned.\n", loanId)
+	return true, nil
+}
+
+// DeclareLost closes an open loan whose copy will not come back and marks
+// the copy lost. In the same transaction, its member is charged under fines
+// the overdue fine run up until now and the price of the copy with the lost
+// item fee.
+func (r *loanRepository) DeclareLost(ctx context.Context, loanId int64, fines internal.FineRules, now time.Time) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		current, err := lockLoan(ctx, tx, loanId)
+		if err != nil {
+			return err
+		}
+		if err := current.CheckOpen(); err != nil {
+			return err
+		}
+
+		if _, err :=
+			tx.Exec(
+				ctx,
+				`UPDATE loans SET lost_at = $2 WHERE id = $1;`, loanId, now); err != nil {
+			return err
+		}
+
+		category, price, err := copyTerms(ctx, tx, current.CopyID)
+		if err != nil {
+			return err
+		}
+		rule := fines.For(category)
+		if err := chargeLoan(ctx, tx, current, internal.ChargeOverdue, rule.OverdueFine(current.DueDate, now), now); err != nil {
+			return err
+		}
+		if err := chargeLoan(ctx, tx, current, internal.ChargeLost, rule.LostCharge(price), now); err != nil {
+			return err
+		}
+
+		_, err =
+			tx.Exec(
+				ctx,
+				`UPDATE copies SET status = $2, updated_at = $4 WHERE id = $1 AND status = $3;`,
+				current.CopyID, internal.CopyLost, internal.CopyOnLoan, now)
+		return err
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("Loan with ID %d closed, its copy was lost.\n", loanId)
+	return true, nil
+}
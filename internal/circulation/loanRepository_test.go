@@ -0,0 +1,272 @@
+package circulation_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database/fixturetest"
+)
+
+func TestCirculation(t *testing.T) {
+	fixturetest.Run(t, "circulation_test", testCirculation)
+}
+
+func testCirculation(t *testing.T, r fixturetest.Repositories) {
+	ctx := context.Background()
+	policy := internal.LoanPolicy{MemberType: internal.MemberStandard, LoanDays: 14, MaxRenewals: 1, MaxLoans: 2}
+
+	bookId := fixturetest.RegisterBook(t, r, "Lent")
+	var copyIds []int64
+	for _, barcode := range []string{"L-001", "L-002", "L-003"} {
+		copyId, err := r.Copies.AddCopy(ctx, fixturetest.NewCopy(bookId, barcode))
+		if err != nil {
+			t.Fatalf("AddCopy(%s): %v", barcode, err)
+		}
+		copyIds = append(copyIds, copyId)
+	}
+	memberId := fixturetest.RegisterMember(t, r, "C-1")
+	otherId := fixturetest.RegisterMember(t, r, "C-2")
+	if _, err := r.Members.RegisterMember(ctx, fixturetest.NewMember("Twin", "C-1")); !errors.Is(err, internal.ErrCardNumberAlreadyExists) {
+		t.Fatalf("RegisterMember(duplicate card) error = %v, want %v", err, internal.ErrCardNumberAlreadyExists)
+	}
+
+	checkedOutAt := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+	loanId, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyIds[0], memberId, checkedOutAt), policy.MaxLoans)
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+
+	loan, err := r.Loans.GetLoanById(ctx, loanId)
+	if err != nil {
+		t.Fatalf("GetLoanById: %v", err)
+	}
+	if loan.BookID != bookId || loan.MemberID != memberId || !loan.DueDate.Equal(fixturetest.Date(2026, time.March, 16)) || loan.ReturnedAt != nil {
+		t.Fatalf("GetLoanById = %+v, want an open loan of book %d due 2026-03-16", loan, bookId)
+	}
+	if c, err := r.Copies.GetCopyById(ctx, copyIds[0]); err != nil || c.Status != internal.CopyOnLoan {
+		t.Fatalf("GetCopyById after checkout = %+v, %v, want it on loan", c, err)
+	}
+
+	if _, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyIds[0], otherId, checkedOutAt), policy.MaxLoans); !errors.Is(err, internal.ErrCopyOnLoan) {
+		t.Fatalf("Checkout(copy on loan) error = %v, want %v", err, internal.ErrCopyOnLoan)
+	}
+	if _, err := r.Copies.WithdrawCopy(ctx, copyIds[2]); err != nil {
+		t.Fatalf("WithdrawCopy: %v", err)
+	}
+	if _, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyIds[2], otherId, checkedOutAt), policy.MaxLoans); !errors.Is(err, internal.ErrCopyWithdrawn) {
+		t.Fatalf("Checkout(withdrawn copy) error = %v, want %v", err, internal.ErrCopyWithdrawn)
+	}
+	if _, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(4242, otherId, checkedOutAt), policy.MaxLoans); !errors.Is(err, internal.ErrCopyNotFound) {
+		t.Fatalf("Checkout(missing copy) error = %v, want %v", err, internal.ErrCopyNotFound)
+	}
+	if _, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyIds[1], memberId, checkedOutAt), 1); !errors.Is(err, internal.ErrLoanLimitReached) {
+		t.Fatalf("Checkout(over the limit) error = %v, want %v", err, internal.ErrLoanLimitReached)
+	}
+	otherLoanId, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyIds[1], otherId, checkedOutAt.Add(time.Hour)), policy.MaxLoans)
+	if err != nil {
+		t.Fatalf("Checkout(second copy): %v", err)
+	}
+
+	// Renewed early, the loan runs on from its due date.
+	if _, err := r.Loans.RenewLoan(ctx, loanId, policy, checkedOutAt.AddDate(0, 0, 3)); err != nil {
+		t.Fatalf("RenewLoan: %v", err)
+	}
+	if loan, err = r.Loans.GetLoanById(ctx, loanId); err != nil || loan.Renewals != 1 || !loan.DueDate.Equal(fixturetest.Date(2026, time.March, 30)) {
+		t.Fatalf("GetLoanById after renewal = %+v, %v, want 1 renewal due 2026-03-30", loan, err)
+	}
+	if _, err := r.Loans.RenewLoan(ctx, loanId, policy, checkedOutAt); !errors.Is(err, internal.ErrRenewalLimitReached) {
+		t.Fatalf("RenewLoan(over the limit) error = %v, want %v", err, internal.ErrRenewalLimitReached)
+	}
+
+	today := fixturetest.Date(2026, time.March, 20)
+	for _, tt := range []struct {
+		query internal.LoanQuery
+		want  []int64
+	}{
+		{internal.LoanQuery{State: internal.LoansOpen}, []int64{otherLoanId, loanId}},
+		{internal.LoanQuery{State: internal.LoansOpen, MemberID: memberId}, []int64{loanId}},
+		{internal.LoanQuery{State: internal.LoansOverdue}, []int64{otherLoanId}},
+		{internal.LoanQuery{State: internal.LoansAll, PageSize: 1, Offset: 1}, []int64{loanId}},
+	} {
+		tt.query.Today = today
+		if err := tt.query.Normalize(); err != nil {
+			t.Fatalf("Normalize(%+v): %v", tt.query, err)
+		}
+		loans, pagination, err := r.Loans.ListLoans(ctx, tt.query)
+		if err != nil {
+			t.Fatalf("ListLoans(%+v): %v", tt.query, err)
+		}
+		var got []int64
+		for _, l := range loans {
+			got = append(got, l.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("ListLoans(%+v) = %v (total %d), want %v", tt.query, got, pagination.TotalCount, tt.want)
+		}
+	}
+
+	returnedAt := today.Add(9 * time.Hour)
+	if _, err := r.Loans.ReturnLoan(ctx, loanId, fixturetest.HoldPolicy, fixturetest.FineRules, returnedAt); err != nil {
+		t.Fatalf("ReturnLoan: %v", err)
+	}
+	if _, err := r.Loans.ReturnLoan(ctx, loanId, fixturetest.HoldPolicy, fixturetest.FineRules, returnedAt); !errors.Is(err, internal.ErrLoanReturned) {
+		t.Fatalf("ReturnLoan(returned) error = %v, want %v", err, internal.ErrLoanReturned)
+	}
+	if _, err := r.Loans.RenewLoan(ctx, loanId, policy, returnedAt); !errors.Is(err, internal.ErrLoanReturned) {
+		t.Fatalf("RenewLoan(returned) error = %v, want %v", err, internal.ErrLoanReturned)
+	}
+	if _, err := r.Loans.ReturnLoan(ctx, 4242, fixturetest.HoldPolicy, fixturetest.FineRules, returnedAt); !errors.Is(err, internal.ErrLoanNotFound) {
+		t.Fatalf("ReturnLoan(missing) error = %v, want %v", err, internal.ErrLoanNotFound)
+	}
+	if loan, err = r.Loans.GetLoanById(ctx, loanId); err != nil || loan.ReturnedAt == nil {
+		t.Fatalf("GetLoanById after return = %+v, %v, want it returned", loan, err)
+	}
+	if c, err := r.Copies.GetCopyById(ctx, copyIds[0]); err != nil || c.Status != internal.CopyAvailable {
+		t.Fatalf("GetCopyById after return = %+v, %v, want it available", c, err)
+	}
+
+	// The returned copy can be lent again.
+	if _, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyIds[0], otherId, returnedAt), policy.MaxLoans); err != nil {
+		t.Fatalf("Checkout(returned copy): %v", err)
+	}
+}
+
+func TestConcurrentCheckout(t *testing.T) {
+	fixturetest.Run(t, "circulation_test", testConcurrentCheckout)
+}
+
+func testConcurrentCheckout(t *testing.T, r fixturetest.Repositories) {
+	ctx := context.Background()
+
+	bookId := fixturetest.RegisterBook(t, r, "Popular")
+	copyId, err := r.Copies.AddCopy(ctx, fixturetest.NewCopy(bookId, "P-001"))
+	if err != nil {
+		t.Fatalf("AddCopy: %v", err)
+	}
+
+	const n = 10
+	var memberIds []int64
+	for i := range n {
+		memberIds = append(memberIds, fixturetest.RegisterMember(t, r, fmt.Sprintf("P-%02d", i)))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for _, memberId := range memberIds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, memberId, time.Now()), 5)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	lent := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			lent++
+		case !errors.Is(err, internal.ErrCopyOnLoan):
+			t.Fatalf("Checkout error = %v, want nil or %v", err, internal.ErrCopyOnLoan)
+		}
+	}
+	if lent != 1 {
+		t.Fatalf("the copy was lent %d times, want once", lent)
+	}
+}
+
+func TestCheckoutStanding(t *testing.T) {
+	fixturetest.Run(t, "circulation_test", testCheckoutStanding)
+}
+
+func testCheckoutStanding(t *testing.T, r fixturetest.Repositories) {
+	ctx := context.Background()
+	start := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	bookId := fixturetest.RegisterBook(t, r, "Guarded")
+	copyId, err := r.Copies.AddCopy(ctx, fixturetest.NewCopy(bookId, "G-001"))
+	if err != nil {
+		t.Fatalf("AddCopy: %v", err)
+	}
+
+	suspended := fixturetest.NewMember("Member G-1", "G-1")
+	suspendedId := fixturetest.RegisterMember(t, r, "G-1")
+	suspended.ID = suspendedId
+	suspended.Status = internal.MemberSuspended
+	if _, err := r.Members.UpdateMember(ctx, suspended); err != nil {
+		t.Fatalf("UpdateMember: %v", err)
+	}
+	if _, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, suspendedId, start), 5); !errors.Is(err, internal.ErrMemberSuspended) {
+		t.Fatalf("Checkout(suspended) error = %v, want %v", err, internal.ErrMemberSuspended)
+	}
+
+	expired := fixturetest.RegisterMember(t, r, "G-2")
+	if _, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, expired, fixturetest.Date(2031, time.January, 1)), 5); !errors.Is(err, internal.ErrMembershipExpired) {
+		t.Fatalf("Checkout(expired) error = %v, want %v", err, internal.ErrMembershipExpired)
+	}
+	if _, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, 9999, start), 5); !errors.Is(err, internal.ErrMemberNotFound) {
+		t.Fatalf("Checkout(missing member) error = %v, want %v", err, internal.ErrMemberNotFound)
+	}
+	fixturetest.AssertCopyStatus(t, r, copyId, internal.CopyAvailable)
+}
+
+func TestDeleteBookWithOpenLoans(t *testing.T) {
+	fixturetest.Run(t, "circulation_test", testDeleteBookWithOpenLoans)
+}
+
+func testDeleteBookWithOpenLoans(t *testing.T, r fixturetest.Repositories) {
+	ctx := context.Background()
+	checkedOutAt := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+
+	bookId := fixturetest.RegisterBook(t, r, "Borrowed")
+	copyId, err := r.Copies.AddCopy(ctx, fixturetest.NewCopy(bookId, "D-001"))
+	if err != nil {
+		t.Fatalf("AddCopy: %v", err)
+	}
+	memberId := fixturetest.RegisterMember(t, r, "D-1")
+
+	loanId, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, memberId, checkedOutAt), 5)
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if _, err := r.Books.DeleteBook(ctx, bookId, internal.ZERO); !errors.Is(err, internal.ErrBookHasOpenLoans) {
+		t.Fatalf("DeleteBook(book on loan) error = %v, want %v", err, internal.ErrBookHasOpenLoans)
+	}
+	if _, err := r.Loans.ReturnLoan(ctx, loanId, fixturetest.HoldPolicy, fixturetest.FineRules, checkedOutAt.Add(time.Hour)); err != nil {
+		t.Fatalf("ReturnLoan: %v", err)
+	}
+	if _, err := r.Books.DeleteBook(ctx, bookId, internal.ZERO); err != nil {
+		t.Fatalf("DeleteBook(returned): %v", err)
+	}
+
+	// A copy of a deleted book lent before it was purged keeps it from
+	// being purged.
+	loanId, err = r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, memberId, checkedOutAt.Add(2*time.Hour)), 5)
+	if err != nil {
+		t.Fatalf("Checkout(deleted book): %v", err)
+	}
+	if _, err := r.Books.PurgeBook(ctx, bookId); !errors.Is(err, internal.ErrBookHasOpenLoans) {
+		t.Fatalf("PurgeBook(book on loan) error = %v, want %v", err, internal.ErrBookHasOpenLoans)
+	}
+	if purged, err := r.Books.PurgeDeletedBooks(ctx, time.Now().Add(time.Hour)); err != nil || purged != 0 {
+		t.Fatalf("PurgeDeletedBooks(book on loan) = %d, %v, want 0", purged, err)
+	}
+	if _, err := r.Copies.GetCopyById(ctx, copyId); err != nil {
+		t.Fatalf("GetCopyById after refused purges: %v", err)
+	}
+
+	if _, err := r.Loans.ReturnLoan(ctx, loanId, fixturetest.HoldPolicy, fixturetest.FineRules, checkedOutAt.Add(3*time.Hour)); err != nil {
+		t.Fatalf("ReturnLoan: %v", err)
+	}
+	if _, err := r.Books.PurgeBook(ctx, bookId); err != nil {
+		t.Fatalf("PurgeBook(returned): %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package circulation
+
+import (
+	"context"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/ledger"
+	"github.com/amarantec/box/internal/member"
+)
+
+type ILoanService interface {
+	CheckoutCopy(ctx context.Context, l internal.Loan) (internal.Response[internal.Loan], error)
+	ListLoans(ctx context.Context, q internal.LoanQuery) (internal.Response[[]internal.Loan], error)
+	GetLoanById(ctx context.Context, loanId int64) (internal.Response[internal.Loan], error)
+	RenewLoan(ctx context.Context, loanId int64) (internal.Response[internal.Loan], error)
+	ReturnLoan(ctx context.Context, loanId int64) (internal.Response[internal.Loan], error)
+	DeclareLost(ctx context.Context, loanId int64) (internal.Response[internal.Loan], error)
+}
+
+type loanService struct {
+	loanRepo   ILoanRepository
+	memberRepo member.IMemberRepository
+	policies   map[internal.MemberType]internal.LoanPolicy
+	holdPolicy internal.HoldPolicy
+	fineRules  internal.FineRules
+}
+
+// NewLoanService lends copies under policies, which replace the default
+// policy of their member type. Returned copies are set aside for holds under
+// holdPolicy; a zero holdPolicy stands for DefaultHoldPolicy. Late and lost
+// copies are charged under fineRules, which replace the default rule of
+// their item category.
+func NewLoanService(repository ILoanRepository, memberRepository member.IMemberRepository, policies []internal.LoanPolicy, holdPolicy internal.HoldPolicy, fineRules []internal.FineRule) ILoanService {
+	if holdPolicy == (internal.HoldPolicy{}) {
+		holdPolicy = DefaultHoldPolicy()
+	}
+
+	s := &loanService{
+		loanRepo:   repository,
+		memberRepo: memberRepository,
+		policies:   map[internal.MemberType]internal.LoanPolicy{},
+		holdPolicy: holdPolicy,
+		fineRules:  internal.FineRules{},
+	}
+	for _, p := range append(DefaultLoanPolicies(), policies...) {
+		s.policies[p.MemberType] = p
+	}
+	for _, r := range append(ledger.DefaultFineRules(), fineRules...) {
+		s.fineRules[r.Category] = r
+	}
+	return s
+}
+
+// policyOf returns the loan policy of a member who may borrow at now. A
+// limit set on the member replaces the MaxLoans of their policy.
+func (s *loanService) policyOf(ctx context.Context, memberId int64, now time.Time) (internal.LoanPolicy, error) {
+	m, err := s.memberRepo.GetMemberById(ctx, memberId)
+	if err != nil {
+		return internal.LoanPolicy{}, err
+	}
+	if err := m.CheckStanding(now); err != nil {
+		return internal.LoanPolicy{}, err
+	}
+
+	p, ok := s.policies[m.Type]
+	if !ok {
+		p = s.policies[internal.MemberStandard]
+	}
+	if m.MaxLoans != nil {
+		p.MaxLoans = *m.MaxLoans
+	}
+	return p, nil
+}
+
+// CheckoutCopy lends an available copy, or one set aside for a hold of the
+// member, to a member. It is due back after the number of days of the
+// member's loan policy. Suspended members and members whose membership has
+// expired cannot borrow.
+func (s *loanService) CheckoutCopy(ctx context.Context, l internal.Loan) (internal.Response[internal.Loan], error) {
+	var response internal.Response[internal.Loan]
+
+	if err := validateCheckout(l); err != nil {
+		response.Data = internal.Loan{}
+		response.Success = false
+		return response, err
+	}
+
+	now := time.Now()
+	policy, err := s.policyOf(ctx, l.MemberID, now)
+	if err != nil {
+		response.Data = internal.Loan{}
+		response.Success = false
+		return response, err
+	}
+
+	l.CheckedOutAt = now
+	l.DueDate = policy.DueDate(now)
+
+	loanId, err := s.loanRepo.Checkout(ctx, l, policy.MaxLoans)
+	var data internal.Loan
+	if err == nil {
+		data, err = s.loanRepo.GetLoanById(ctx, loanId)
+	}
+	if err != nil {
+		response.Data = internal.Loan{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Copy checked out successfully."
+	return response, nil
+}
+
+func (s *loanService) ListLoans(ctx context.Context, q internal.LoanQuery) (internal.Response[[]internal.Loan], error) {
+	var response internal.Response[[]internal.Loan]
+
+	if err := q.Normalize(); err != nil {
+		response.Data = []internal.Loan{}
+		response.Success = false
+		return response, err
+	}
+	q.Today = internal.Day(time.Now())
+
+	data, pagination, err := s.loanRepo.ListLoans(ctx, q)
+	if err != nil {
+		response.Data = []internal.Loan{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Loans, most recent checkout first."
+	response.Pagination = &pagination
+	return response, nil
+}
+
+func (s *loanService) GetLoanById(ctx context.Context, loanId int64) (internal.Response[internal.Loan], error) {
+	var response internal.Response[internal.Loan]
+
+	data, err := s.loanRepo.GetLoanById(ctx, loanId)
+	if err != nil {
+		response.Data = internal.Loan{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Loan found successfully."
+	return response, nil
+}
+
+// RenewLoan extends an open loan under the loan policy of its member, up to
+// the number of renewals the policy allows. Loans of a book more members
+// are waiting for than there are copies available, and loans of suspended
+// or expired members, cannot be renewed.
+func (s *loanService) RenewLoan(ctx context.Context, loanId int64) (internal.Response[internal.Loan], error) {
+	var response internal.Response[internal.Loan]
+
+	now := time.Now()
+	l, err := s.loanRepo.GetLoanById(ctx, loanId)
+	var policy internal.LoanPolicy
+	if err == nil {
+		policy, err = s.policyOf(ctx, l.MemberID, now)
+	}
+	if err == nil {
+		_, err = s.loanRepo.RenewLoan(ctx, loanId, policy, now)
+	}
+	var data internal.Loan
+	if err == nil {
+		data, err = s.loanRepo.GetLoanById(ctx, loanId)
+	}
+	if err != nil {
+		response.Data = internal.Loan{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Loan renewed successfully."
+	return response, nil
+}
+
+// ReturnLoan closes an open loan, charging its member a fine if it is late.
+// Its copy is set aside for the first member waiting for the book, or made
+// available again.
+func (s *loanService) ReturnLoan(ctx context.Context, loanId int64) (internal.Response[internal.Loan], error) {
+	var response internal.Response[internal.Loan]
+
+	_, err := s.loanRepo.ReturnLoan(ctx, loanId, s.holdPolicy, s.fineRules, time.Now())
+	var data internal.Loan
+	if err == nil {
+		data, err = s.loanRepo.GetLoanById(ctx, loanId)
+	}
+	if err != nil {
+		response.Data = internal.Loan{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Loan returned successfully."
+	return response, nil
+}
+
+// DeclareLost closes an open loan whose copy will not come back. The copy is
+// marked lost and its member charged for it, on top of any fine for keeping
+// it late.
+func (s *loanService) DeclareLost(ctx context.Context, loanId int64) (internal.Response[internal.Loan], error) {
+	var response internal.Response[internal.Loan]
+
+	_, err := s.loanRepo.DeclareLost(ctx, loanId, s.fineRules, time.Now())
+	var data internal.Loan
+	if err == nil {
+		data, err = s.loanRepo.GetLoanById(ctx, loanId)
+	}
+	if err != nil {
+		response.Data = internal.Loan{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Copy declared lost, loan closed."
+	return response, nil
+}
+
+// validateCheckout checks a loan sent by a client to check a copy out. Only
+// the copy and the member are the client's to set.
+func validateCheckout(l internal.Loan) error {
+	v := &internal.ValidationError{}
+
+	v.Check(l.ID == internal.ZERO, "ID", "is assigned by the server and must not be set")
+	v.Check(l.CopyID > internal.ZERO, "CopyID", "is required")
+	v.Check(l.MemberID > internal.ZERO, "MemberID", "is required")
+	v.Check(l.BookID == internal.ZERO, "BookID", "is the book of the copy and must not be set")
+	v.Check(l.CheckedOutAt.IsZero(), "CheckedOutAt", "is managed by the server and must not be set")
+	v.Check(l.DueDate.IsZero(), "DueDate", "is set by the loan policy and must not be set")
+	v.Check(l.Renewals == internal.ZERO, "Renewals", "is managed by the server and must not be set")
+	v.Check(l.ReturnedAt == nil, "ReturnedAt", "is managed by the server and must not be set")
+	v.Check(l.LostAt == nil, "LostAt", "is managed by the server and must not be set")
+
+	return v.Err()
+}
+
+// checkCheckout reports why a copy with status cannot be lent. A copy on
+// hold can only be lent to the member whose hold it is set aside for.
+func checkCheckout(status internal.CopyStatus) error {
+	switch status {
+	case internal.CopyAvailable:
+		return nil
+	case internal.CopyOnLoan:
+		return internal.ErrCopyOnLoan
+	case internal.CopyOnHold:
+		return internal.ErrCopyOnHold
+	case internal.CopyWithdrawn:
+		return internal.ErrCopyWithdrawn
+	}
+	return internal.ErrCopyUnavailable
+}
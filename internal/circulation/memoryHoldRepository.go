@@ -0,0 +1,277 @@
+package circulation
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/inventory"
+)
+
+// memoryHoldRepository is an IHoldRepository kept in process memory. It sets
+// copies aside through copies, and does not know about books or members: the
+// service checks the book and member of a hold exist.
+type memoryHoldRepository struct {
+	mu     sync.RWMutex
+	holds  map[int64]internal.Hold
+	nextID int64
+	copies inventory.ICopyRepository
+}
+
+func NewMemoryHoldRepository(copies inventory.ICopyRepository) IHoldRepository {
+	return &memoryHoldRepository{holds: map[int64]internal.Hold{}, copies: copies}
+}
+
+// queueOrder orders holds first placed first.
+func queueOrder(a, b internal.Hold) int {
+	if c := a.PlacedAt.Compare(b.PlacedAt); c != internal.ZERO {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// withPosition sets the position of h in its book's queue. The caller must
+// hold r.mu.
+func (r *memoryHoldRepository) withPosition(h internal.Hold) internal.Hold {
+	h.Position = internal.ZERO
+	if h.Status != internal.HoldWaiting {
+		return h
+	}
+
+	for _, other := range r.holds {
+		if other.BookID == h.BookID && other.Status == internal.HoldWaiting && queueOrder(other, h) <= internal.ZERO {
+			h.Position++
+		}
+	}
+	return h
+}
+
+// next returns the first waiting hold on bookId. The caller must hold r.mu.
+func (r *memoryHoldRepository) next(bookId int64) (internal.Hold, bool) {
+	var first internal.Hold
+	found := false
+	for _, h := range r.holds {
+		if h.BookID == bookId && h.Status == internal.HoldWaiting && (!found || queueOrder(h, first) < internal.ZERO) {
+			first, found = h, true
+		}
+	}
+	return first, found
+}
+
+// setAside makes h ready with copyId. The caller must hold r.mu.
+func (r *memoryHoldRepository) setAside(h internal.Hold, copyId int64, policy internal.HoldPolicy, now time.Time) {
+	h.Status = internal.HoldReady
+	h.CopyID = copyId
+	h.ReadyAt = &now
+	h.ExpiresOn = policy.PickupBy(now)
+	r.holds[h.ID] = h
+}
+
+// release passes the copy set aside for the closed hold h on to the next
+// waiting hold, or puts it back on the shelf. The caller must hold r.mu.
+func (r *memoryHoldRepository) release(ctx context.Context, h internal.Hold, policy internal.HoldPolicy, now time.Time) error {
+	if next, ok := r.next(h.BookID); ok {
+		r.setAside(next, h.CopyID, policy, now)
+		return nil
+	}
+
+	_, err := r.copies.ChangeCopyStatus(ctx, h.CopyID, internal.CopyOnHold, internal.CopyAvailable)
+	return err
+}
+
+func (r *memoryHoldRepository) PlaceHold(ctx context.Context, h internal.Hold) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.holds {
+		if other.BookID == h.BookID && other.MemberID == h.MemberID && other.Open() {
+			return internal.ZERO, internal.ErrHoldAlreadyPlaced
+		}
+	}
+
+	r.nextID++
+	h.ID = r.nextID
+	h.CopyID = internal.ZERO
+	h.Status = internal.HoldWaiting
+	h.Position = internal.ZERO
+	h.ReadyAt = nil
+	h.ClosedAt = nil
+	r.holds[h.ID] = h
+
+	return h.ID, nil
+}
+
+func (r *memoryHoldRepository) ListHolds(ctx context.Context, q internal.HoldQuery) ([]internal.Hold, error) {
+	if err := ctx.Err(); err != nil {
+		return []internal.Hold{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	holds := []internal.Hold{}
+	for _, h := range r.holds {
+		if !h.Open() || (q.BookID != internal.ZERO && h.BookID != q.BookID) || (q.MemberID != internal.ZERO && h.MemberID != q.MemberID) {
+			continue
+		}
+		holds = append(holds, r.withPosition(h))
+	}
+
+	slices.SortFunc(holds, func(a, b internal.Hold) int {
+		if q.BookID != internal.ZERO && a.Status != b.Status {
+			if a.Status == internal.HoldReady {
+				return -1
+			}
+			return 1
+		}
+		return queueOrder(a, b)
+	})
+	return holds, nil
+}
+
+func (r *memoryHoldRepository) GetHoldById(ctx context.Context, holdId int64) (internal.Hold, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.Hold{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	h, ok := r.holds[holdId]
+	if !ok {
+		return internal.Hold{}, internal.ErrHoldNotFound
+	}
+	return r.withPosition(h), nil
+}
+
+func (r *memoryHoldRepository) CancelHold(ctx context.Context, holdId int64, policy internal.HoldPolicy, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.holds[holdId]
+	if !ok {
+		return false, internal.ErrHoldNotFound
+	}
+	if !h.Open() {
+		return false, internal.ErrHoldClosed
+	}
+
+	wasReady := h.Status == internal.HoldReady
+	h.Status = internal.HoldCancelled
+	h.ClosedAt = &now
+	r.holds[holdId] = h
+
+	if wasReady {
+		if err := r.release(ctx, h, policy, now); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (r *memoryHoldRepository) ExpireHolds(ctx context.Context, policy internal.HoldPolicy, now time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Every expired hold is closed before any copy is released, so that
+	// none is set aside for a hold expiring as well.
+	var released []internal.Hold
+	var expired int64
+	for id, h := range r.holds {
+		if !h.Expired(now) {
+			continue
+		}
+		if h.Status == internal.HoldReady {
+			released = append(released, h)
+		}
+		h.Status = internal.HoldExpired
+		h.ClosedAt = &now
+		r.holds[id] = h
+		expired++
+	}
+
+	slices.SortFunc(released, queueOrder)
+	for _, h := range released {
+		if err := r.release(ctx, h, policy, now); err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+func (r *memoryHoldRepository) AllocateCopy(ctx context.Context, copyId int64, policy internal.HoldPolicy, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.copies.GetCopyById(ctx, copyId)
+	if err != nil {
+		return false, err
+	}
+	next, ok := r.next(c.BookID)
+	if !ok {
+		return false, nil
+	}
+
+	changed, err := r.copies.ChangeCopyStatus(ctx, copyId, internal.CopyAvailable, internal.CopyOnHold)
+	if err != nil || !changed {
+		return false, err
+	}
+	r.setAside(next, copyId, policy, now)
+
+	return true, nil
+}
+
+func (r *memoryHoldRepository) FulfillHold(ctx context.Context, copyId, memberId int64, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, h := range r.holds {
+		if h.CopyID == copyId && h.MemberID == memberId && h.Status == internal.HoldReady {
+			h.Status = internal.HoldFulfilled
+			h.ClosedAt = &now
+			r.holds[id] = h
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryHoldRepository) CountWaitingHolds(ctx context.Context, bookId int64) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := internal.ZERO
+	for _, h := range r.holds {
+		if h.BookID == bookId && h.Status == internal.HoldWaiting {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,313 @@
+package circulation
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/inventory"
+	"github.com/amarantec/box/internal/ledger"
+	"github.com/amarantec/box/internal/member"
+	"github.com/shopspring/decimal"
+)
+
+// memoryLoanRepository is an ILoanRepository kept in process memory. It
+// checks the standing of borrowers through members, puts copies on loan and
+// back through copies, fulfills and allocates holds through holds, and
+// charges members through ledger.
+type memoryLoanRepository struct {
+	mu      sync.RWMutex
+	loans   map[int64]internal.Loan
+	nextID  int64
+	members member.IMemberRepository
+	copies  inventory.ICopyRepository
+	holds   IHoldRepository
+	ledger  ledger.ILedgerRepository
+}
+
+func NewMemoryLoanRepository(members member.IMemberRepository, copies inventory.ICopyRepository, holds IHoldRepository, accounts ledger.ILedgerRepository) ILoanRepository {
+	return &memoryLoanRepository{loans: map[int64]internal.Loan{}, members: members, copies: copies, holds: holds, ledger: accounts}
+}
+
+// openLoans counts the open loans of a member. The caller must hold r.mu.
+func (r *memoryLoanRepository) openLoans(memberId int64) int {
+	open := internal.ZERO
+	for _, l := range r.loans {
+		if l.MemberID == memberId && l.Open() {
+			open++
+		}
+	}
+	return open
+}
+
+func (r *memoryLoanRepository) Checkout(ctx context.Context, l internal.Loan, maxLoans int) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, err := r.members.GetMemberById(ctx, l.MemberID)
+	if err != nil {
+		return internal.ZERO, err
+	}
+	if err := m.CheckStanding(l.CheckedOutAt); err != nil {
+		return internal.ZERO, err
+	}
+
+	c, err := r.copies.GetCopyById(ctx, l.CopyID)
+	if err != nil {
+		return internal.ZERO, err
+	}
+	if c.Status != internal.CopyOnHold {
+		if err := checkCheckout(c.Status); err != nil {
+			return internal.ZERO, err
+		}
+	}
+	if r.openLoans(l.MemberID) >= maxLoans {
+		return internal.ZERO, internal.ErrLoanLimitReached
+	}
+
+	// The copy may have been changed since it was read, outside r.mu.
+	changed, err := r.copies.ChangeCopyStatus(ctx, c.ID, c.Status, internal.CopyOnLoan)
+	if err != nil {
+		return internal.ZERO, err
+	}
+	if !changed {
+		return internal.ZERO, internal.ErrCopyUnavailable
+	}
+	if c.Status == internal.CopyOnHold {
+		fulfilled, err := r.holds.FulfillHold(ctx, c.ID, l.MemberID, l.CheckedOutAt)
+		if err == nil && !fulfilled {
+			err = internal.ErrCopyOnHold
+		}
+		if err != nil {
+			// Put the copy back on the shelf for the hold it was set aside for.
+			if _, undoErr := r.copies.ChangeCopyStatus(ctx, c.ID, internal.CopyOnLoan, internal.CopyOnHold); undoErr != nil {
+				return internal.ZERO, errors.Join(err, undoErr)
+			}
+			return internal.ZERO, err
+		}
+	}
+
+	r.nextID++
+	l.ID = r.nextID
+	l.BookID = c.BookID
+	l.Renewals = internal.ZERO
+	l.ReturnedAt = nil
+	l.LostAt = nil
+	r.loans[l.ID] = l
+
+	return l.ID, nil
+}
+
+func (r *memoryLoanRepository) ListLoans(ctx context.Context, q internal.LoanQuery) ([]internal.Loan, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+	if err := ctx.Err(); err != nil {
+		return []internal.Loan{}, pagination, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	loans := []internal.Loan{}
+	for _, l := range r.loans {
+		if q.MemberID != internal.ZERO && l.MemberID != q.MemberID {
+			continue
+		}
+
+		var match bool
+		switch q.State {
+		case internal.LoansOpen:
+			match = l.Open()
+		case internal.LoansOverdue:
+			match = l.Open() && l.DueDate.Before(q.Today)
+		case internal.LoansReturned:
+			match = l.ReturnedAt != nil
+		case internal.LoansLost:
+			match = l.LostAt != nil
+		case internal.LoansAll:
+			match = true
+		}
+		if match {
+			loans = append(loans, l)
+		}
+	}
+
+	slices.SortFunc(loans, func(a, b internal.Loan) int {
+		if c := b.CheckedOutAt.Compare(a.CheckedOutAt); c != internal.ZERO {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	pagination.TotalCount = int64(len(loans))
+	loans = loans[min(q.Offset, len(loans)):]
+	if len(loans) > q.PageSize {
+		loans = loans[:q.PageSize]
+	}
+
+	return loans, pagination, nil
+}
+
+func (r *memoryLoanRepository) GetLoanById(ctx context.Context, loanId int64) (internal.Loan, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.Loan{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	l, ok := r.loans[loanId]
+	if !ok {
+		return internal.Loan{}, internal.ErrLoanNotFound
+	}
+	return l, nil
+}
+
+func (r *memoryLoanRepository) RenewLoan(ctx context.Context, loanId int64, policy internal.LoanPolicy, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.loans[loanId]
+	if !ok {
+		return false, internal.ErrLoanNotFound
+	}
+	renewed, err := policy.Renew(current, now)
+	if err != nil {
+		return false, err
+	}
+	waiting, err := r.holds.CountWaitingHolds(ctx, current.BookID)
+	if err != nil {
+		return false, err
+	}
+	availability, err := r.copies.CountCopies(ctx, current.BookID)
+	if err != nil {
+		return false, err
+	}
+	if waiting > availability.Available {
+		return false, internal.ErrRenewalBlocked
+	}
+	r.loans[loanId] = renewed
+
+	return true, nil
+}
+
+// copyTerms returns the category and the price of a copy, as a general one
+// bought for nothing if it is gone.
+func (r *memoryLoanRepository) copyTerms(ctx context.Context, copyId int64) (internal.ItemCategory, decimal.Decimal, error) {
+	c, err := r.copies.GetCopyById(ctx, copyId)
+	if errors.Is(err, internal.ErrCopyNotFound) {
+		return internal.CategoryGeneral, decimal.Zero, nil
+	}
+	if err != nil {
+		return internal.EMPTY, decimal.Zero, err
+	}
+	return c.Category, c.Price, nil
+}
+
+// charge charges the member of l amount for reason. Nothing is charged for a
+// zero amount.
+func (r *memoryLoanRepository) charge(ctx context.Context, l internal.Loan, reason internal.ChargeReason, amount decimal.Decimal, now time.Time) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	_, err := r.ledger.AddEntry(ctx, internal.LedgerEntry{
+		MemberID:   l.MemberID,
+		LoanID:     l.ID,
+		Kind:       internal.EntryCharge,
+		Reason:     reason,
+		Amount:     amount,
+		RecordedBy: internal.ActorFromContext(ctx),
+		CreatedAt:  now,
+	})
+	return err
+}
+
+func (r *memoryLoanRepository) ReturnLoan(ctx context.Context, loanId int64, holds internal.HoldPolicy, fines internal.FineRules, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.loans[loanId]
+	if !ok {
+		return false, internal.ErrLoanNotFound
+	}
+	if err := l.CheckOpen(); err != nil {
+		return false, err
+	}
+
+	category, _, err := r.copyTerms(ctx, l.CopyID)
+	if err != nil {
+		return false, err
+	}
+	if err := r.charge(ctx, l, internal.ChargeOverdue, fines.For(category).OverdueFine(l.DueDate, now), now); err != nil {
+		return false, err
+	}
+
+	l.ReturnedAt = &now
+	r.loans[loanId] = l
+
+	returned, err := r.copies.ChangeCopyStatus(ctx, l.CopyID, internal.CopyOnLoan, internal.CopyAvailable)
+	if err != nil {
+		return false, err
+	}
+	if returned {
+		if _, err := r.holds.AllocateCopy(ctx, l.CopyID, holds, now); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (r *memoryLoanRepository) DeclareLost(ctx context.Context, loanId int64, fines internal.FineRules, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.loans[loanId]
+	if !ok {
+		return false, internal.ErrLoanNotFound
+	}
+	if err := l.CheckOpen(); err != nil {
+		return false, err
+	}
+
+	category, price, err := r.copyTerms(ctx, l.CopyID)
+	if err != nil {
+		return false, err
+	}
+	rule := fines.For(category)
+	if err := r.charge(ctx, l, internal.ChargeOverdue, rule.OverdueFine(l.DueDate, now), now); err != nil {
+		return false, err
+	}
+	if err := r.charge(ctx, l, internal.ChargeLost, rule.LostCharge(price), now); err != nil {
+		return false, err
+	}
+
+	l.LostAt = &now
+	r.loans[loanId] = l
+
+	if _, err := r.copies.ChangeCopyStatus(ctx, l.CopyID, internal.CopyOnLoan, internal.CopyLost); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
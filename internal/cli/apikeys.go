@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/amarantec/box/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+// runWithAPIKeyService opens a database connection and hands an API key
+// service backed by it to fn.
+func runWithAPIKeyService(cmd *cobra.Command, fn func(ctx context.Context, service auth.IAPIKeyService) error) error {
+	ctx := cmd.Context()
+
+	Conn, err := openConnection(ctx, connectTimeout)
+	if err != nil {
+		return err
+	}
+	defer Conn.Close()
+
+	return fn(ctx, auth.NewAPIKeyService(postgresRepositories(Conn).APIKeys))
+}
+
+func newAPIKeysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apikeys",
+		Short: "Manage the API keys clients authenticate with",
+	}
+
+	cmd.AddCommand(
+		newAPIKeysCreateCmd(),
+		newAPIKeysListCmd(),
+		newAPIKeysRevokeCmd(),
+	)
+
+	return cmd
+}
+
+func newAPIKeysCreateCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create an API key and print it, once",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWithAPIKeyService(cmd, func(ctx context.Context, service auth.IAPIKeyService) error {
+				response, err := service.CreateAPIKey(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				if asJSON {
+					return printJSON(cmd.OutOrStdout(), response)
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "API key %d (%s) created. Store it now, it cannot be shown again:\n\n%s\n",
+					response.Data.APIKey.ID, response.Data.APIKey.Name, response.Data.Key)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the JSON response instead of text")
+
+	return cmd
+}
+
+func newAPIKeysListCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the API keys, revoked ones included",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWithAPIKeyService(cmd, func(ctx context.Context, service auth.IAPIKeyService) error {
+				response, err := service.ListAPIKeys(ctx)
+				if err != nil {
+					return err
+				}
+				if asJSON {
+					return printJSON(cmd.OutOrStdout(), response)
+				}
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tNAME\tPREFIX\tCREATED\tCREATED BY\tREVOKED")
+				for _, k := range response.Data {
+					revoked := "-"
+					if k.RevokedAt != nil {
+						revoked = k.RevokedAt.Format(time.RFC3339)
+					}
+					fmt.Fprintf(w, "%d\t%s\t%s…\t%s\t%s\t%s\n", k.ID, k.Name, k.Prefix,
+						k.CreatedAt.Format(time.RFC3339), k.CreatedBy, revoked)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the JSON response instead of a table")
+
+	return cmd
+}
+
+func newAPIKeysRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke <key-id>",
+		Short: "Revoke an API key, which stops authenticating at once",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyId, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid key id %q: %w", args[0], err)
+			}
+
+			return runWithAPIKeyService(cmd, func(ctx context.Context, service auth.IAPIKeyService) error {
+				response, err := service.RevokeAPIKey(ctx, keyId)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), response.Message)
+				return nil
+			})
+		},
+	}
+}
@@ -0,0 +1,370 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/book"
+	"github.com/spf13/cobra"
+)
+
+const dateLayout = "2006-01-02"
+
+type bookFlags struct {
+	title       string
+	description string
+	genres      []string
+	authors     []string
+	publishDate string
+	publisher   string
+	pages       int
+	isbn        string
+}
+
+func (f *bookFlags) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&f.title, "title", "", "book title")
+	flags.StringVar(&f.description, "description", "", "book description")
+	flags.StringSliceVar(&f.genres, "genre", nil, "book genre (repeatable)")
+	flags.StringSliceVar(&f.authors, "author", nil, "book author (repeatable)")
+	flags.StringVar(&f.publishDate, "publish-date", "", "publish date (YYYY-MM-DD)")
+	flags.StringVar(&f.publisher, "publisher", "", "book publisher")
+	flags.IntVar(&f.pages, "pages", 0, "number of pages")
+	flags.StringVar(&f.isbn, "isbn", "", "ISBN-10 or ISBN-13, hyphens allowed")
+
+	for _, name := range []string{"title", "description", "genre", "author", "publish-date", "publisher", "pages"} {
+		cmd.MarkFlagRequired(name)
+	}
+}
+
+func (f *bookFlags) book() (internal.Book, error) {
+	publishDate, err := time.Parse(dateLayout, f.publishDate)
+	if err != nil {
+		return internal.Book{}, fmt.Errorf("invalid --publish-date: %w", err)
+	}
+
+	b := internal.Book{
+		Title:       f.title,
+		Description: f.description,
+		PublishDate: publishDate,
+		Publisher:   internal.Publisher{Name: f.publisher},
+		Pages:       f.pages,
+	}
+	b.SetISBN(f.isbn)
+	for _, name := range f.genres {
+		b.Genres = append(b.Genres, internal.Genre{Name: name})
+	}
+	for _, name := range f.authors {
+		b.Authors = append(b.Authors, internal.Author{Name: name})
+	}
+
+	return b, nil
+}
+
+// runWithBookService opens a database connection and hands a book service
+// backed by it to fn.
+func runWithBookService(cmd *cobra.Command, fn func(ctx context.Context, service book.IBookService) (any, error)) error {
+	ctx := cmd.Context()
+
+	Conn, err := openConnection(ctx, connectTimeout)
+	if err != nil {
+		return err
+	}
+	defer Conn.Close()
+
+	response, err := fn(ctx, newBookService(postgresRepositories(Conn)))
+	if err != nil {
+		return err
+	}
+
+	return printJSON(cmd.OutOrStdout(), response)
+}
+
+func parseBookId(arg string) (int64, error) {
+	bookId, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return internal.ZERO, fmt.Errorf("invalid book id %q: %w", arg, err)
+	}
+	return bookId, nil
+}
+
+func newBooksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "books",
+		Short: "Manage the book catalog",
+	}
+
+	cmd.AddCommand(
+		newBooksListCmd(),
+		newBooksGetCmd(),
+		newBooksISBNCmd(),
+		newBooksRegisterCmd(),
+		newBooksUpdateCmd(),
+		newBooksDeleteCmd(),
+		newBooksRestoreCmd(),
+		newBooksPurgeCmd(),
+	)
+
+	return cmd
+}
+
+type bookQueryFlags struct {
+	pageSize      int
+	offset        int
+	cursor        string
+	sort          string
+	publisher     string
+	publishedFrom string
+	publishedTo   string
+	minPages      int
+	maxPages      int
+	genre         string
+	author        string
+}
+
+func (f *bookQueryFlags) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.IntVar(&f.pageSize, "page-size", internal.DefaultPageSize, "number of books per page")
+	flags.IntVar(&f.offset, "offset", 0, "number of books to skip")
+	flags.StringVar(&f.cursor, "cursor", "", "cursor returned by a previous page")
+	f.registerFilters(cmd)
+}
+
+// registerFilters registers the sort and filter flags without the page ones.
+func (f *bookQueryFlags) registerFilters(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&f.sort, "sort", "id", "sort field, prefix with - for descending")
+	flags.StringVar(&f.publisher, "publisher", "", "only list books from this publisher")
+	flags.StringVar(&f.publishedFrom, "published-from", "", "only list books published on or after this date (YYYY-MM-DD)")
+	flags.StringVar(&f.publishedTo, "published-to", "", "only list books published on or before this date (YYYY-MM-DD)")
+	flags.IntVar(&f.minPages, "min-pages", 0, "only list books with at least this many pages")
+	flags.IntVar(&f.maxPages, "max-pages", 0, "only list books with at most this many pages")
+	flags.StringVar(&f.genre, "genre", "", "only list books of this genre")
+	flags.StringVar(&f.author, "author", "", "only list books by this author")
+}
+
+func (f *bookQueryFlags) query(cmd *cobra.Command) (internal.BookQuery, error) {
+	q := internal.BookQuery{
+		PageSize:      f.pageSize,
+		Offset:        f.offset,
+		Cursor:        f.cursor,
+		SortBy:        strings.TrimPrefix(f.sort, "-"),
+		SortDirection: internal.SortAsc,
+		Publisher:     f.publisher,
+		Genre:         f.genre,
+		Author:        f.author,
+	}
+
+	if strings.HasPrefix(f.sort, "-") {
+		q.SortDirection = internal.SortDesc
+	}
+
+	for _, d := range []struct {
+		value  string
+		name   string
+		target **time.Time
+	}{
+		{f.publishedFrom, "--published-from", &q.PublishedFrom},
+		{f.publishedTo, "--published-to", &q.PublishedTo},
+	} {
+		if d.value == "" {
+			continue
+		}
+		t, err := time.Parse(dateLayout, d.value)
+		if err != nil {
+			return q, fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+		*d.target = &t
+	}
+
+	if cmd.Flags().Changed("min-pages") {
+		q.MinPages = &f.minPages
+	}
+	if cmd.Flags().Changed("max-pages") {
+		q.MaxPages = &f.maxPages
+	}
+
+	return q, nil
+}
+
+func newBooksListCmd() *cobra.Command {
+	f := &bookQueryFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List books",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q, err := f.query(cmd)
+			if err != nil {
+				return err
+			}
+
+			return runWithBookService(cmd, func(ctx context.Context, service book.IBookService) (any, error) {
+				return service.ListBooks(ctx, q)
+			})
+		},
+	}
+
+	f.register(cmd)
+
+	return cmd
+}
+
+func newBooksGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <id>",
+		Short: "Show a book",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bookId, err := parseBookId(args[0])
+			if err != nil {
+				return err
+			}
+
+			return runWithBookService(cmd, func(ctx context.Context, service book.IBookService) (any, error) {
+				return service.GetBookById(ctx, bookId)
+			})
+		},
+	}
+}
+
+func newBooksISBNCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "isbn <isbn>",
+		Short: "Show the book with an ISBN-10 or ISBN-13",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWithBookService(cmd, func(ctx context.Context, service book.IBookService) (any, error) {
+				return service.GetBookByISBN(ctx, args[0])
+			})
+		},
+	}
+}
+
+func newBooksRegisterCmd() *cobra.Command {
+	f := &bookFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "register",
+		Short: "Register a new book",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := f.book()
+			if err != nil {
+				return err
+			}
+
+			return runWithBookService(cmd, func(ctx context.Context, service book.IBookService) (any, error) {
+				return service.RegisterBook(ctx, b)
+			})
+		},
+	}
+
+	f.register(cmd)
+
+	return cmd
+}
+
+func newBooksUpdateCmd() *cobra.Command {
+	f := &bookFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "update <id>",
+		Short: "Replace every field of a book",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bookId, err := parseBookId(args[0])
+			if err != nil {
+				return err
+			}
+
+			b, err := f.book()
+			if err != nil {
+				return err
+			}
+			b.ID = bookId
+
+			return runWithBookService(cmd, func(ctx context.Context, service book.IBookService) (any, error) {
+				return service.UpdateBook(ctx, b)
+			})
+		},
+	}
+
+	f.register(cmd)
+
+	return cmd
+}
+
+func newBooksDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Delete a book",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bookId, err := parseBookId(args[0])
+			if err != nil {
+				return err
+			}
+
+			return runWithBookService(cmd, func(ctx context.Context, service book.IBookService) (any, error) {
+				return service.DeleteBook(ctx, bookId, internal.ZERO)
+			})
+		},
+	}
+}
+
+func newBooksRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore <id>",
+		Short: "Restore a deleted book",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bookId, err := parseBookId(args[0])
+			if err != nil {
+				return err
+			}
+
+			return runWithBookService(cmd, func(ctx context.Context, service book.IBookService) (any, error) {
+				return service.RestoreBook(ctx, bookId, internal.ZERO)
+			})
+		},
+	}
+}
+
+func newBooksPurgeCmd() *cobra.Command {
+	var olderThan time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "purge [id]",
+		Short: "Permanently remove a deleted book, or every book deleted more than --older-than ago",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (len(args) == internal.ZERO) == !cmd.Flags().Changed("older-than") {
+				return fmt.Errorf("give either a book id or --older-than")
+			}
+
+			if len(args) == internal.ZERO {
+				return runWithBookService(cmd, func(ctx context.Context, service book.IBookService) (any, error) {
+					return service.PurgeDeletedBooks(ctx, olderThan)
+				})
+			}
+
+			bookId, err := parseBookId(args[0])
+			if err != nil {
+				return err
+			}
+
+			return runWithBookService(cmd, func(ctx context.Context, service book.IBookService) (any, error) {
+				return service.PurgeBook(ctx, bookId)
+			})
+		},
+	}
+
+	cmd.Flags().DurationVar(&olderThan, "older-than", 0, "purge every book deleted more than this long ago")
+	return cmd
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/amarantec/box/internal"
+	"github.com/spf13/cobra"
+)
+
+// exportExtensions maps file extensions to the export format they imply.
+var exportExtensions = map[string]string{
+	".csv":    internal.ExportFormatCSV,
+	".ndjson": internal.ExportFormatNDJSON,
+	".jsonl":  internal.ExportFormatNDJSON,
+	".xlsx":   internal.ExportFormatXLSX,
+}
+
+func newExportCmd() *cobra.Command {
+	f := &bookQueryFlags{}
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the books matching the filters as CSV, NDJSON or XLSX",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q, err := f.query(cmd)
+			if err != nil {
+				return err
+			}
+
+			if format == internal.EMPTY {
+				format = exportExtensions[strings.ToLower(filepath.Ext(output))]
+			}
+			if format == internal.EMPTY {
+				format = internal.ExportFormatCSV
+			}
+
+			ctx := cmd.Context()
+
+			Conn, err := openConnection(ctx, connectTimeout)
+			if err != nil {
+				return err
+			}
+			defer Conn.Close()
+
+			service := newBookService(postgresRepositories(Conn))
+			if output == internal.EMPTY {
+				_, err := service.ExportBooks(ctx, q, format, cmd.OutOrStdout())
+				return err
+			}
+
+			file, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			response, err := service.ExportBooks(ctx, q, format, file)
+			if err != nil {
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "exported %d books to %s\n", response.Data, output)
+			return nil
+		},
+	}
+
+	f.registerFilters(cmd)
+	cmd.Flags().StringVar(&format, "format", "", "csv, ndjson or xlsx (default: from the --output extension, else csv)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write (default: stdout)")
+
+	return cmd
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/ledger"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+// runWithLedgerService opens a database connection and hands a ledger
+// service backed by it to fn.
+func runWithLedgerService(cmd *cobra.Command, fn func(ctx context.Context, service ledger.ILedgerService) error) error {
+	ctx := cmd.Context()
+
+	Conn, err := openConnection(ctx, connectTimeout)
+	if err != nil {
+		return err
+	}
+	defer Conn.Close()
+
+	return fn(ctx, newLedgerService(postgresRepositories(Conn)))
+}
+
+func parseMemberId(arg string) (int64, error) {
+	memberId, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return internal.ZERO, fmt.Errorf("invalid member id %q: %w", arg, err)
+	}
+	return memberId, nil
+}
+
+func newFinesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fines",
+		Short: "Report what members owe in fines",
+	}
+
+	cmd.AddCommand(
+		newFinesOutstandingCmd(),
+		newFinesMemberCmd(),
+	)
+
+	return cmd
+}
+
+func newFinesOutstandingCmd() *cobra.Command {
+	var minOutstanding string
+	var pageSize, offset int
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "outstanding",
+		Short: "List the members with an outstanding balance, largest first",
+		Long: `List the members with an outstanding balance, largest first.
+
+Loans are charged when they are returned or declared lost, so fines still
+accruing on open overdue loans are not listed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := internal.BalanceQuery{PageSize: pageSize, Offset: offset}
+			if minOutstanding != "" {
+				var err error
+				if q.MinOutstanding, err = decimal.NewFromString(minOutstanding); err != nil {
+					return fmt.Errorf("invalid --min: %w", err)
+				}
+			}
+
+			return runWithLedgerService(cmd, func(ctx context.Context, service ledger.ILedgerService) error {
+				response, err := service.ListBalances(ctx, q)
+				if err != nil {
+					return err
+				}
+				if asJSON {
+					return printJSON(cmd.OutOrStdout(), response)
+				}
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "MEMBER\tCARD\tNAME\tCHARGED\tPAID\tWAIVED\tOUTSTANDING")
+				for _, b := range response.Data {
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", b.MemberID, b.CardNumber, b.Name,
+						b.Charged.StringFixed(2), b.Paid.StringFixed(2), b.Waived.StringFixed(2), b.Outstanding.StringFixed(2))
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "%d of %d member(s) with an outstanding balance.\n",
+					len(response.Data), response.Pagination.TotalCount)
+				return nil
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&minOutstanding, "min", "", "only list members owing at least this amount, such as 5.00")
+	flags.IntVar(&pageSize, "page-size", internal.DefaultPageSize, "number of members per page")
+	flags.IntVar(&offset, "offset", 0, "number of members to skip")
+	flags.BoolVar(&asJSON, "json", false, "print the JSON response instead of a table")
+
+	return cmd
+}
+
+func newFinesMemberCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "member <member-id>",
+		Short: "Show the balance and ledger of a member",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			memberId, err := parseMemberId(args[0])
+			if err != nil {
+				return err
+			}
+
+			return runWithLedgerService(cmd, func(ctx context.Context, service ledger.ILedgerService) error {
+				balance, err := service.GetBalance(ctx, memberId)
+				if err != nil {
+					return err
+				}
+				entries, err := service.ListEntries(ctx, memberId)
+				if err != nil {
+					return err
+				}
+				if asJSON {
+					return printJSON(cmd.OutOrStdout(), map[string]any{
+						"balance": balance,
+						"ledger":  entries,
+					})
+				}
+
+				b := balance.Data
+				fmt.Fprintf(cmd.OutOrStdout(), "Member %d, %s (%s): %s outstanding.\n\n",
+					b.MemberID, b.Name, b.CardNumber, b.Outstanding.StringFixed(2))
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "DATE\tKIND\tREASON\tLOAN\tAMOUNT\tRECORDED BY\tNOTE")
+				for _, e := range entries.Data {
+					loan := "-"
+					if e.LoanID != internal.ZERO {
+						loan = strconv.FormatInt(e.LoanID, 10)
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.CreatedAt.Format(time.RFC3339), e.Kind, e.Reason,
+						loan, e.Amount.StringFixed(2), e.RecordedBy, e.Note)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the JSON responses instead of a table")
+
+	return cmd
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/book"
+	"github.com/spf13/cobra"
+)
+
+// importExtensions maps file extensions to the import format they imply.
+var importExtensions = map[string]string{
+	".csv":    internal.ImportFormatCSV,
+	".ndjson": internal.ImportFormatNDJSON,
+	".jsonl":  internal.ImportFormatNDJSON,
+}
+
+func newImportCmd() *cobra.Command {
+	var opts internal.ImportOptions
+
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Register the books of a CSV or NDJSON file, - for stdin",
+		Long: `Register the books of a CSV or NDJSON file in a single transaction.
+
+A CSV needs a header row with the columns ` + strings.Join(book.CSVColumns, ", ") + `;
+genres and authors are separated by "` + book.CSVListSeparator + `". An NDJSON file holds one book per line in
+the API's JSON format.
+
+By default a single invalid row rolls the whole import back; with --best-effort
+the valid rows are kept. Either way a report of every row is printed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var r io.Reader = cmd.InOrStdin()
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+
+				if opts.Format == internal.EMPTY {
+					opts.Format = importExtensions[strings.ToLower(filepath.Ext(args[0]))]
+				}
+			}
+			if opts.Format == internal.EMPTY {
+				return fmt.Errorf("cannot tell the format of %q, set --format", args[0])
+			}
+
+			var report internal.ImportReport
+			err := runWithBookService(cmd, func(ctx context.Context, service book.IBookService) (any, error) {
+				response, err := service.ImportBooks(ctx, r, opts)
+				report = response.Data
+				return response, err
+			})
+			if err == nil && !report.Committed {
+				err = errors.New("import rolled back")
+			}
+			return err
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Format, "format", "", "csv or ndjson (default: from the file extension)")
+	flags.BoolVar(&opts.BestEffort, "best-effort", false, "keep the valid rows when others fail")
+	flags.IntVar(&opts.BatchSize, "batch-size", internal.DefaultImportBatchSize, "number of books stored per COPY")
+
+	return cmd
+}
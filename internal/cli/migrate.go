@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/amarantec/box/internal/database"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage database schema migrations",
+	}
+
+	cmd.AddCommand(
+		newMigrateUpCmd(),
+		newMigrateDownCmd(),
+		newMigrateStatusCmd(),
+	)
+
+	return cmd
+}
+
+func newMigrateUpCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "up",
+		Short: "Apply all pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			Conn, err := openConnection(ctx, connectTimeout)
+			if err != nil {
+				return err
+			}
+			defer Conn.Close()
+
+			applied, err := database.MigrateUp(ctx, Conn)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%d migration(s) applied.\n", applied)
+			return nil
+		},
+	}
+}
+
+func newMigrateDownCmd() *cobra.Command {
+	var steps int
+
+	cmd := &cobra.Command{
+		Use:   "down",
+		Short: "Roll back applied migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if steps < 1 {
+				return fmt.Errorf("--steps must be at least 1")
+			}
+
+			ctx := cmd.Context()
+
+			Conn, err := openConnection(ctx, connectTimeout)
+			if err != nil {
+				return err
+			}
+			defer Conn.Close()
+
+			rolledBack, err := database.MigrateDown(ctx, Conn, steps)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%d migration(s) rolled back.\n", rolledBack)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&steps, "steps", 1, "number of migrations to roll back")
+
+	return cmd
+}
+
+func newMigrateStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show which migrations are applied",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			Conn, err := openConnection(ctx, connectTimeout)
+			if err != nil {
+				return err
+			}
+			defer Conn.Close()
+
+			status, err := database.MigrationsStatus(ctx, Conn)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+			for _, s := range status {
+				appliedAt := "pending"
+				if s.Applied {
+					appliedAt = s.AppliedAt.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+// runWithRoleService opens a database connection and hands a role service
+// backed by it to fn.
+func runWithRoleService(cmd *cobra.Command, fn func(ctx context.Context, service auth.IRoleService) error) error {
+	ctx := cmd.Context()
+
+	Conn, err := openConnection(ctx, connectTimeout)
+	if err != nil {
+		return err
+	}
+	defer Conn.Close()
+
+	return fn(ctx, auth.NewRoleService(postgresRepositories(Conn).Roles))
+}
+
+func newRolesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "roles",
+		Short: "Manage the roles of the principals behind JWTs and API keys",
+		Long: `Manage the roles of the principals behind JWTs and API keys.
+
+A subject is the sub claim of a JWT, or "key:" followed by the name of an API
+key. Subjects without an assigned role are readers.`,
+	}
+
+	cmd.AddCommand(
+		newRolesAssignCmd(),
+		newRolesListCmd(),
+		newRolesRemoveCmd(),
+	)
+
+	return cmd
+}
+
+func newRolesAssignCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "assign <subject> <reader|librarian|admin>",
+		Short: "Assign a role to a subject, replacing the one it had",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWithRoleService(cmd, func(ctx context.Context, service auth.IRoleService) error {
+				response, err := service.AssignRole(ctx, args[0], internal.Role(args[1]))
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), response.Message)
+				return nil
+			})
+		},
+	}
+}
+
+func newRolesListCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the assigned roles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWithRoleService(cmd, func(ctx context.Context, service auth.IRoleService) error {
+				response, err := service.ListRoleAssignments(ctx)
+				if err != nil {
+					return err
+				}
+				if asJSON {
+					return printJSON(cmd.OutOrStdout(), response)
+				}
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "SUBJECT\tROLE\tASSIGNED\tASSIGNED BY")
+				for _, a := range response.Data {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.Subject, a.Role,
+						a.AssignedAt.Format(time.RFC3339), a.AssignedBy)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the JSON response instead of a table")
+
+	return cmd
+}
+
+func newRolesRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <subject>",
+		Short: "Remove the role of a subject, leaving it a reader",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWithRoleService(cmd, func(ctx context.Context, service auth.IRoleService) error {
+				response, err := service.RemoveRoleAssignment(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), response.Message)
+				return nil
+			})
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/auth"
+	"github.com/amarantec/box/internal/book"
+	"github.com/amarantec/box/internal/circulation"
+	"github.com/amarantec/box/internal/database"
+	"github.com/amarantec/box/internal/handler/routes"
+	"github.com/amarantec/box/internal/inventory"
+	"github.com/amarantec/box/internal/ledger"
+	"github.com/amarantec/box/internal/member"
+	"github.com/amarantec/box/internal/reference"
+	"github.com/amarantec/box/internal/utils"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+type rootOptions struct {
+	envFile string
+}
+
+func NewRootCmd() *cobra.Command {
+	opts := &rootOptions{}
+
+	cmd := &cobra.Command{
+		Use:          "box",
+		Short:        "Box book catalog server and admin tool",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Book changes made by admin commands are recorded in the
+			// history as done by cliActor; HTTP requests carry their own.
+			cmd.SetContext(internal.WithActor(cmd.Context(), cliActor))
+
+			if opts.envFile == "" {
+				utils.LoadEnv()
+				return nil
+			}
+			return utils.LoadEnvFile(opts.envFile)
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&opts.envFile, "env-file", "",
+		"path to the .env file (default: search from the working directory)")
+
+	cmd.AddCommand(
+		newServeCmd(),
+		newMigrateCmd(),
+		newSeedCmd(),
+		newBooksCmd(),
+		newImportCmd(),
+		newExportCmd(),
+		newFinesCmd(),
+		newAPIKeysCmd(),
+		newRolesCmd(),
+	)
+
+	return cmd
+}
+
+func Execute() error {
+	return NewRootCmd().Execute()
+}
+
+const cliActor = "cli"
+
+// connectTimeout bounds how long admin commands wait for the database.
+const connectTimeout = 10 * time.Second
+
+func openConnection(ctx context.Context, timeout time.Duration) (*pgxpool.Pool, error) {
+	dbConfig, err := utils.BuildDBConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return database.OpenConnection(ctxTimeout, dbConfig)
+}
+
+func postgresRepositories(conn *pgxpool.Pool) routes.Repositories {
+	return routes.Repositories{
+		Books:      book.NewBookRepository(conn),
+		Authors:    reference.NewRepository(conn, reference.Authors),
+		Genres:     reference.NewRepository(conn, reference.Genres),
+		Publishers: reference.NewRepository(conn, reference.Publishers),
+		Copies:     inventory.NewCopyRepository(conn),
+		Members:    member.NewMemberRepository(conn),
+		Loans:      circulation.NewLoanRepository(conn),
+		Holds:      circulation.NewHoldRepository(conn),
+		Ledger:     ledger.NewLedgerRepository(conn),
+		APIKeys:    auth.NewAPIKeyRepository(conn),
+		Roles:      auth.NewRoleRepository(conn),
+	}
+}
+
+func newBookService(repos routes.Repositories) book.IBookService {
+	return book.NewBookService(repos.Books, repos.Copies)
+}
+
+func newHoldService(repos routes.Repositories, policy internal.HoldPolicy) circulation.IHoldService {
+	return circulation.NewHoldService(repos.Holds, repos.Members, repos.Books, repos.Copies, policy)
+}
+
+func newLedgerService(repos routes.Repositories) ledger.ILedgerService {
+	return ledger.NewLedgerService(repos.Ledger, repos.Members)
+}
+
+func printJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
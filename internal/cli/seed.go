@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/book"
+	"github.com/spf13/cobra"
+)
+
+func seedBooks() []internal.Book {
+	return []internal.Book{
+		{
+			Title:       "The Pragmatic Programmer",
+			Description: "From journeyman to master.",
+			Genres:      []internal.Genre{{Name: "Technology"}},
+			Authors:     []internal.Author{{Name: "Andrew Hunt"}, {Name: "David Thomas"}},
+			PublishDate: time.Date(1999, time.October, 20, 0, 0, 0, 0, time.UTC),
+			Publisher:   internal.Publisher{Name: "Addison-Wesley"},
+			Pages:       352,
+		},
+		{
+			Title:       "The Go Programming Language",
+			Description: "The authoritative resource to writing clear and idiomatic Go.",
+			Genres:      []internal.Genre{{Name: "Technology"}},
+			Authors:     []internal.Author{{Name: "Alan A. A. Donovan"}, {Name: "Brian W. Kernighan"}},
+			PublishDate: time.Date(2015, time.October, 26, 0, 0, 0, 0, time.UTC),
+			Publisher:   internal.Publisher{Name: "Addison-Wesley"},
+			Pages:       380,
+		},
+		{
+			Title:       "Dom Casmurro",
+			Description: "Bento Santiago recalls his life and his jealousy of Capitu.",
+			Genres:      []internal.Genre{{Name: "Novel"}},
+			Authors:     []internal.Author{{Name: "Machado de Assis"}},
+			PublishDate: time.Date(1899, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Publisher:   internal.Publisher{Name: "Livraria Garnier"},
+			Pages:       256,
+		},
+	}
+}
+
+func newSeedCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "seed",
+		Short: "Register a set of sample books",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			Conn, err := openConnection(ctx, connectTimeout)
+			if err != nil {
+				return err
+			}
+			defer Conn.Close()
+
+			return seed(ctx, cmd, newBookService(postgresRepositories(Conn)))
+		},
+	}
+}
+
+func seed(ctx context.Context, cmd *cobra.Command, service book.IBookService) error {
+	for _, b := range seedBooks() {
+		response, err := service.RegisterBook(ctx, b)
+		if err != nil {
+			return fmt.Errorf("seed %q: %w", b.Title, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "registered %q with id %d\n", b.Title, response.Data)
+	}
+	return nil
+}
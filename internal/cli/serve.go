@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/auth"
+	"github.com/amarantec/box/internal/book"
+	"github.com/amarantec/box/internal/circulation"
+	"github.com/amarantec/box/internal/database"
+	"github.com/amarantec/box/internal/handler/routes"
+	"github.com/amarantec/box/internal/health"
+	"github.com/amarantec/box/internal/ledger"
+	"github.com/amarantec/box/internal/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+type serveOptions struct {
+	addr              string
+	connectTimeout    time.Duration
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	requestTimeout    time.Duration
+	bulkTimeout       time.Duration
+	shutdownTimeout   time.Duration
+	migrateTimeout    time.Duration
+	readyTimeout      time.Duration
+	requireIfMatch    bool
+	trashRetention    time.Duration
+	trashPurgeEvery   time.Duration
+	migrate           bool
+	storage           string
+	loanPolicies      string
+	fineRules         string
+	holdMaxWaitDays   int
+	holdPickupDays    int
+	holdExpiryEvery   time.Duration
+	auth              bool
+	jwtPublicKey      string
+	jwtIssuer         string
+	jwtAudience       string
+	admins            []string
+}
+
+const (
+	storagePostgres = "postgres"
+	storageMemory   = "memory"
+)
+
+func newServeCmd() *cobra.Command {
+	opts := &serveOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Start the HTTP API server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(cmd.Context(), opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.addr, "addr", ":8080", "address the server listens on")
+	flags.DurationVar(&opts.connectTimeout, "connect-timeout", 10*time.Second, "how long to wait for the database on startup")
+	flags.DurationVar(&opts.readTimeout, "read-timeout", 15*time.Second, "maximum duration for reading a request")
+	flags.DurationVar(&opts.readHeaderTimeout, "read-header-timeout", 5*time.Second, "maximum duration for reading request headers")
+	flags.DurationVar(&opts.writeTimeout, "write-timeout", 30*time.Second, "maximum duration before timing out writes of the response")
+	flags.DurationVar(&opts.idleTimeout, "idle-timeout", 60*time.Second, "maximum time to wait for the next request on keep-alive connections")
+	flags.DurationVar(&opts.requestTimeout, "request-timeout", 10*time.Second, "maximum time a handler may spend on a request, including database calls")
+	flags.DurationVar(&opts.bulkTimeout, "bulk-timeout", 10*time.Minute, "maximum time a book import or export may take, in place of --request-timeout, --read-timeout and --write-timeout")
+	flags.DurationVar(&opts.shutdownTimeout, "shutdown-timeout", 20*time.Second, "how long to wait for in-flight requests to finish on SIGINT or SIGTERM")
+	flags.DurationVar(&opts.migrateTimeout, "migrate-timeout", 60*time.Second, "maximum duration for applying migrations on startup")
+	flags.DurationVar(&opts.readyTimeout, "ready-timeout", 2*time.Second, "deadline for the database checks behind /readyz")
+	flags.BoolVar(&opts.requireIfMatch, "require-if-match", false, "refuse book updates and deletes without an If-Match header (428)")
+	flags.DurationVar(&opts.trashRetention, "trash-retention", 0, "purge deleted books after this long, e.g. 720h; 0 keeps them forever")
+	flags.DurationVar(&opts.trashPurgeEvery, "trash-purge-interval", time.Hour, "how often to look for deleted books past --trash-retention")
+	flags.BoolVar(&opts.migrate, "migrate", true, "apply pending migrations before serving")
+	flags.StringVar(&opts.storage, "storage", storagePostgres, "where books are stored: postgres or memory (data is lost on exit)")
+	flags.StringVar(&opts.loanPolicies, "loan-policies", "", "JSON file of loan policies per member type, replacing the defaults of the types it lists")
+	flags.StringVar(&opts.fineRules, "fine-rules", "", "JSON file of fine rules per item category, replacing the defaults of the categories it lists")
+	flags.IntVar(&opts.holdMaxWaitDays, "hold-max-wait-days", circulation.DefaultHoldPolicy().MaxWaitDays, "days a hold stays in the queue before it expires")
+	flags.IntVar(&opts.holdPickupDays, "hold-pickup-days", circulation.DefaultHoldPolicy().PickupDays, "days a copy set aside for a hold is kept for the member")
+	flags.DurationVar(&opts.holdExpiryEvery, "hold-expiry-interval", time.Hour, "how often to look for holds past their expiry day")
+	flags.BoolVar(&opts.auth, "auth", true, "require a JWT or API key on every route but the health checks")
+	flags.StringVar(&opts.jwtPublicKey, "jwt-public-key", "", "PEM file of the RSA public key RS256, RS384 and RS512 tokens are checked against")
+	flags.StringVar(&opts.jwtIssuer, "jwt-issuer", "", "iss claim JWTs must carry")
+	flags.StringVar(&opts.jwtAudience, "jwt-audience", "", "aud claim JWTs must carry")
+	flags.StringSliceVar(&opts.admins, "admin", nil, "JWT subject, or \"key:\" and an API key name, that is an admin whatever its assigned role (repeatable)")
+
+	return cmd
+}
+
+func runServe(ctx context.Context, opts *serveOptions) error {
+	if opts.trashRetention < 0 || opts.trashPurgeEvery <= 0 {
+		return errors.New("--trash-retention must not be negative and --trash-purge-interval must be positive")
+	}
+	if opts.holdMaxWaitDays < 1 || opts.holdPickupDays < 1 || opts.holdExpiryEvery <= 0 {
+		return errors.New("--hold-max-wait-days, --hold-pickup-days and --hold-expiry-interval must be positive")
+	}
+	holdPolicy := internal.HoldPolicy{MaxWaitDays: opts.holdMaxWaitDays, PickupDays: opts.holdPickupDays}
+
+	var loanPolicies []internal.LoanPolicy
+	if opts.loanPolicies != "" {
+		var err error
+		if loanPolicies, err = circulation.LoadLoanPolicies(opts.loanPolicies); err != nil {
+			return err
+		}
+	}
+
+	var fineRules []internal.FineRule
+	if opts.fineRules != "" {
+		var err error
+		if fineRules, err = ledger.LoadFineRules(opts.fineRules); err != nil {
+			return err
+		}
+	}
+
+	var repos routes.Repositories
+	var Conn *pgxpool.Pool
+
+	switch opts.storage {
+	case storageMemory:
+		log.Println("using in-memory storage, data will be lost when the server stops")
+		repos = routes.NewMemoryRepositories()
+	case storagePostgres:
+		var err error
+		Conn, err = openConnection(ctx, opts.connectTimeout)
+		if err != nil {
+			return err
+		}
+		defer Conn.Close()
+
+		if opts.migrate {
+			migrateCtx, cancelMigrate := context.WithTimeout(ctx, opts.migrateTimeout)
+			defer cancelMigrate()
+
+			if _, err := database.MigrateUp(migrateCtx, Conn); err != nil {
+				return err
+			}
+		}
+
+		repos = postgresRepositories(Conn)
+	default:
+		return fmt.Errorf("unknown --storage %q, want %q or %q", opts.storage, storagePostgres, storageMemory)
+	}
+
+	var authenticator *auth.Authenticator
+	if opts.auth {
+		var err error
+		if authenticator, err = newAuthenticator(opts, repos); err != nil {
+			return err
+		}
+	} else {
+		log.Println("authentication is off, every route is open")
+	}
+
+	mux := routes.Router(repos, routes.Config{
+		Health:         health.NewChecker(Conn, opts.readyTimeout),
+		Authenticator:  authenticator,
+		RequireIfMatch: opts.requireIfMatch,
+		BulkTimeout:    opts.bulkTimeout,
+		LoanPolicies:   loanPolicies,
+		HoldPolicy:     holdPolicy,
+		FineRules:      fineRules,
+	})
+	handler := middleware.LoggerMiddleware(
+		middleware.RequestIDMiddleware(
+			middleware.TimeoutMiddleware(mux, opts.requestTimeout)))
+
+	server := &http.Server{
+		Addr:              opts.addr,
+		Handler:           handler,
+		ReadTimeout:       opts.readTimeout,
+		ReadHeaderTimeout: opts.readHeaderTimeout,
+		WriteTimeout:      opts.writeTimeout,
+		IdleTimeout:       opts.idleTimeout,
+	}
+
+	if opts.trashRetention > 0 {
+		stopRetention := startTrashRetention(ctx, newBookService(repos), opts.trashRetention, opts.trashPurgeEvery)
+		defer stopRetention()
+	}
+
+	stopHoldExpiry := startHoldExpiry(ctx, newHoldService(repos, holdPolicy), opts.holdExpiryEvery)
+	defer stopHoldExpiry()
+
+	return listenAndServe(ctx, server, opts.shutdownTimeout)
+}
+
+// jwtSecretEnv names the environment variable holding the secret HS256,
+// HS384 and HS512 tokens are checked against. It is not a flag so that it
+// stays out of process listings.
+const jwtSecretEnv = "JWT_HMAC_SECRET"
+
+// minJWTSecretLength is the shortest HMAC secret accepted, in bytes.
+const minJWTSecretLength = 32
+
+// newAuthenticator checks JWTs against the keys given by opts and the
+// environment, and API keys against repos. The --admin principals are admins
+// whatever their assigned role, so that the first roles can be assigned.
+func newAuthenticator(opts *serveOptions, repos routes.Repositories) (*auth.Authenticator, error) {
+	cfg := auth.JWTConfig{
+		HMACSecret: []byte(os.Getenv(jwtSecretEnv)),
+		Issuer:     opts.jwtIssuer,
+		Audience:   opts.jwtAudience,
+	}
+	if n := len(cfg.HMACSecret); n > 0 && n < minJWTSecretLength {
+		return nil, fmt.Errorf("%s must be at least %d bytes long", jwtSecretEnv, minJWTSecretLength)
+	}
+
+	if opts.jwtPublicKey != "" {
+		data, err := os.ReadFile(opts.jwtPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.RSAPublicKey, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+			return nil, fmt.Errorf("--jwt-public-key %s: %w", opts.jwtPublicKey, err)
+		}
+	}
+
+	authenticator := auth.NewAuthenticator(repos.APIKeys, repos.Roles, opts.admins, cfg)
+	if !authenticator.AcceptsJWT() {
+		if opts.storage == storageMemory {
+			return nil, fmt.Errorf("--storage=%s keeps no API keys: set %s or --jwt-public-key, or turn --auth off", storageMemory, jwtSecretEnv)
+		}
+		log.Printf("neither %s nor --jwt-public-key is set, only API keys are accepted", jwtSecretEnv)
+	}
+	return authenticator, nil
+}
+
+// startTrashRetention runs book.RunTrashRetention in the background. The
+// returned function stops it and waits for an ongoing purge to finish, so it
+// must be called before the database pool is closed.
+func startTrashRetention(ctx context.Context, service book.IBookService, retention, interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		book.RunTrashRetention(ctx, service, retention, interval)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// startHoldExpiry runs circulation.RunHoldExpiry in the background. Like
+// startTrashRetention, the returned function must be called before the
+// database pool is closed.
+func startHoldExpiry(ctx context.Context, service circulation.IHoldService, interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		circulation.RunHoldExpiry(ctx, service, interval)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// listenAndServe runs server until it fails or the process receives SIGINT or
+// SIGTERM. On a signal it stops accepting connections and waits up to
+// shutdownTimeout for in-flight requests before closing them. It returns only
+// once the server has stopped, so callers can then release the database pool.
+func listenAndServe(ctx context.Context, server *http.Server, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		fmt.Printf("Server listen on: http://localhost%s\n", server.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Printf("shutting down, waiting up to %s for in-flight requests", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	log.Println("server stopped")
+	return nil
+}
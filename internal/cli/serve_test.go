@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestListenAndServeGracefulShutdown(t *testing.T) {
+	addr := freeAddr(t)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	server := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusOK)
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	served := make(chan error, 1)
+	go func() { served <- listenAndServe(ctx, server, 5*time.Second) }()
+
+	status := make(chan int, 1)
+	go func() {
+		for {
+			resp, err := http.Get("http://" + addr)
+			if err == nil {
+				resp.Body.Close()
+				status <- resp.StatusCode
+				return
+			}
+			select {
+			case <-started:
+				// The request got through but its connection failed.
+				status <- 0
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the handler")
+	}
+
+	cancel()
+	select {
+	case err := <-served:
+		t.Fatalf("listenAndServe returned %v with a request in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if got := <-status; got != http.StatusOK {
+		t.Fatalf("in-flight request answered %d, want 200", got)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("listenAndServe = %v, want nil", err)
+	}
+
+	if _, err := http.Get("http://" + addr); err == nil {
+		t.Fatal("server still accepts connections after shutdown")
+	}
+}
+
+func TestListenAndServeShutdownTimeout(t *testing.T) {
+	addr := freeAddr(t)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	server := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	served := make(chan error, 1)
+	go func() { served <- listenAndServe(ctx, server, 50*time.Millisecond) }()
+	go func() {
+		for {
+			if resp, err := http.Get("http://" + addr); err == nil {
+				resp.Body.Close()
+				return
+			}
+			select {
+			case <-started:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	<-started
+	cancel()
+	if err := <-served; err == nil {
+		t.Fatal("listenAndServe = nil, want an error once the shutdown timeout passed")
+	}
+}
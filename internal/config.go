@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// ConfigList describes a configuration file holding a JSON array of T, at
+// most one for each of Keys. Name, Key and Entry name the file, the key and
+// an element in errors, as in
+//
+//	loan policies policies.json: member type "staff" has more than one policy
+type ConfigList[T interface{ Validate() error }, K ~string] struct {
+	Name  string
+	Key   string
+	Entry string
+	Keys  []K
+	KeyOf func(T) K
+}
+
+// Load reads the list from path and checks each of its elements.
+func (c ConfigList[T, K]) Load(path string) ([]T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []T
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s %s: %w", c.Name, path, err)
+	}
+
+	seen := map[K]bool{}
+	for _, v := range list {
+		key := c.KeyOf(v)
+		if !slices.Contains(c.Keys, key) {
+			return nil, fmt.Errorf("%s %s: unknown %s %q", c.Name, path, c.Key, key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%s %s: %s %q has more than one %s", c.Name, path, c.Key, key, c.Entry)
+		}
+		seen[key] = true
+
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", c.Name, path, err)
+		}
+	}
+
+	return list, nil
+}
@@ -0,0 +1,68 @@
+package internal
+
+import "context"
+
+// AnonymousActor is recorded when a change is made without an identified
+// actor.
+const AnonymousActor = "anonymous"
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestIDKey
+	principalKey
+	principalHolderKey
+)
+
+// WithActor returns a context recording who is making the changes done with
+// it, such as a user name or "cli".
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != EMPTY {
+		return actor
+	}
+	return AnonymousActor
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithPrincipal returns a context carrying the authenticated client of a
+// request. It also records the principal as the actor of the changes made
+// with the context.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return WithActor(context.WithValue(ctx, principalKey, p), p.Actor())
+}
+
+// PrincipalFromContext returns the authenticated client of a request, and
+// false when the request was not authenticated.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	return p, ok
+}
+
+// WithPrincipalHolder returns a context in which HoldPrincipal records the
+// principal a request is authenticated as, and the holder it is recorded
+// in. It lets code wrapping the authentication, such as the request logger,
+// learn who made a request once it has been served.
+func WithPrincipalHolder(ctx context.Context) (context.Context, *Principal) {
+	holder := &Principal{}
+	return context.WithValue(ctx, principalHolderKey, holder), holder
+}
+
+// HoldPrincipal records p in the holder of ctx, if it has one.
+func HoldPrincipal(ctx context.Context, p Principal) {
+	if holder, ok := ctx.Value(principalHolderKey).(*Principal); ok {
+		*holder = p
+	}
+}
@@ -0,0 +1,82 @@
+// Package databasetest connects tests to the PostgreSQL database named by
+// BOX_TEST_DATABASE_URL. Every test package works in a schema of its own, so
+// that the packages "go test ./..." runs in parallel do not see each other's
+// rows.
+package databasetest
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// URLEnv names the environment variable pointing at a disposable database.
+const URLEnv = "BOX_TEST_DATABASE_URL"
+
+// Open returns a pool whose tables live in schema, created and migrated when
+// needed. It skips t unless URLEnv is set. The tables keep the rows of
+// earlier tests; see Truncate.
+func Open(t *testing.T, schema string) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := os.Getenv(URLEnv)
+	if dsn == "" {
+		t.Skip(URLEnv + " is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse %s: %v", URLEnv, err)
+	}
+	// Names are looked up in schema alone, so the migrations create their
+	// tables there.
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+
+	conn, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	if _, err := conn.Exec(ctx, `CREATE SCHEMA IF NOT EXISTS `+pgx.Identifier{schema}.Sanitize()+`;`); err != nil {
+		t.Fatalf("create schema %s: %v", schema, err)
+	}
+	if _, err := database.MigrateUp(ctx, conn); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	return conn
+}
+
+// Truncate empties every table of the schema conn works in, but for the
+// migrations table, and restarts their sequences.
+func Truncate(t *testing.T, conn *pgxpool.Pool) {
+	t.Helper()
+	ctx := context.Background()
+
+	rows, err := conn.Query(ctx,
+		`SELECT tablename FROM pg_tables WHERE schemaname = current_schema() AND tablename <> 'schema_migrations';`)
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+
+	identifiers := make([]string, 0, len(tables))
+	for _, table := range tables {
+		identifiers = append(identifiers, pgx.Identifier{table}.Sanitize())
+	}
+	if _, err := conn.Exec(ctx, `TRUNCATE `+strings.Join(identifiers, ", ")+` RESTART IDENTITY CASCADE;`); err != nil {
+		t.Fatalf("truncate tables: %v", err)
+	}
+}
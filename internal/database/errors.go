@@ -0,0 +1,65 @@
+package database
+
+import (
+	"errors"
+	"net"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+
+	// Classes of SQLSTATE codes, their first two characters.
+	dataException                = "22"
+	integrityConstraintViolation = "23"
+	programLimitExceeded         = "54"
+)
+
+func hasCode(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// IsUniqueViolation reports whether err was raised by a unique constraint.
+func IsUniqueViolation(err error) bool {
+	return hasCode(err, uniqueViolation)
+}
+
+// IsUniqueViolationOf reports whether err was raised by the named unique
+// constraint or index.
+func IsUniqueViolationOf(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == constraint
+}
+
+// IsForeignKeyViolation reports whether err was raised by a foreign key,
+// such as deleting a row that is still referenced.
+func IsForeignKeyViolation(err error) bool {
+	return hasCode(err, foreignKeyViolation)
+}
+
+// IsUnavailable reports whether err means the database could not be reached,
+// as opposed to a query that was rejected.
+func IsUnavailable(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) || errors.As(err, &netErr) || pgconn.SafeToRetry(err)
+}
+
+// IsRejectedRow reports whether err was raised by the data of a statement
+// rather than by the statement or the connection: a data exception such as a
+// value out of range, an integrity constraint violation or a value beyond a
+// limit such as the size of an index row. Another row may well succeed.
+func IsRejectedRow(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || len(pgErr.Code) != 5 {
+		return false
+	}
+	switch pgErr.Code[:2] {
+	case dataException, integrityConstraintViolation, programLimitExceeded:
+		return true
+	}
+	return false
+}
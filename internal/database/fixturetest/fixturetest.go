@@ -0,0 +1,170 @@
+// Package fixturetest holds the repositories and fixtures the inventory,
+// member, circulation and ledger tests share: books, copies, members and
+// loans stored in memory or in the PostgreSQL database of databasetest.
+package fixturetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/book"
+	"github.com/amarantec/box/internal/circulation"
+	"github.com/amarantec/box/internal/database/databasetest"
+	"github.com/amarantec/box/internal/inventory"
+	"github.com/amarantec/box/internal/ledger"
+	"github.com/amarantec/box/internal/member"
+	"github.com/amarantec/box/internal/reference"
+	"github.com/shopspring/decimal"
+)
+
+// Repositories hold the books, copies, members, loans, holds and accounts of
+// one library, all kept in memory or all in the same schema.
+type Repositories struct {
+	Books   book.IBookRepository
+	Copies  inventory.ICopyRepository
+	Members member.IMemberRepository
+	Loans   circulation.ILoanRepository
+	Holds   circulation.IHoldRepository
+	Ledger  ledger.ILedgerRepository
+}
+
+// Run runs test against empty in-memory repositories and, when
+// BOX_TEST_DATABASE_URL is set, against empty PostgreSQL ones in schema.
+func Run(t *testing.T, schema string, test func(t *testing.T, r Repositories)) {
+	t.Run("Memory", func(t *testing.T) {
+		copies := inventory.NewMemoryCopyRepository()
+		holds := circulation.NewMemoryHoldRepository(copies)
+		members := member.NewMemoryMemberRepository()
+		accounts := ledger.NewMemoryLedgerRepository(members)
+		test(t, Repositories{
+			Books: book.NewMemoryBookRepository(
+				reference.NewMemoryRepository(reference.Authors),
+				reference.NewMemoryRepository(reference.Genres),
+				reference.NewMemoryRepository(reference.Publishers),
+				copies,
+			),
+			Copies:  copies,
+			Members: members,
+			Loans:   circulation.NewMemoryLoanRepository(members, copies, holds, accounts),
+			Holds:   holds,
+			Ledger:  accounts,
+		})
+	})
+	t.Run("PostgreSQL", func(t *testing.T) {
+		conn := databasetest.Open(t, schema)
+		databasetest.Truncate(t, conn)
+		test(t, Repositories{
+			Books:   book.NewBookRepository(conn),
+			Copies:  inventory.NewCopyRepository(conn),
+			Members: member.NewMemberRepository(conn),
+			Loans:   circulation.NewLoanRepository(conn),
+			Holds:   circulation.NewHoldRepository(conn),
+			Ledger:  ledger.NewLedgerRepository(conn),
+		})
+	})
+}
+
+// HoldPolicy is the hold policy the tests allocate copies under.
+var HoldPolicy = internal.HoldPolicy{MaxWaitDays: 30, PickupDays: 7}
+
+// FineRules are the fine rules the tests charge returns under. General
+// copies have two days of grace; short-loan copies have none.
+var FineRules = internal.FineRules{
+	internal.CategoryGeneral: {
+		Category:  internal.CategoryGeneral,
+		DailyRate: decimal.RequireFromString("0.25"),
+		GraceDays: 2,
+		MaxFine:   decimal.RequireFromString("10.00"),
+		LostFee:   decimal.RequireFromString("5.00"),
+	},
+	internal.CategoryShortLoan: {
+		Category:  internal.CategoryShortLoan,
+		DailyRate: decimal.RequireFromString("1.00"),
+		MaxFine:   decimal.RequireFromString("3.00"),
+		LostFee:   decimal.RequireFromString("2.50"),
+	},
+}
+
+// Date is midnight UTC of the given day.
+func Date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// RegisterBook stores a book called title and returns its ID.
+func RegisterBook(t *testing.T, r Repositories, title string) int64 {
+	t.Helper()
+
+	id, err := r.Books.RegisterBook(context.Background(), internal.Book{
+		Title:       title,
+		Description: "A book called " + title + ".",
+		Genres:      []internal.Genre{{Name: "Fiction"}},
+		Authors:     []internal.Author{{Name: "Jane Doe"}},
+		PublishDate: Date(2001, time.March, 4),
+		Publisher:   internal.Publisher{Name: "Acme"},
+		Pages:       100,
+	})
+	if err != nil {
+		t.Fatalf("RegisterBook(%q): %v", title, err)
+	}
+	return id
+}
+
+// NewCopy is a general copy of the book in good condition.
+func NewCopy(bookId int64, barcode string) internal.BookCopy {
+	return internal.BookCopy{
+		BookID:     bookId,
+		Barcode:    barcode,
+		Condition:  internal.ConditionGood,
+		Category:   internal.CategoryGeneral,
+		AcquiredOn: Date(2020, time.May, 6),
+		Price:      decimal.RequireFromString("24.90"),
+	}
+}
+
+// NewMember is an active standard member whose card expires in 2030.
+func NewMember(name, cardNumber string) internal.Member {
+	return internal.Member{
+		Name:       name,
+		CardNumber: cardNumber,
+		Type:       internal.MemberStandard,
+		Status:     internal.MemberActive,
+		ExpiresOn:  Date(2030, time.December, 31),
+	}
+}
+
+// RegisterMember stores a member holding cardNumber and returns its ID.
+func RegisterMember(t *testing.T, r Repositories, cardNumber string) int64 {
+	t.Helper()
+
+	memberId, err := r.Members.RegisterMember(context.Background(), NewMember("Member "+cardNumber, cardNumber))
+	if err != nil {
+		t.Fatalf("RegisterMember(%s): %v", cardNumber, err)
+	}
+	return memberId
+}
+
+// NewLoan is a loan of the copy to the member, due two weeks after it is
+// checked out.
+func NewLoan(copyId, memberId int64, checkedOutAt time.Time) internal.Loan {
+	return internal.Loan{
+		CopyID:       copyId,
+		MemberID:     memberId,
+		CheckedOutAt: checkedOutAt,
+		DueDate:      internal.Day(checkedOutAt).AddDate(0, 0, 14),
+	}
+}
+
+// AssertCopyStatus fails t unless the copy is in status.
+func AssertCopyStatus(t *testing.T, r Repositories, copyId int64, status internal.CopyStatus) {
+	t.Helper()
+
+	c, err := r.Copies.GetCopyById(context.Background(), copyId)
+	if err != nil {
+		t.Fatalf("GetCopyById(%d): %v", copyId, err)
+	}
+	if c.Status != status {
+		t.Fatalf("copy %d is %s, want %s", copyId, c.Status, status)
+	}
+}
@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS books_search_vector_trigger ON books;
+
+ALTER TABLE books
+    ALTER COLUMN title TYPE CHAR(250),
+    ALTER COLUMN genre TYPE CHAR(250)[],
+    ALTER COLUMN author TYPE CHAR(250)[],
+    ALTER COLUMN publisher TYPE CHAR(250);
+
+CREATE TRIGGER books_search_vector_trigger
+    BEFORE INSERT OR UPDATE OF title, author, description ON books
+    FOR EACH ROW EXECUTE FUNCTION books_search_vector_update();
@@ -0,0 +1,13 @@
+-- CHAR(250) pads values with spaces, so titles and names came back padded
+-- and never compared equal to what clients sent.
+DROP TRIGGER IF EXISTS books_search_vector_trigger ON books;
+
+ALTER TABLE books
+    ALTER COLUMN title TYPE VARCHAR(250),
+    ALTER COLUMN genre TYPE VARCHAR(250)[],
+    ALTER COLUMN author TYPE VARCHAR(250)[],
+    ALTER COLUMN publisher TYPE VARCHAR(250);
+
+CREATE TRIGGER books_search_vector_trigger
+    BEFORE INSERT OR UPDATE OF title, author, description ON books
+    FOR EACH ROW EXECUTE FUNCTION books_search_vector_update();
@@ -0,0 +1,54 @@
+ALTER TABLE books
+    ADD COLUMN genre VARCHAR(250)[] NOT NULL DEFAULT '{}',
+    ADD COLUMN author VARCHAR(250)[] NOT NULL DEFAULT '{}',
+    ADD COLUMN publisher VARCHAR(250) NOT NULL DEFAULT '';
+
+UPDATE books b SET
+    genre = COALESCE((
+        SELECT array_agg(g.name ORDER BY bg.position)
+            FROM book_genres bg JOIN genres g ON g.id = bg.genre_id
+            WHERE bg.book_id = b.id), '{}'),
+    author = COALESCE((
+        SELECT array_agg(a.name ORDER BY ba.position)
+            FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = b.id), '{}'),
+    publisher = (SELECT p.name FROM publishers p WHERE p.id = b.publisher_id);
+
+ALTER TABLE books
+    ALTER COLUMN genre DROP DEFAULT,
+    ALTER COLUMN author DROP DEFAULT,
+    ALTER COLUMN publisher DROP DEFAULT;
+
+DROP TRIGGER IF EXISTS authors_search_vector_trigger ON authors;
+DROP FUNCTION IF EXISTS authors_search_vector_update();
+DROP TRIGGER IF EXISTS book_authors_search_vector_trigger ON book_authors;
+DROP FUNCTION IF EXISTS book_authors_search_vector_update();
+DROP TRIGGER IF EXISTS books_search_vector_trigger ON books;
+
+CREATE OR REPLACE FUNCTION books_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(array_to_string(NEW.author, ' '), '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(NEW.description, '')), 'C');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER books_search_vector_trigger
+    BEFORE INSERT OR UPDATE OF title, author, description ON books
+    FOR EACH ROW EXECUTE FUNCTION books_search_vector_update();
+
+UPDATE books SET search_vector =
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(array_to_string(author, ' '), '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'C');
+
+DROP FUNCTION IF EXISTS book_search_vector(INTEGER, TEXT, TEXT);
+
+ALTER TABLE books DROP COLUMN publisher_id;
+DROP TABLE IF EXISTS book_genres;
+DROP TABLE IF EXISTS book_authors;
+DROP TABLE IF EXISTS publishers;
+DROP TABLE IF EXISTS genres;
+DROP TABLE IF EXISTS authors;
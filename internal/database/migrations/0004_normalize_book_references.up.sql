@@ -0,0 +1,146 @@
+CREATE TABLE IF NOT EXISTS authors (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(250) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS authors_name_key ON authors (lower(name));
+
+CREATE TABLE IF NOT EXISTS genres (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(250) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS genres_name_key ON genres (lower(name));
+
+CREATE TABLE IF NOT EXISTS publishers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(250) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS publishers_name_key ON publishers (lower(name));
+
+CREATE TABLE IF NOT EXISTS book_authors (
+    book_id INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    author_id INTEGER NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, author_id)
+);
+CREATE INDEX IF NOT EXISTS book_authors_author_id_idx ON book_authors (author_id);
+
+CREATE TABLE IF NOT EXISTS book_genres (
+    book_id INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    genre_id INTEGER NOT NULL REFERENCES genres (id) ON DELETE RESTRICT,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, genre_id)
+);
+CREATE INDEX IF NOT EXISTS book_genres_genre_id_idx ON book_genres (genre_id);
+
+-- Move the existing array and text values into the new tables, merging
+-- names that only differ by case or surrounding spaces.
+INSERT INTO authors (name)
+SELECT DISTINCT ON (lower(btrim(name))) btrim(name)
+    FROM books CROSS JOIN LATERAL unnest(author) AS name
+    WHERE btrim(name) <> ''
+ON CONFLICT DO NOTHING;
+
+INSERT INTO book_authors (book_id, author_id, position)
+SELECT b.id, a.id, MIN(x.ord)
+    FROM books b
+    CROSS JOIN LATERAL unnest(b.author) WITH ORDINALITY AS x (name, ord)
+    JOIN authors a ON lower(a.name) = lower(btrim(x.name))
+    GROUP BY b.id, a.id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO genres (name)
+SELECT DISTINCT ON (lower(btrim(name))) btrim(name)
+    FROM books CROSS JOIN LATERAL unnest(genre) AS name
+    WHERE btrim(name) <> ''
+ON CONFLICT DO NOTHING;
+
+INSERT INTO book_genres (book_id, genre_id, position)
+SELECT b.id, g.id, MIN(x.ord)
+    FROM books b
+    CROSS JOIN LATERAL unnest(b.genre) WITH ORDINALITY AS x (name, ord)
+    JOIN genres g ON lower(g.name) = lower(btrim(x.name))
+    GROUP BY b.id, g.id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO publishers (name)
+SELECT DISTINCT ON (lower(name)) name
+    FROM (SELECT COALESCE(NULLIF(btrim(publisher), ''), 'Unknown') AS name FROM books) AS p
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS publisher_id INTEGER REFERENCES publishers (id) ON DELETE RESTRICT;
+
+UPDATE books b SET publisher_id = p.id
+    FROM publishers p
+    WHERE lower(p.name) = lower(COALESCE(NULLIF(btrim(b.publisher), ''), 'Unknown'));
+
+ALTER TABLE books ALTER COLUMN publisher_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS books_publisher_id_idx ON books (publisher_id);
+
+-- Authors now live in book_authors, so the search vector is rebuilt whenever
+-- a book, its author list or an author's name changes.
+DROP TRIGGER IF EXISTS books_search_vector_trigger ON books;
+
+CREATE OR REPLACE FUNCTION book_search_vector(p_book_id INTEGER, p_title TEXT, p_description TEXT) RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('english', coalesce(p_title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce((
+            SELECT string_agg(a.name, ' ')
+                FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+                WHERE ba.book_id = p_book_id), '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(p_description, '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION books_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := book_search_vector(NEW.id, NEW.title, NEW.description);
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER books_search_vector_trigger
+    BEFORE INSERT OR UPDATE OF title, description ON books
+    FOR EACH ROW EXECUTE FUNCTION books_search_vector_update();
+
+CREATE OR REPLACE FUNCTION book_authors_search_vector_update() RETURNS TRIGGER AS $$
+DECLARE
+    changed_book_id INTEGER;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed_book_id := OLD.book_id;
+    ELSE
+        changed_book_id := NEW.book_id;
+    END IF;
+
+    UPDATE books SET search_vector = book_search_vector(id, title, description)
+        WHERE id = changed_book_id;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER book_authors_search_vector_trigger
+    AFTER INSERT OR DELETE ON book_authors
+    FOR EACH ROW EXECUTE FUNCTION book_authors_search_vector_update();
+
+CREATE OR REPLACE FUNCTION authors_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE books SET search_vector = book_search_vector(id, title, description)
+        WHERE id IN (SELECT book_id FROM book_authors WHERE author_id = NEW.id);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER authors_search_vector_trigger
+    AFTER UPDATE OF name ON authors
+    FOR EACH ROW EXECUTE FUNCTION authors_search_vector_update();
+
+UPDATE books SET search_vector = book_search_vector(id, title, description);
+
+ALTER TABLE books
+    DROP COLUMN genre,
+    DROP COLUMN author,
+    DROP COLUMN publisher;
@@ -0,0 +1,3 @@
+-- version is incremented on every write and backs the ETag / If-Match
+-- optimistic concurrency checks.
+ALTER TABLE books ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+DROP TABLE book_revisions;
+DROP FUNCTION book_revisions_append_only();
@@ -0,0 +1,26 @@
+-- book_revisions is the append-only history of every create, update and
+-- delete of a book. book_id has no foreign key so the history outlives the
+-- book itself.
+CREATE TABLE book_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    book_id BIGINT NOT NULL,
+    version BIGINT NOT NULL,
+    action VARCHAR(16) NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    actor VARCHAR(250) NOT NULL,
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    before JSONB NULL,
+    after JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX book_revisions_book_id_idx ON book_revisions (book_id, id);
+
+CREATE FUNCTION book_revisions_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'book_revisions is append-only';
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER book_revisions_append_only_trigger
+    BEFORE UPDATE OR DELETE ON book_revisions
+    FOR EACH STATEMENT EXECUTE FUNCTION book_revisions_append_only();
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS books_deleted_at_idx;
+
+-- The revisions the older schema cannot hold have to go, so the append-only
+-- trigger is lifted while they are removed.
+ALTER TABLE book_revisions DISABLE TRIGGER book_revisions_append_only_trigger;
+DELETE FROM book_revisions WHERE action IN ('restore', 'purge');
+ALTER TABLE book_revisions ENABLE TRIGGER book_revisions_append_only_trigger;
+
+ALTER TABLE book_revisions ALTER COLUMN after SET NOT NULL;
+ALTER TABLE book_revisions DROP CONSTRAINT book_revisions_action_check;
+ALTER TABLE book_revisions ADD CONSTRAINT book_revisions_action_check
+    CHECK (action IN ('create', 'update', 'delete'));
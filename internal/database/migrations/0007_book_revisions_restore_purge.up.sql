@@ -0,0 +1,8 @@
+-- Restoring and purging a soft-deleted book are recorded in its history. A
+-- purge leaves nothing behind, so its revision has no after snapshot.
+ALTER TABLE book_revisions DROP CONSTRAINT book_revisions_action_check;
+ALTER TABLE book_revisions ADD CONSTRAINT book_revisions_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge'));
+ALTER TABLE book_revisions ALTER COLUMN after DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS books_deleted_at_idx ON books (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,7 @@
+-- ISBNs are stored normalized, without hyphens, and are optional. Only live
+-- books must have distinct ISBN-13s, so a book in the trash does not keep
+-- its ISBN from being catalogued again; restoring it then fails instead.
+ALTER TABLE books ADD COLUMN isbn10 VARCHAR(10);
+ALTER TABLE books ADD COLUMN isbn13 VARCHAR(13);
+
+CREATE UNIQUE INDEX books_isbn13_key ON books (isbn13) WHERE deleted_at IS NULL;
@@ -0,0 +1,18 @@
+-- copies are the physical copies of a book. Barcodes stay unique among
+-- withdrawn copies too, since a label is never reprinted. Purging a book
+-- takes its copies with it.
+CREATE TABLE copies (
+    id BIGSERIAL PRIMARY KEY,
+    book_id INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    barcode VARCHAR(64) NOT NULL,
+    condition VARCHAR(16) NOT NULL CHECK (condition IN ('new', 'good', 'fair', 'poor', 'damaged')),
+    acquired_on DATE NOT NULL,
+    price NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (price >= 0),
+    status VARCHAR(16) NOT NULL DEFAULT 'available' CHECK (status IN ('available', 'on-loan', 'lost', 'withdrawn')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NULL,
+    withdrawn_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX copies_barcode_key ON copies (barcode);
+CREATE INDEX copies_book_id_idx ON copies (book_id, id);
@@ -0,0 +1,29 @@
+-- members are the people who borrow copies.
+CREATE TABLE members (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(250) NOT NULL,
+    card_number VARCHAR(32) NOT NULL,
+    type VARCHAR(16) NOT NULL DEFAULT 'standard' CHECK (type IN ('standard', 'student', 'staff')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX members_card_number_key ON members (card_number);
+
+-- loans records every checkout. copy_id and book_id have no foreign key so
+-- the loan history outlives a purged book. A copy has at most one open loan,
+-- which backs the check made while checking it out.
+CREATE TABLE loans (
+    id BIGSERIAL PRIMARY KEY,
+    copy_id BIGINT NOT NULL,
+    book_id BIGINT NOT NULL,
+    member_id BIGINT NOT NULL REFERENCES members (id),
+    checked_out_at TIMESTAMP NOT NULL,
+    due_date DATE NOT NULL,
+    renewals INTEGER NOT NULL DEFAULT 0 CHECK (renewals >= 0),
+    returned_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX loans_copy_open_key ON loans (copy_id) WHERE returned_at IS NULL;
+CREATE INDEX loans_member_id_idx ON loans (member_id, id);
+CREATE INDEX loans_due_date_idx ON loans (due_date) WHERE returned_at IS NULL;
@@ -0,0 +1,22 @@
+ALTER TABLE copies DROP CONSTRAINT copies_status_check;
+ALTER TABLE copies ADD CONSTRAINT copies_status_check
+    CHECK (status IN ('available', 'on-loan', 'on-hold', 'lost', 'withdrawn'));
+
+-- holds queue members for a book, first placed first served. copy_id is the
+-- copy set aside for a ready hold. A member has at most one open hold per
+-- book.
+CREATE TABLE holds (
+    id BIGSERIAL PRIMARY KEY,
+    book_id INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    member_id BIGINT NOT NULL REFERENCES members (id),
+    copy_id BIGINT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'ready', 'fulfilled', 'cancelled', 'expired')),
+    placed_at TIMESTAMP NOT NULL,
+    ready_at TIMESTAMP NULL,
+    expires_on DATE NOT NULL,
+    closed_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX holds_member_book_open_key ON holds (member_id, book_id) WHERE status IN ('waiting', 'ready');
+CREATE INDEX holds_queue_idx ON holds (book_id, placed_at, id) WHERE status = 'waiting';
+CREATE INDEX holds_copy_id_idx ON holds (copy_id) WHERE status = 'ready';
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS ledger_entries;
+
+DROP INDEX loans_due_date_idx;
+CREATE INDEX loans_due_date_idx ON loans (due_date) WHERE returned_at IS NULL;
+DROP INDEX loans_copy_open_key;
+-- Loans of lost copies are closed as returned so that the index still holds.
+UPDATE loans SET returned_at = lost_at WHERE lost_at IS NOT NULL;
+CREATE UNIQUE INDEX loans_copy_open_key ON loans (copy_id) WHERE returned_at IS NULL;
+ALTER TABLE loans DROP COLUMN lost_at;
+
+ALTER TABLE copies DROP COLUMN category;
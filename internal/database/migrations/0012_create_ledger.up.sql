@@ -0,0 +1,30 @@
+-- The category of a copy decides the fines charged when it is late or lost.
+ALTER TABLE copies ADD COLUMN category VARCHAR(16) NOT NULL DEFAULT 'general'
+    CHECK (category IN ('general', 'short-loan', 'audiovisual'));
+
+-- A loan is closed by returning its copy or by declaring the copy lost.
+ALTER TABLE loans ADD COLUMN lost_at TIMESTAMP NULL;
+
+DROP INDEX loans_copy_open_key;
+CREATE UNIQUE INDEX loans_copy_open_key ON loans (copy_id) WHERE returned_at IS NULL AND lost_at IS NULL;
+DROP INDEX loans_due_date_idx;
+CREATE INDEX loans_due_date_idx ON loans (due_date) WHERE returned_at IS NULL AND lost_at IS NULL;
+
+-- ledger_entries are the charges, payments and waivers on the accounts of
+-- members. Only charges have a reason, and a loan is charged at most once
+-- for each reason.
+CREATE TABLE ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    member_id BIGINT NOT NULL REFERENCES members (id),
+    loan_id BIGINT NULL REFERENCES loans (id),
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('charge', 'payment', 'waiver')),
+    reason VARCHAR(16) NULL CHECK (reason IN ('overdue', 'lost')),
+    amount NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    recorded_by VARCHAR(250) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((kind = 'charge') = (reason IS NOT NULL))
+);
+
+CREATE INDEX ledger_entries_member_id_idx ON ledger_entries (member_id, id);
+CREATE UNIQUE INDEX ledger_entries_loan_charge_key ON ledger_entries (loan_id, reason) WHERE kind = 'charge';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS members_name_idx;
+
+ALTER TABLE members
+    DROP COLUMN max_loans,
+    DROP COLUMN expires_on,
+    DROP COLUMN status,
+    DROP COLUMN phone,
+    DROP COLUMN email;
@@ -0,0 +1,13 @@
+-- Members gain contact details, a status and the last day of their
+-- membership. Existing members are given a year from today.
+ALTER TABLE members
+    ADD COLUMN email VARCHAR(254) NOT NULL DEFAULT '',
+    ADD COLUMN phone VARCHAR(32) NOT NULL DEFAULT '',
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended')),
+    ADD COLUMN expires_on DATE NOT NULL DEFAULT (CURRENT_DATE + INTERVAL '1 year')::DATE,
+    ADD COLUMN max_loans INTEGER NULL CHECK (max_loans >= 0);
+
+ALTER TABLE members ALTER COLUMN expires_on DROP DEFAULT;
+
+-- Members are listed and searched by name.
+CREATE INDEX members_name_idx ON members (name, id);
@@ -0,0 +1,9 @@
+-- role_assignments give roles to principals, by the actor name they are
+-- recorded under: the sub claim of a JWT, or "key:" and the name of an API
+-- key. Principals without an assignment are readers.
+CREATE TABLE role_assignments (
+    subject VARCHAR(250) PRIMARY KEY,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('reader', 'librarian', 'admin')),
+    assigned_by VARCHAR(250) NOT NULL,
+    assigned_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -1,13 +1,91 @@
 package internal
 
-import "errors"
-
 const (
 	ZERO    = 0
 	EMPTY   = ""
 	ENVFILE = ".env"
+
+	MaxNameLength = 250
 )
 
 var (
-	ErrBookNotFound = errors.New("Book not found")
+	ErrBookNotFound     = NewError(ErrNotFound, "book_not_found", "Book not found")
+	ErrInvalidBookQuery = NewError(ErrBadRequest, "invalid_book_query", "Invalid book query")
+	ErrBookModified     = NewError(ErrPreconditionFailed, "book_version_mismatch", "Book was modified since the given version")
+	ErrIfMatchRequired  = NewError(ErrPreconditionRequired, "if_match_required", "If-Match header is required to modify a book")
+	ErrBookNotDeleted   = NewError(ErrConflict, "book_not_deleted", "Book must be deleted first")
+	ErrBookHasOpenLoans = NewError(ErrConflict, "book_has_open_loans", "Book has copies on loan")
+
+	ErrInvalidISBN       = NewError(ErrBadRequest, "invalid_isbn", "ISBN must be a valid ISBN-10 or ISBN-13")
+	ErrISBNAlreadyExists = NewError(ErrConflict, "isbn_already_exists", "A book with this ISBN already exists")
+
+	ErrBookRevisionNotFound = NewError(ErrNotFound, "book_revision_not_found", "Book revision not found")
+
+	ErrUnsupportedPatch = NewError(ErrUnsupported, "unsupported_patch_type", "Patch must be "+MergePatchContentType+" or "+JSONPatchContentType)
+	ErrInvalidPatch     = NewError(ErrBadRequest, "invalid_patch", "Invalid patch document")
+	ErrPatchTestFailed  = NewError(ErrConflict, "patch_test_failed", "A JSON Patch test operation failed")
+
+	ErrUnsupportedImport = NewError(ErrUnsupported, "unsupported_import_type", "Import must be text/csv or application/x-ndjson")
+	ErrInvalidImport     = NewError(ErrBadRequest, "invalid_import", "Invalid import")
+	ErrInvalidImportRow  = NewError(ErrBadRequest, "invalid_row", "Row could not be read")
+	ErrImportRowRejected = NewError(ErrBadRequest, "row_rejected", "The database rejected this row")
+	ErrImportTooLarge    = NewError(ErrTooLarge, "import_too_large", "Import is larger than the server accepts")
+
+	ErrUnsupportedExport = NewError(ErrBadRequest, "unsupported_export_format", "Export format must be csv, ndjson or xlsx")
+
+	ErrCopyNotFound         = NewError(ErrNotFound, "copy_not_found", "Copy not found")
+	ErrBarcodeAlreadyExists = NewError(ErrConflict, "barcode_already_exists", "A copy with this barcode already exists")
+	ErrCopyOnLoan           = NewError(ErrConflict, "copy_on_loan", "Copy is on loan")
+	ErrCopyWithdrawn        = NewError(ErrConflict, "copy_withdrawn", "Copy has been withdrawn")
+	ErrCopyStatusChange     = NewError(ErrConflict, "invalid_status_change", "Copy status can only be changed between available and lost")
+
+	ErrMemberNotFound          = NewError(ErrNotFound, "member_not_found", "Member not found")
+	ErrCardNumberAlreadyExists = NewError(ErrConflict, "card_number_already_exists", "A member with this card number already exists")
+	ErrInvalidMemberQuery      = NewError(ErrBadRequest, "invalid_member_query", "Invalid member query")
+	ErrMemberSuspended         = NewError(ErrConflict, "member_suspended", "Member is suspended")
+	ErrMembershipExpired       = NewError(ErrConflict, "membership_expired", "Membership has expired")
+
+	ErrLoanNotFound        = NewError(ErrNotFound, "loan_not_found", "Loan not found")
+	ErrInvalidLoanQuery    = NewError(ErrBadRequest, "invalid_loan_query", "Invalid loan query")
+	ErrCopyUnavailable     = NewError(ErrConflict, "copy_unavailable", "Copy is not available for loan")
+	ErrLoanLimitReached    = NewError(ErrConflict, "loan_limit_reached", "Member has as many copies on loan as their policy allows")
+	ErrRenewalLimitReached = NewError(ErrConflict, "renewal_limit_reached", "Loan has been renewed as many times as its policy allows")
+	ErrLoanReturned        = NewError(ErrConflict, "loan_returned", "Loan has already been returned")
+	ErrRenewalBlocked      = NewError(ErrConflict, "renewal_blocked_by_holds", "Loan cannot be renewed while other members are waiting for the book")
+	ErrLoanLost            = NewError(ErrConflict, "loan_lost", "Copy of this loan has been declared lost")
+
+	ErrLedgerEntryNotFound  = NewError(ErrNotFound, "ledger_entry_not_found", "Ledger entry not found")
+	ErrInvalidBalanceQuery  = NewError(ErrBadRequest, "invalid_balance_query", "Invalid balance query")
+	ErrCreditExceedsBalance = NewError(ErrConflict, "credit_exceeds_balance", "Amount is more than the member owes")
+
+	ErrHoldNotFound      = NewError(ErrNotFound, "hold_not_found", "Hold not found")
+	ErrHoldAlreadyPlaced = NewError(ErrConflict, "hold_already_placed", "Member already has a hold on this book")
+	ErrHoldNotNeeded     = NewError(ErrConflict, "hold_not_needed", "A copy of this book is available")
+	ErrHoldClosed        = NewError(ErrConflict, "hold_closed", "Hold is no longer open")
+	ErrCopyOnHold        = NewError(ErrConflict, "copy_on_hold", "Copy is set aside for a member's hold")
+
+	ErrAuthorNotFound      = NewError(ErrNotFound, "author_not_found", "Author not found")
+	ErrAuthorAlreadyExists = NewError(ErrConflict, "author_already_exists", "Author already exists")
+	ErrAuthorInUse         = NewError(ErrConflict, "author_in_use", "Author is referenced by books")
+
+	ErrGenreNotFound      = NewError(ErrNotFound, "genre_not_found", "Genre not found")
+	ErrGenreAlreadyExists = NewError(ErrConflict, "genre_already_exists", "Genre already exists")
+	ErrGenreInUse         = NewError(ErrConflict, "genre_in_use", "Genre is referenced by books")
+
+	ErrPublisherNotFound      = NewError(ErrNotFound, "publisher_not_found", "Publisher not found")
+	ErrPublisherAlreadyExists = NewError(ErrConflict, "publisher_already_exists", "Publisher already exists")
+	ErrPublisherInUse         = NewError(ErrConflict, "publisher_in_use", "Publisher is referenced by books")
+
+	ErrAuthenticationRequired = NewError(ErrUnauthorized, "authentication_required", "A bearer token or API key is required")
+	ErrInvalidCredentials     = NewError(ErrUnauthorized, "invalid_credentials", "Token or API key is invalid, expired or revoked")
+	ErrAPIKeyNotFound         = NewError(ErrNotFound, "api_key_not_found", "API key not found")
+	ErrAPIKeyNameTaken        = NewError(ErrConflict, "api_key_name_taken", "An active API key with this name already exists")
+	ErrAPIKeyRevoked          = NewError(ErrConflict, "api_key_revoked", "API key has already been revoked")
+
+	ErrPermissionDenied       = NewError(ErrForbidden, "permission_denied", "Your role does not allow this operation")
+	ErrRoleAssignmentNotFound = NewError(ErrNotFound, "role_assignment_not_found", "No role is assigned to this subject")
+	ErrOwnRoleChange          = NewError(ErrConflict, "own_role_change", "Admins cannot change their own role")
+
+	ErrRequestTimeout     = NewError(ErrTimeout, "request_timeout", "The request did not complete in time")
+	ErrStorageUnavailable = NewError(ErrUnavailable, "storage_unavailable", "The storage backend is unavailable")
 )
@@ -0,0 +1,42 @@
+package internal
+
+import "errors"
+
+// Error kinds group domain errors by how a client should react to them. Every
+// *Error wraps exactly one kind, so callers can test either for a specific
+// error, such as ErrBookNotFound, or for its kind with errors.Is.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnavailable  = errors.New("unavailable")
+	ErrTimeout      = errors.New("timeout")
+	ErrUnsupported  = errors.New("unsupported media type")
+	ErrTooLarge     = errors.New("content too large")
+
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrPreconditionRequired = errors.New("precondition required")
+)
+
+// Error is a domain error with a stable, machine-readable Code. Message is
+// safe to show to clients.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func NewError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FineRule sets what members are charged for copies of Category. A late
+// copy costs DailyRate for every day past its due date, unless it comes back
+// within GraceDays of it, up to MaxFine; a zero MaxFine leaves the fine
+// uncapped. A lost copy costs its price plus LostFee.
+type FineRule struct {
+	Category  ItemCategory
+	DailyRate decimal.Decimal
+	GraceDays int
+	MaxFine   decimal.Decimal
+	LostFee   decimal.Decimal
+}
+
+// OverdueFine is the fine for a copy due on dueDate and back at at.
+func (r FineRule) OverdueFine(dueDate, at time.Time) decimal.Decimal {
+	days := int(Day(at).Sub(dueDate).Hours() / 24)
+	if days <= ZERO || days <= r.GraceDays {
+		return decimal.Zero
+	}
+
+	fine := r.DailyRate.Mul(decimal.NewFromInt(int64(days)))
+	if r.MaxFine.IsPositive() && fine.GreaterThan(r.MaxFine) {
+		return r.MaxFine
+	}
+	return fine
+}
+
+// LostCharge is the charge for losing a copy bought for price.
+func (r FineRule) LostCharge(price decimal.Decimal) decimal.Decimal {
+	return price.Add(r.LostFee)
+}
+
+func (r FineRule) Validate() error {
+	for _, amount := range []struct {
+		name  string
+		value decimal.Decimal
+	}{
+		{"DailyRate", r.DailyRate},
+		{"MaxFine", r.MaxFine},
+		{"LostFee", r.LostFee},
+	} {
+		if amount.value.IsNegative() || !amount.value.Equal(amount.value.Round(2)) {
+			return fmt.Errorf("fine rule %q: %s must not be negative nor have more than two decimal places", r.Category, amount.name)
+		}
+	}
+	if r.GraceDays < ZERO {
+		return fmt.Errorf("fine rule %q: GraceDays must not be negative", r.Category)
+	}
+	return nil
+}
+
+// FineRules are the fine rules by item category.
+type FineRules map[ItemCategory]FineRule
+
+// For returns the rule of category, or the one of CategoryGeneral when
+// category has none.
+func (r FineRules) For(category ItemCategory) FineRule {
+	if rule, ok := r[category]; ok {
+		return rule
+	}
+	return r[CategoryGeneral]
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOverdueFine(t *testing.T) {
+	due := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+	rule := FineRule{
+		Category:  CategoryGeneral,
+		DailyRate: decimal.RequireFromString("0.25"),
+		GraceDays: 2,
+		MaxFine:   decimal.RequireFromString("10.00"),
+	}
+	uncapped := rule
+	uncapped.MaxFine = decimal.Zero
+
+	tests := []struct {
+		name string
+		rule FineRule
+		at   time.Time
+		want string
+	}{
+		{name: "early", rule: rule, at: due.AddDate(0, 0, -1), want: "0"},
+		{name: "due date", rule: rule, at: due.Add(18 * time.Hour), want: "0"},
+		{name: "last grace day", rule: rule, at: due.AddDate(0, 0, 2).Add(23 * time.Hour), want: "0"},
+		{name: "day after grace", rule: rule, at: due.AddDate(0, 0, 3), want: "0.75"},
+		{name: "at the cap", rule: rule, at: due.AddDate(0, 0, 40), want: "10.00"},
+		{name: "past the cap", rule: rule, at: due.AddDate(0, 0, 41), want: "10.00"},
+		{name: "zero MaxFine is uncapped", rule: uncapped, at: due.AddDate(0, 0, 100), want: "25.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rule.OverdueFine(due, tt.at)
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Fatalf("OverdueFine(%s) = %s, want %s", tt.at.Format(time.DateTime), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFineRuleValidate(t *testing.T) {
+	valid := FineRule{
+		Category:  CategoryGeneral,
+		DailyRate: decimal.RequireFromString("0.25"),
+		GraceDays: 2,
+		MaxFine:   decimal.RequireFromString("10.00"),
+		LostFee:   decimal.RequireFromString("5.00"),
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(r *FineRule)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(r *FineRule) {}},
+		{name: "zero MaxFine", modify: func(r *FineRule) { r.MaxFine = decimal.Zero }},
+		{name: "no grace", modify: func(r *FineRule) { r.GraceDays = 0 }},
+		{name: "trailing zeros", modify: func(r *FineRule) { r.DailyRate = decimal.RequireFromString("0.2500") }},
+		{name: "three decimal DailyRate", modify: func(r *FineRule) { r.DailyRate = decimal.RequireFromString("0.255") }, wantErr: true},
+		{name: "three decimal MaxFine", modify: func(r *FineRule) { r.MaxFine = decimal.RequireFromString("10.001") }, wantErr: true},
+		{name: "three decimal LostFee", modify: func(r *FineRule) { r.LostFee = decimal.RequireFromString("4.999") }, wantErr: true},
+		{name: "negative DailyRate", modify: func(r *FineRule) { r.DailyRate = decimal.RequireFromString("-0.25") }, wantErr: true},
+		{name: "negative GraceDays", modify: func(r *FineRule) { r.GraceDays = -1 }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid
+			tt.modify(&r)
+			if err := r.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
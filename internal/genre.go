@@ -0,0 +1,6 @@
+package internal
+
+type Genre struct {
+	ID   int64
+	Name string
+}
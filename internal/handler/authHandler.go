@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/auth"
+)
+
+// APIKeyHeader carries an API key for clients that cannot set the
+// Authorization header.
+const APIKeyHeader = "X-API-Key"
+
+type AuthHandler struct {
+	Authenticator *auth.Authenticator
+}
+
+func NewAuthHandler(authenticator *auth.Authenticator) *AuthHandler {
+	return &AuthHandler{Authenticator: authenticator}
+}
+
+// Authenticate lets through the requests to next carrying a valid
+// credential, with their principal in the request context and in its
+// principal holder, and answers the others with 401. The credential is a JWT
+// or an API key sent as a bearer token, or an API key sent in X-API-Key.
+func (h *AuthHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credential := credentialOf(r)
+		if credential == internal.EMPTY {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="box"`)
+			writeError(w, r, internal.ErrAuthenticationRequired)
+			return
+		}
+
+		principal, err := h.Authenticator.Authenticate(r.Context(), credential)
+		if err != nil {
+			if errors.Is(err, internal.ErrInvalidCredentials) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="box", error="invalid_token"`)
+			}
+			writeError(w, r, err)
+			return
+		}
+
+		internal.HoldPrincipal(r.Context(), principal)
+		next.ServeHTTP(w, r.WithContext(internal.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// Require returns a wrapper letting through to a handler only the requests
+// whose principal has a role granting p, and answering the others with 403.
+// With authentication off, every request is let through.
+func (h *AuthHandler) Require(p internal.Permission) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if h.Authenticator == nil {
+			return next
+		}
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := internal.PrincipalFromContext(r.Context())
+			if !ok {
+				writeError(w, r, internal.ErrAuthenticationRequired)
+				return
+			}
+			if !principal.Role.Can(p) {
+				writeError(w, r, internal.ErrPermissionDenied)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+func credentialOf(r *http.Request) string {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(r.Header.Get(APIKeyHeader))
+}
+
+// Me reports the principal the request was authenticated as.
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	principal, ok := internal.PrincipalFromContext(r.Context())
+	if !ok {
+		writeError(w, r, internal.ErrAuthenticationRequired)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, internal.Response[internal.Principal]{
+		Data:    principal,
+		Success: true,
+		Message: "Authenticated principal.",
+	})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+)
+
+// exportContentTypes are the media types of the formats ExportBooks writes.
+var exportContentTypes = map[string]string{
+	internal.ExportFormatCSV:    "text/csv; charset=utf-8",
+	internal.ExportFormatNDJSON: "application/x-ndjson",
+	internal.ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// exportWriter sets the export headers on the first write, so that errors
+// found before any book was written can still be reported as problems.
+type exportWriter struct {
+	w       http.ResponseWriter
+	format  string
+	written bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.written {
+		e.written = true
+		e.w.Header().Set("Content-Type", exportContentTypes[e.format])
+		e.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="books.%s"`, e.format))
+		e.w.WriteHeader(http.StatusOK)
+	}
+	return e.w.Write(p)
+}
+
+// ExportBooks streams every book matching the ListBooks filters and sort as
+// CSV (the default), NDJSON or XLSX, chosen by the format parameter. The
+// page_size, offset and cursor parameters are ignored. The export is bound
+// by h.BulkTimeout; "box export" is not bound at all.
+func (h *BookHandler) ExportBooks(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := bulkContext(w, r, h.BulkTimeout)
+	defer cancel()
+
+	query, err := parseBookQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == internal.EMPTY {
+		format = internal.ExportFormatCSV
+	}
+	if exportContentTypes[format] == internal.EMPTY {
+		writeError(w, r, internal.ErrUnsupportedExport)
+		return
+	}
+
+	out := &exportWriter{w: w, format: format}
+	response, err := h.Service.ExportBooks(ctx, query, format, out)
+	if err == nil {
+		// An empty NDJSON export writes nothing, yet still succeeded.
+		out.Write(nil)
+		return
+	}
+
+	if !out.written {
+		writeError(w, r, err)
+		return
+	}
+
+	// Part of the export was sent with a 200 already; abort the response so
+	// the client sees a truncated body rather than a complete one.
+	log.Printf("%s %s failed after %d books: %v", r.Method, r.URL.Path, response.Data, err)
+	panic(http.ErrAbortHandler)
+}
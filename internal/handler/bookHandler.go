@@ -1,19 +1,24 @@
 package handler
 
 import (
-	"context"
 	"encoding/json"
-	"errors"
+	"io"
+	"mime"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/amarantec/box/internal"
 	"github.com/amarantec/box/internal/book"
 )
 
+// BookHandler serves books. When RequireIfMatch is set, writes to an existing
+// book are refused with 428 unless they carry an If-Match header. Imports and
+// exports get BulkTimeout rather than the server's request timeout; zero
+// leaves them bound by it.
 type BookHandler struct {
-	Service book.IBookService
+	Service        book.IBookService
+	RequireIfMatch bool
+	BulkTimeout    time.Duration
 }
 
 func NewBookHandler(service book.IBookService) *BookHandler {
@@ -21,232 +26,395 @@ func NewBookHandler(service book.IBookService) *BookHandler {
 }
 
 func (h *BookHandler) RegisterBook(w http.ResponseWriter, r *http.Request) {
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	var book internal.Book
 
 	if err :=
 		json.NewDecoder(r.Body).Decode(&book); err != nil {
-		http.Error(w,
-			"Could not decode this request. Error: "+err.Error(),
-			http.StatusBadRequest)
+		writeError(w, r, badRequest("malformed_body", err))
 		return
 	}
 
-	response, err := h.Service.RegisterBook(ctxTimeout, book)
+	response, err := h.Service.RegisterBook(ctx, book)
 	if err != nil {
-		http.Error(w,
-			"Could not register this book. Error: "+err.Error(),
-			http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"response": response,
-	}); err != nil {
-		http.Error(w,
-			"Could not encode this response. Error: "+err.Error(),
-			http.StatusInternalServerError)
-		return
-	}
+	writeResponse(w, http.StatusCreated, response)
 }
 
 func (h *BookHandler) ListBooks(w http.ResponseWriter, r *http.Request) {
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	response, err := h.Service.ListBooks(ctxTimeout)
+	query, err := parseBookQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w,
-			"Could not list books from repository. Error: "+err.Error(),
-			http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"response": response,
-	}); err != nil {
-		http.Error(w,
-			"Could not encode this response. Error: "+err.Error(),
-			http.StatusInternalServerError)
+	response, err := h.Service.ListBooks(ctx, query)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
+
+	writeResponse(w, http.StatusOK, response)
 }
 
 func (h *BookHandler) GetBookById(w http.ResponseWriter, r *http.Request) {
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	bookId, err := strconv.ParseInt(r.PathValue("bookId"), 10, 64)
+	bookId, err := idParam(r, "bookId")
 	if err != nil {
-		http.Error(w,
-			"Invalid parameter. Error: "+err.Error(),
-			http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	response, err := h.Service.GetBookById(ctxTimeout, bookId)
+	response, err := h.Service.GetBookById(ctx, bookId)
 	if err != nil {
-		if errors.Is(err, internal.ErrBookNotFound) {
-			http.Error(w, "Book not found", http.StatusNotFound)
-		} else {
-			http.Error(w,
-				"Could not get this book from repository. Error: "+err.Error(),
-				http.StatusInternalServerError)
-		}
+		writeError(w, r, err)
 		return
+	}
 
+	etag := bookDetailsETag(response.Data)
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"response": response,
-	}); err != nil {
-		http.Error(w,
-			"Could not encode this response. Error: "+err.Error(),
-			http.StatusInternalServerError)
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *BookHandler) GetBookByISBN(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	response, err := h.Service.GetBookByISBN(ctx, r.PathValue("isbn"))
+	if err != nil {
+		writeError(w, r, err)
 		return
+	}
 
+	etag := bookDetailsETag(response.Data)
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
 	}
+
+	writeResponse(w, http.StatusOK, response)
 }
 
 func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	var book internal.Book
 
 	if err :=
 		json.NewDecoder(r.Body).Decode(&book); err != nil {
-		http.Error(w,
-			"Could not decode this request. Error: "+err.Error(),
-			http.StatusBadRequest)
+		writeError(w, r, badRequest("malformed_body", err))
 		return
 	}
 
-	response, err := h.Service.UpdateBook(ctxTimeout, book)
+	id, err := resourceID(r, "bookId", book.ID)
 	if err != nil {
-		if errors.Is(err, internal.ErrBookNotFound) {
-			http.Error(w, "Book not found", http.StatusNotFound)
-			return
-		} else {
-			http.Error(w,
-				"Could not update this book. Error: "+err.Error(),
-				http.StatusInternalServerError)
-			return
+		writeError(w, r, err)
+		return
+	}
+	book.ID = id
 
-		}
+	// If-Match takes precedence over a Version sent in the body.
+	version, err := ifMatchVersion(r, h.RequireIfMatch)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if r.Header.Get("If-Match") != internal.EMPTY {
+		book.Version = version
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusNoContent)
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"response": response,
-	}); err != nil {
-		http.Error(w,
-			"Could not encode this response. Error: "+err.Error(),
-			http.StatusInternalServerError)
+	response, err := h.Service.UpdateBook(ctx, book)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
+
+	// A write conditioned on a version leaves the book at the next one.
+	written := book.Version
+	if written != internal.ZERO {
+		written++
+	}
+	h.setBookETag(w, r, book.ID, written)
+	writeResponse(w, http.StatusNoContent, response)
 }
 
-func (h *BookHandler) DeleteBook(w http.ResponseWriter, r *http.Request) {
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// setBookETag sets the ETag GetBookById answers with on the response to a
+// write, so that a client can send it back in If-Match or If-None-Match
+// without reading the book again. It is left out if the book cannot be read
+// back at version, or at any version when version is zero.
+func (h *BookHandler) setBookETag(w http.ResponseWriter, r *http.Request, bookId, version int64) {
+	details, err := h.Service.GetBookById(r.Context(), bookId)
+	if err != nil || (version != internal.ZERO && details.Data.Version != version) {
+		return
+	}
+	w.Header().Set("ETag", bookDetailsETag(details.Data))
+}
+
+// maxPatchSize bounds the patch documents read by PatchBook.
+const maxPatchSize = 1 << 20
+
+func (h *BookHandler) PatchBook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Set("Accept-Patch", internal.MergePatchContentType+", "+internal.JSONPatchContentType)
+
+	bookId, err := idParam(r, "bookId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, err := ifMatchVersion(r, h.RequireIfMatch)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	bookId, err := strconv.ParseInt(r.PathValue("bookId"), 10, 64)
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
 	if err != nil {
-		http.Error(w,
-			"Invalid parameter. Error: "+err.Error(),
-			http.StatusBadRequest)
+		writeError(w, r, internal.ErrUnsupportedPatch)
 		return
 	}
 
-	response, err := h.Service.DeleteBook(ctxTimeout, bookId)
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
 	if err != nil {
-		if errors.Is(err, internal.ErrBookNotFound) {
-			http.Error(w, "Book not found", http.StatusNotFound)
-			return
-		} else {
-			http.Error(w,
-				"Could not delete this book from repository. Error: "+err.Error(),
-				http.StatusInternalServerError)
-			return
-		}
+		writeError(w, r, badRequest("malformed_body", err))
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusNoContent)
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"response": response,
-	}); err != nil {
-		http.Error(w,
-			"Could not encode this response. Error: "+err.Error(),
-			http.StatusInternalServerError)
+	response, err := h.Service.PatchBook(ctx, bookId, version, contentType, patch)
+	if err != nil {
+		writeError(w, r, err)
 		return
+	}
+
+	h.setBookETag(w, r, bookId, response.Data.Version)
+	writeResponse(w, http.StatusOK, response)
+}
 
+func (h *BookHandler) DeleteBook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bookId, err := idParam(r, "bookId")
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
+
+	version, err := ifMatchVersion(r, h.RequireIfMatch)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.DeleteBook(ctx, bookId, version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, response)
 }
 
 func (h *BookHandler) ListBooksByGenre(w http.ResponseWriter, r *http.Request) {
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	bookGenre := r.PathValue("bookGenre")
 
-	response, err := h.Service.ListBooksByGenre(ctxTimeout, bookGenre)
+	response, err := h.Service.ListBooksByGenre(ctx, bookGenre)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *BookHandler) ListBooksByAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bookAuthor := r.PathValue("bookAuthor")
+
+	response, err := h.Service.ListBooksByAuthor(ctx, bookAuthor)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *BookHandler) SearchBooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	values := r.URL.Query()
+	query := internal.BookSearchQuery{Query: values.Get("q")}
+
+	var err error
+	if query.PageSize, err = intParam(values, "page_size"); err == nil {
+		query.Offset, err = intParam(values, "offset")
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.SearchBooks(ctx, query)
 	if err != nil {
-		http.Error(w,
-			"Could not list books from repository. Error: "+err.Error(),
-			http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
+	}
 
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *BookHandler) ListBookHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bookId, err := idParam(r, "bookId")
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"response": response,
-	}); err != nil {
-		http.Error(w,
-			"Could not encode this response. Error: "+err.Error(),
-			http.StatusInternalServerError)
+	response, err := h.Service.ListBookHistory(ctx, bookId)
+	if err != nil {
+		writeError(w, r, err)
 		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *BookHandler) GetBookRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
+	bookId, err := idParam(r, "bookId")
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
+
+	revisionId, err := idParam(r, "revisionId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.GetBookRevision(ctx, bookId, revisionId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
 }
 
-func (h *BookHandler) ListBooksByAuthor(w http.ResponseWriter, r *http.Request) {
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+func (h *BookHandler) DiffBookRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	bookAuthor := r.PathValue("bookAuthor")
+	bookId, err := idParam(r, "bookId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	from, err := requiredIDQuery(r, "from")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	to, err := requiredIDQuery(r, "to")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	response, err := h.Service.ListBooksByAuthor(ctxTimeout, bookAuthor)
+	response, err := h.Service.DiffBookRevisions(ctx, bookId, from, to)
 	if err != nil {
-		http.Error(w,
-			"Could not list books from repository. Error: "+err.Error(),
-			http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
 
+func (h *BookHandler) ListDeletedBooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	values := r.URL.Query()
+	var query internal.DeletedBookQuery
+
+	var err error
+	if query.PageSize, err = intParam(values, "page_size"); err == nil {
+		query.Offset, err = intParam(values, "offset")
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"response": response,
-	}); err != nil {
-		http.Error(w,
-			"Could not encode this response. Error: "+err.Error(),
-			http.StatusInternalServerError)
+	response, err := h.Service.ListDeletedBooks(ctx, query)
+	if err != nil {
+		writeError(w, r, err)
 		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *BookHandler) RestoreBook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
+	bookId, err := idParam(r, "bookId")
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
+
+	version, err := ifMatchVersion(r, h.RequireIfMatch)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.RestoreBook(ctx, bookId, version)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.setBookETag(w, r, bookId, response.Data.Version)
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *BookHandler) PurgeBook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bookId, err := idParam(r, "bookId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.PurgeBook(ctx, bookId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, response)
 }
@@ -0,0 +1,198 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/handler/routes"
+)
+
+func TestRegisterBookValidation(t *testing.T) {
+	h := newRouter(nil, routes.Config{})
+
+	w := serve(h, http.MethodPost, "/books", `{
+		"ID": 7,
+		"Title": "",
+		"Description": "No title.",
+		"ISBN13": "9780441172710",
+		"Genres": [],
+		"Authors": [{"ID": -1}],
+		"PublishDate": "2999-01-01T00:00:00Z",
+		"Publisher": {},
+		"Pages": 0
+	}`)
+	assertStatus(t, w, http.StatusUnprocessableEntity)
+
+	p := decodeProblem(t, w)
+	if p.Type != "urn:box:problem:validation" || p.Code != "validation_failed" || p.Instance != "/books" {
+		t.Fatalf("problem = %+v, want a validation_failed problem for /books", p)
+	}
+
+	var fields []string
+	for _, e := range p.Errors {
+		fields = append(fields, e.Field)
+	}
+	for _, want := range []string{"ID", "Title", "ISBN13", "Pages", "PublishDate", "Publisher", "Genres", "Authors[0].ID"} {
+		if !slices.Contains(fields, want) {
+			t.Errorf("errors %v do not report %s", fields, want)
+		}
+	}
+}
+
+func TestRegisterBook(t *testing.T) {
+	h := newRouter(nil, routes.Config{})
+
+	if id := registerBook(t, h); id <= 0 {
+		t.Fatalf("RegisterBook returned id %d, want a positive id", id)
+	}
+}
+
+func TestBookProblems(t *testing.T) {
+	h := newRouter(nil, routes.Config{})
+
+	tests := []struct {
+		name, method, target, body string
+		wantStatus                 int
+		wantCode                   string
+	}{
+		{"missing book", http.MethodGet, "/books/4242", "", http.StatusNotFound, "book_not_found"},
+		{"malformed id", http.MethodGet, "/books/dune", "", http.StatusBadRequest, "invalid_parameter"},
+		{"malformed body", http.MethodPost, "/books", `{"Title":`, http.StatusBadRequest, "malformed_body"},
+		{"invalid query", http.MethodGet, "/books?page_size=-1", "", http.StatusBadRequest, "invalid_book_query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, tt.method, tt.target, tt.body)
+			assertStatus(t, w, tt.wantStatus)
+
+			if p := decodeProblem(t, w); p.Code != tt.wantCode {
+				t.Fatalf("problem code = %q, want %q", p.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPatchBookMediaTypes(t *testing.T) {
+	h := newRouter(nil, routes.Config{})
+	id := registerBook(t, h)
+	target := fmt.Sprintf("/books/%d", id)
+
+	w := serve(h, http.MethodPatch, target, `{"Pages": 500}`)
+	assertStatus(t, w, http.StatusUnsupportedMediaType)
+	if p := decodeProblem(t, w); p.Code != "unsupported_patch_type" {
+		t.Fatalf("problem code = %q, want unsupported_patch_type", p.Code)
+	}
+
+	w = serve(h, http.MethodPatch, target, `{"Pages": 500}`, "Content-Type", internal.MergePatchContentType)
+	assertStatus(t, w, http.StatusOK)
+
+	w = serve(h, http.MethodPatch, target, `[{"op": "replace", "path": "/Pages", "value": 501}]`, "Content-Type", internal.JSONPatchContentType)
+	assertStatus(t, w, http.StatusOK)
+
+	var patched internal.Book
+	decodeResponse(t, w.Body, &patched)
+	if patched.Pages != 501 || patched.Version != 3 {
+		t.Fatalf("patched book has %d pages at version %d, want 501 at version 3", patched.Pages, patched.Version)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	h := newRouter(nil, routes.Config{})
+	id := registerBook(t, h)
+	target := fmt.Sprintf("/books/%d", id)
+
+	w := serve(h, http.MethodGet, target, "")
+	assertStatus(t, w, http.StatusOK)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET returned no ETag")
+	}
+
+	w = serve(h, http.MethodGet, target, "", "If-None-Match", etag)
+	assertStatus(t, w, http.StatusNotModified)
+	if w.Body.Len() != 0 {
+		t.Fatalf("304 carried a body: %s", w.Body)
+	}
+	assertStatus(t, serve(h, http.MethodGet, target, "", "If-None-Match", `W/"0"`), http.StatusOK)
+
+	update := strings.Replace(validBook, `"Pages": 412`, `"Pages": 413`, 1)
+	w = serve(h, http.MethodPut, target, update, "If-Match", `"7"`)
+	assertStatus(t, w, http.StatusPreconditionFailed)
+	if p := decodeProblem(t, w); p.Code != "book_version_mismatch" {
+		t.Fatalf("problem code = %q, want book_version_mismatch", p.Code)
+	}
+	assertStatus(t, serve(h, http.MethodPatch, target, `{"Pages": 9}`, "Content-Type", internal.MergePatchContentType, "If-Match", `"7"`), http.StatusPreconditionFailed)
+	assertStatus(t, serve(h, http.MethodDelete, target, "", "If-Match", `"7"`), http.StatusPreconditionFailed)
+
+	assertStatus(t, serve(h, http.MethodPut, target, update, "If-Match", etag), http.StatusNoContent)
+	assertStatus(t, serve(h, http.MethodGet, target, "", "If-None-Match", etag), http.StatusOK)
+}
+
+func TestWriteETags(t *testing.T) {
+	h := newRouter(nil, routes.Config{})
+	id := registerBook(t, h)
+	target := fmt.Sprintf("/books/%d", id)
+
+	written := func(name string, w *httptest.ResponseRecorder) string {
+		t.Helper()
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			t.Fatalf("%s returned no ETag", name)
+		}
+		if got := serve(h, http.MethodGet, target, "").Header().Get("ETag"); got != etag {
+			t.Fatalf("%s ETag = %s, GET ETag = %s", name, etag, got)
+		}
+		assertStatus(t, serve(h, http.MethodGet, target, "", "If-None-Match", etag), http.StatusNotModified)
+		return etag
+	}
+
+	etag := serve(h, http.MethodGet, target, "").Header().Get("ETag")
+	update := strings.Replace(validBook, `"Pages": 412`, `"Pages": 413`, 1)
+	w := serve(h, http.MethodPut, target, update, "If-Match", etag)
+	assertStatus(t, w, http.StatusNoContent)
+	etag = written("PUT", w)
+
+	w = serve(h, http.MethodPatch, target, `{"Pages": 9}`, "Content-Type", internal.MergePatchContentType, "If-Match", etag)
+	assertStatus(t, w, http.StatusOK)
+	etag = written("PATCH", w)
+
+	assertStatus(t, serve(h, http.MethodDelete, target, "", "If-Match", etag), http.StatusNoContent)
+	w = serve(h, http.MethodPost, fmt.Sprintf("/admin/trash/books/%d/restore", id), "")
+	assertStatus(t, w, http.StatusOK)
+	written("restore", w)
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	h := newRouter(nil, routes.Config{RequireIfMatch: true})
+	id := registerBook(t, h)
+	target := fmt.Sprintf("/books/%d", id)
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		w := serve(h, method, target, validBook)
+		assertStatus(t, w, http.StatusPreconditionRequired)
+		if p := decodeProblem(t, w); p.Code != "if_match_required" {
+			t.Fatalf("%s problem code = %q, want if_match_required", method, p.Code)
+		}
+	}
+	assertStatus(t, serve(h, http.MethodPatch, target, `{"Pages": 9}`, "Content-Type", internal.MergePatchContentType), http.StatusPreconditionRequired)
+
+	assertStatus(t, serve(h, http.MethodDelete, target, "", "If-Match", "*"), http.StatusNoContent)
+}
+
+func TestImportBooksTooLarge(t *testing.T) {
+	h := newRouter(nil, routes.Config{})
+
+	// An unterminated quoted field keeps the CSV reader reading to the limit.
+	body := "title,description,publish_date,pages,publisher,genres,authors\n\"" + strings.Repeat("x", 64<<20)
+	w := serve(h, http.MethodPost, "/books/import", body, "Content-Type", "text/csv")
+	assertStatus(t, w, http.StatusRequestEntityTooLarge)
+	if p := decodeProblem(t, w); p.Code != "import_too_large" {
+		t.Fatalf("problem code = %q, want import_too_large", p.Code)
+	}
+}
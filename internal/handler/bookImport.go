@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/amarantec/box/internal"
+)
+
+// importFormats maps the media types accepted by ImportBooks to an import
+// format.
+var importFormats = map[string]string{
+	"text/csv":             internal.ImportFormatCSV,
+	"application/x-ndjson": internal.ImportFormatNDJSON,
+	"application/jsonl":    internal.ImportFormatNDJSON,
+}
+
+// Values of the mode query parameter of ImportBooks.
+const (
+	importModeAtomic     = "atomic"
+	importModeBestEffort = "best-effort"
+)
+
+// maxImportSize bounds the request bodies read by ImportBooks. Larger
+// catalogs can be loaded with "box import".
+const maxImportSize = 64 << 20
+
+// ImportBooks streams the request body into the catalog. It answers 200 with
+// the per-row report when the import was committed, and 422 with the same
+// report when it was rolled back. The import is bound by h.BulkTimeout and
+// bodies larger than maxImportSize are refused with 413.
+func (h *BookHandler) ImportBooks(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := bulkContext(w, r, h.BulkTimeout)
+	defer cancel()
+
+	opts, err := importOptions(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.ImportBooks(ctx, http.MaxBytesReader(w, r.Body, maxImportSize), opts)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		err = fmt.Errorf("%w: the limit is %d bytes", internal.ErrImportTooLarge, tooLarge.Limit)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !response.Data.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+	writeResponse(w, status, response)
+}
+
+func importOptions(r *http.Request) (internal.ImportOptions, error) {
+	var opts internal.ImportOptions
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || importFormats[contentType] == internal.EMPTY {
+		return opts, internal.ErrUnsupportedImport
+	}
+	opts.Format = importFormats[contentType]
+
+	values := r.URL.Query()
+
+	switch mode := values.Get("mode"); mode {
+	case internal.EMPTY, importModeAtomic:
+	case importModeBestEffort:
+		opts.BestEffort = true
+	default:
+		return opts, fmt.Errorf("%w: mode must be %s or %s", internal.ErrInvalidImport, importModeAtomic, importModeBestEffort)
+	}
+
+	if raw := values.Get("batch_size"); raw != internal.EMPTY {
+		if opts.BatchSize, err = strconv.Atoi(raw); err != nil {
+			return opts, fmt.Errorf("%w: batch_size must be an integer", internal.ErrInvalidImport)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amarantec/box/internal"
+)
+
+const dateLayout = "2006-01-02"
+
+// parseBookQuery reads the ListBooks query parameters:
+//
+//	page_size, offset, cursor, sort (prefix with "-" for descending),
+//	publisher, published_from, published_to, min_pages, max_pages, genre, author
+func parseBookQuery(values url.Values) (internal.BookQuery, error) {
+	var q internal.BookQuery
+	var err error
+
+	if q.PageSize, err = intParam(values, "page_size"); err != nil {
+		return q, err
+	}
+	if q.Offset, err = intParam(values, "offset"); err != nil {
+		return q, err
+	}
+	q.Cursor = values.Get("cursor")
+
+	if sort := values.Get("sort"); sort != internal.EMPTY {
+		q.SortBy, q.SortDirection = sort, internal.SortAsc
+		if field, found := strings.CutPrefix(sort, "-"); found {
+			q.SortBy, q.SortDirection = field, internal.SortDesc
+		}
+	}
+
+	q.Publisher = values.Get("publisher")
+	q.Genre = values.Get("genre")
+	q.Author = values.Get("author")
+
+	if q.PublishedFrom, err = dateParam(values, "published_from"); err != nil {
+		return q, err
+	}
+	if q.PublishedTo, err = dateParam(values, "published_to"); err != nil {
+		return q, err
+	}
+	if q.MinPages, err = optionalIntParam(values, "min_pages"); err != nil {
+		return q, err
+	}
+	if q.MaxPages, err = optionalIntParam(values, "max_pages"); err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
+
+func intParam(values url.Values, name string) (int, error) {
+	raw := values.Get(name)
+	if raw == internal.EMPTY {
+		return internal.ZERO, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return internal.ZERO, fmt.Errorf("%w: %s must be an integer", internal.ErrInvalidBookQuery, name)
+	}
+	return v, nil
+}
+
+func optionalIntParam(values url.Values, name string) (*int, error) {
+	if values.Get(name) == internal.EMPTY {
+		return nil, nil
+	}
+
+	v, err := intParam(values, name)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func dateParam(values url.Values, name string) (*time.Time, error) {
+	raw := values.Get(name)
+	if raw == internal.EMPTY {
+		return nil, nil
+	}
+
+	v, err := time.Parse(dateLayout, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be a date formatted as YYYY-MM-DD", internal.ErrInvalidBookQuery, name)
+	}
+	return &v, nil
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amarantec/box/internal"
+)
+
+// bulkContext gives a request that streams a large body, such as an import
+// or an export, timeout instead of the server's request timeout, and lifts
+// the connection's read and write deadlines to match. The returned context is
+// still canceled when the client goes away. A zero timeout leaves the request
+// as it is.
+func bulkContext(w http.ResponseWriter, r *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= internal.ZERO {
+		return context.WithCancel(r.Context())
+	}
+
+	deadline := time.Now().Add(timeout)
+	rc := http.NewResponseController(w)
+	// Writers that cannot set deadlines, such as httptest's, have none to lift.
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+
+	ctx, cancel := context.WithDeadline(context.WithoutCancel(r.Context()), deadline)
+	stop := context.AfterFunc(r.Context(), func() {
+		if !errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+			cancel()
+		}
+	})
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
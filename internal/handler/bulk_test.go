@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBulkContextOutlivesRequestTimeout(t *testing.T) {
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelParent()
+	r := httptest.NewRequest(http.MethodGet, "/books/export", nil).WithContext(parent)
+
+	ctx, cancel := bulkContext(httptest.NewRecorder(), r, time.Minute)
+	defer cancel()
+
+	<-parent.Done()
+	select {
+	case <-ctx.Done():
+		t.Fatalf("bulk context ended with the request timeout: %v", ctx.Err())
+	case <-time.After(20 * time.Millisecond):
+	}
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) < 50*time.Second {
+		t.Fatalf("bulk context deadline = %v, want about a minute from now", deadline)
+	}
+}
+
+func TestBulkContextCanceledWithClient(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/books/export", nil).WithContext(parent)
+
+	ctx, cancel := bulkContext(httptest.NewRecorder(), r, time.Minute)
+	defer cancel()
+
+	cancelParent()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("bulk context outlived the client")
+	}
+}
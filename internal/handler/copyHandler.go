@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/inventory"
+)
+
+type CopyHandler struct {
+	Service inventory.ICopyService
+}
+
+func NewCopyHandler(service inventory.ICopyService) *CopyHandler {
+	return &CopyHandler{Service: service}
+}
+
+func (h *CopyHandler) AddCopy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var bookCopy internal.BookCopy
+
+	if err :=
+		json.NewDecoder(r.Body).Decode(&bookCopy); err != nil {
+		writeError(w, r, badRequest("malformed_body", err))
+		return
+	}
+
+	bookId, err := resourceID(r, "bookId", bookCopy.BookID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	bookCopy.BookID = bookId
+
+	response, err := h.Service.AddCopy(ctx, bookCopy)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusCreated, response)
+}
+
+func (h *CopyHandler) ListCopies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bookId, err := idParam(r, "bookId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.ListCopies(ctx, bookId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *CopyHandler) GetCopyById(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	copyId, err := idParam(r, "copyId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.GetCopyById(ctx, copyId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *CopyHandler) UpdateCopy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var bookCopy internal.BookCopy
+
+	if err :=
+		json.NewDecoder(r.Body).Decode(&bookCopy); err != nil {
+		writeError(w, r, badRequest("malformed_body", err))
+		return
+	}
+
+	id, err := resourceID(r, "copyId", bookCopy.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	bookCopy.ID = id
+
+	response, err := h.Service.UpdateCopy(ctx, bookCopy)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, response)
+}
+
+func (h *CopyHandler) WithdrawCopy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	copyId, err := idParam(r, "copyId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.WithdrawCopy(ctx, copyId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, response)
+}
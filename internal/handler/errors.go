@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
+)
+
+const problemTypePrefix = "urn:box:problem:"
+
+// Problem is an RFC 9457 problem details body. Code is a stable identifier
+// for the specific error and Errors lists the failing fields of a payload.
+type Problem struct {
+	Type     string                `json:"type"`
+	Title    string                `json:"title"`
+	Status   int                   `json:"status"`
+	Detail   string                `json:"detail,omitempty"`
+	Instance string                `json:"instance,omitempty"`
+	Code     string                `json:"code"`
+	Errors   []internal.FieldError `json:"errors,omitempty"`
+}
+
+type problemKind struct {
+	kind   error
+	status int
+	name   string
+	title  string
+}
+
+var problemKinds = []problemKind{
+	{internal.ErrBadRequest, http.StatusBadRequest, "bad-request", "Bad request"},
+	{internal.ErrUnauthorized, http.StatusUnauthorized, "unauthorized", "Unauthorized"},
+	{internal.ErrForbidden, http.StatusForbidden, "forbidden", "Forbidden"},
+	{internal.ErrNotFound, http.StatusNotFound, "not-found", "Resource not found"},
+	{internal.ErrConflict, http.StatusConflict, "conflict", "Conflict with the current state"},
+	{internal.ErrValidation, http.StatusUnprocessableEntity, "validation", "Validation failed"},
+	{internal.ErrUnavailable, http.StatusServiceUnavailable, "unavailable", "Service unavailable"},
+	{internal.ErrTimeout, http.StatusGatewayTimeout, "timeout", "Request timed out"},
+	{internal.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition-failed", "Precondition failed"},
+	{internal.ErrPreconditionRequired, http.StatusPreconditionRequired, "precondition-required", "Precondition required"},
+	{internal.ErrUnsupported, http.StatusUnsupportedMediaType, "unsupported-media-type", "Unsupported media type"},
+	{internal.ErrTooLarge, http.StatusRequestEntityTooLarge, "content-too-large", "Content too large"},
+}
+
+var internalProblem = problemKind{nil, http.StatusInternalServerError, "internal", "Internal server error"}
+
+// badRequest reports a request that could not be read, such as a malformed
+// body or path parameter.
+func badRequest(code string, err error) error {
+	return internal.NewError(internal.ErrBadRequest, code, err.Error())
+}
+
+// newProblem describes err without exposing anything but the messages of
+// domain errors. Errors outside the taxonomy become a generic 500.
+func newProblem(err error) Problem {
+	var validationErr *internal.ValidationError
+	if errors.As(err, &validationErr) {
+		return problemFrom(kindOf(err), "validation_failed", "One or more fields are invalid.", validationErr.Errors)
+	}
+
+	var domainErr *internal.Error
+	switch {
+	case errors.As(err, &domainErr):
+	case errors.Is(err, context.DeadlineExceeded):
+		domainErr, err = internal.ErrRequestTimeout, internal.ErrRequestTimeout
+	case database.IsUnavailable(err):
+		domainErr, err = internal.ErrStorageUnavailable, internal.ErrStorageUnavailable
+	default:
+		return problemFrom(internalProblem, "internal_error", "The server could not complete this request.", nil)
+	}
+
+	// err may wrap the domain error with more detail, as in
+	// "Invalid book query: offset must not be negative".
+	return problemFrom(kindOf(domainErr), domainErr.Code, err.Error(), nil)
+}
+
+func kindOf(err error) problemKind {
+	for _, k := range problemKinds {
+		if errors.Is(err, k.kind) {
+			return k
+		}
+	}
+	return internalProblem
+}
+
+func problemFrom(k problemKind, code, detail string, fields []internal.FieldError) Problem {
+	return Problem{
+		Type:   problemTypePrefix + k.name,
+		Title:  k.title,
+		Status: k.status,
+		Detail: detail,
+		Code:   code,
+		Errors: fields,
+	}
+}
+
+// writeError renders err as application/problem+json. Server errors are
+// logged with their cause, which is never sent to the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := newProblem(err)
+	// r.URL.Path has the route prefix stripped; report the path as requested.
+	problem.Instance, _, _ = strings.Cut(r.RequestURI, "?")
+
+	if problem.Status >= http.StatusInternalServerError {
+		log.Printf("%s %s failed: %v", r.Method, problem.Instance, err)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Printf("Could not encode this problem. Error: %v", err)
+	}
+}
+
+// writeResponse sends response wrapped in the {"response": ...} envelope. A
+// 204 status is sent without a body.
+func writeResponse(w http.ResponseWriter, status int, response any) {
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"response": response,
+	}); err != nil {
+		log.Printf("Could not encode this response. Error: %v", err)
+	}
+}
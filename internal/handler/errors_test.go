@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amarantec/box/internal"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   string
+		wantCode   string
+		wantDetail string
+	}{
+		{
+			name:       "domain error",
+			err:        internal.ErrBookNotFound,
+			wantStatus: http.StatusNotFound,
+			wantType:   "urn:box:problem:not-found",
+			wantCode:   "book_not_found",
+			wantDetail: "Book not found",
+		},
+		{
+			name:       "wrapped domain error",
+			err:        fmt.Errorf("%w: offset must not be negative", internal.ErrInvalidBookQuery),
+			wantStatus: http.StatusBadRequest,
+			wantType:   "urn:box:problem:bad-request",
+			wantCode:   "invalid_book_query",
+			wantDetail: "Invalid book query: offset must not be negative",
+		},
+		{
+			name:       "deadline",
+			err:        fmt.Errorf("list books: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout,
+			wantType:   "urn:box:problem:timeout",
+			wantCode:   internal.ErrRequestTimeout.Code,
+			wantDetail: internal.ErrRequestTimeout.Message,
+		},
+		{
+			name:       "unknown error",
+			err:        errors.New("pq: password authentication failed for user box"),
+			wantStatus: http.StatusInternalServerError,
+			wantType:   "urn:box:problem:internal",
+			wantCode:   "internal_error",
+			wantDetail: "The server could not complete this request.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/books/42?fields=all", nil)
+			w := httptest.NewRecorder()
+			writeError(w, r, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Fatalf("Content-Type = %q, want application/problem+json", ct)
+			}
+
+			var p Problem
+			if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			want := Problem{
+				Type:     tt.wantType,
+				Title:    p.Title,
+				Status:   tt.wantStatus,
+				Detail:   tt.wantDetail,
+				Instance: "/books/42",
+				Code:     tt.wantCode,
+			}
+			if p.Title == "" || fmt.Sprint(p) != fmt.Sprint(want) {
+				t.Fatalf("problem = %+v, want %+v", p, want)
+			}
+		})
+	}
+}
+
+func TestWriteValidationError(t *testing.T) {
+	v := &internal.ValidationError{}
+	v.Add("Title", "must not be empty")
+
+	r := httptest.NewRequest(http.MethodPost, "/books", nil)
+	w := httptest.NewRecorder()
+	writeError(w, r, v)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	body := w.Body.String()
+	for _, want := range []string{`"code":"validation_failed"`, `"errors":[{"Field":"Title","Message":"must not be empty"}]`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("body %s does not contain %s", body, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/amarantec/box/internal"
+)
+
+// bookDetailsETag is the strong entity tag of a book looked up with its
+// availability. The counts follow the version, so the tag changes when a
+// copy is lent, returned or set aside even though the book itself did not.
+func bookDetailsETag(d internal.BookDetails) string {
+	a := d.Availability
+	return fmt.Sprintf(`"%d-%d.%d.%d.%d.%d"`, d.Version, a.Available, a.OnLoan, a.OnHold, a.Lost, a.Withdrawn)
+}
+
+// ifMatchVersion reads the version a write is conditioned on from If-Match.
+// It returns zero, meaning any version, for "*" or when the header is absent
+// and not required. A tag that cannot be one of ours never matches.
+func ifMatchVersion(r *http.Request, required bool) (int64, error) {
+	raw := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch {
+	case raw == internal.EMPTY && required:
+		return internal.ZERO, internal.ErrIfMatchRequired
+	case raw == internal.EMPTY || raw == "*":
+		return internal.ZERO, nil
+	case strings.Contains(raw, ","):
+		return internal.ZERO, internal.NewError(internal.ErrBadRequest, "invalid_if_match", "If-Match must hold a single entity tag")
+	}
+
+	// Weak tags never match under the strong comparison If-Match requires.
+	unquoted, ok := strings.CutPrefix(raw, `"`)
+	unquoted, closed := strings.CutSuffix(unquoted, `"`)
+	// A write only depends on the version part of a bookDetailsETag.
+	unquoted, _, _ = strings.Cut(unquoted, "-")
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if !ok || !closed || err != nil || version <= internal.ZERO {
+		return internal.ZERO, internal.ErrBookModified
+	}
+	return version, nil
+}
+
+// notModified reports whether If-None-Match lists etag, so a read can be
+// answered with 304. Tags are compared weakly, as RFC 9110 prescribes.
+func notModified(r *http.Request, etag string) bool {
+	raw := r.Header.Get("If-None-Match")
+	if raw == internal.EMPTY {
+		return false
+	}
+
+	for _, tag := range strings.Split(raw, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
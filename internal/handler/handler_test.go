@@ -0,0 +1,108 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal/handler"
+	"github.com/amarantec/box/internal/handler/routes"
+	"github.com/amarantec/box/internal/health"
+)
+
+// newRouter serves repos, or empty in-memory repositories when repos is nil,
+// with a health checker unless cfg has one.
+func newRouter(repos *routes.Repositories, cfg routes.Config) http.Handler {
+	if repos == nil {
+		r := routes.NewMemoryRepositories()
+		repos = &r
+	}
+	if cfg.Health == nil {
+		cfg.Health = health.NewChecker(nil, time.Second)
+	}
+	return routes.Router(*repos, cfg)
+}
+
+// serve sends a request with body to h, setting the given header pairs.
+func serve(h http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) handler.Problem {
+	t.Helper()
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("Content-Type = %q, want application/problem+json; body %s", ct, w.Body)
+	}
+	var p handler.Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if p.Status != w.Code {
+		t.Fatalf("problem status = %d, response status %d", p.Status, w.Code)
+	}
+	return p
+}
+
+// decodeResponse reads the {"response": ...} envelope into data.
+func decodeResponse(t *testing.T, body io.Reader, data any) {
+	t.Helper()
+
+	envelope := struct {
+		Response struct {
+			Data    any
+			Success bool
+		} `json:"response"`
+	}{}
+	envelope.Response.Data = data
+	if err := json.NewDecoder(body).Decode(&envelope); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !envelope.Response.Success {
+		t.Fatalf("response Success = false")
+	}
+}
+
+func assertStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d; body %s", w.Code, want, w.Body)
+	}
+}
+
+const validBook = `{
+	"Title": "Dune",
+	"Description": "A desert planet.",
+	"Genres": [{"Name": "Science Fiction"}],
+	"Authors": [{"Name": "Frank Herbert"}],
+	"PublishDate": "1965-08-01T00:00:00Z",
+	"Publisher": {"Name": "Chilton"},
+	"Pages": 412
+}`
+
+// registerBook stores validBook through h and returns its ID.
+func registerBook(t *testing.T, h http.Handler, header ...string) int64 {
+	t.Helper()
+
+	w := serve(h, http.MethodPost, "/books", validBook, header...)
+	assertStatus(t, w, http.StatusCreated)
+
+	var id int64
+	decodeResponse(t, w.Body, &id)
+	return id
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/health"
+)
+
+type HealthHandler struct {
+	Checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{Checker: checker}
+}
+
+// Healthz reports that the process is alive; it never touches the database.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, internal.Response[string]{
+		Data:    health.StatusOK,
+		Success: true,
+		Message: "Service is alive.",
+	})
+}
+
+// Readyz answers 503 until the database is reachable and migrated.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	readiness := h.Checker.Ready(r.Context())
+
+	status, message := http.StatusOK, "Service is ready."
+	if !readiness.Ready {
+		status, message = http.StatusServiceUnavailable, "Service is not ready."
+	}
+
+	writeResponse(w, status, internal.Response[health.Readiness]{
+		Data:    readiness,
+		Success: readiness.Ready,
+		Message: message,
+	})
+}
+
+func (h *HealthHandler) Status(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, internal.Response[health.Status]{
+		Data:    h.Checker.Status(),
+		Success: true,
+		Message: "Service status.",
+	})
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/circulation"
+)
+
+type HoldHandler struct {
+	Service circulation.IHoldService
+}
+
+func NewHoldHandler(service circulation.IHoldService) *HoldHandler {
+	return &HoldHandler{Service: service}
+}
+
+func (h *HoldHandler) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var hold internal.Hold
+
+	if err :=
+		json.NewDecoder(r.Body).Decode(&hold); err != nil {
+		writeError(w, r, badRequest("malformed_body", err))
+		return
+	}
+
+	bookId, err := resourceID(r, "bookId", hold.BookID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	hold.BookID = bookId
+
+	response, err := h.Service.PlaceHold(ctx, hold)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusCreated, response)
+}
+
+func (h *HoldHandler) ListBookHolds(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bookId, err := idParam(r, "bookId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.ListBookHolds(ctx, bookId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *HoldHandler) ListMemberHolds(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	memberId, err := idParam(r, "memberId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.ListMemberHolds(ctx, memberId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *HoldHandler) GetHoldById(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	holdId, err := idParam(r, "holdId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.GetHoldById(ctx, holdId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *HoldHandler) CancelHold(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	holdId, err := idParam(r, "holdId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.CancelHold(ctx, holdId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/ledger"
+	"github.com/shopspring/decimal"
+)
+
+type LedgerHandler struct {
+	Service ledger.ILedgerService
+}
+
+func NewLedgerHandler(service ledger.ILedgerService) *LedgerHandler {
+	return &LedgerHandler{Service: service}
+}
+
+func (h *LedgerHandler) ListEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	memberId, err := idParam(r, "memberId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.ListEntries(ctx, memberId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *LedgerHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	memberId, err := idParam(r, "memberId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.GetBalance(ctx, memberId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *LedgerHandler) RecordPayment(w http.ResponseWriter, r *http.Request) {
+	h.recordCredit(w, r, h.Service.RecordPayment)
+}
+
+func (h *LedgerHandler) RecordWaiver(w http.ResponseWriter, r *http.Request) {
+	h.recordCredit(w, r, h.Service.RecordWaiver)
+}
+
+// recordCredit reads a payment or a waiver for the member of the route and
+// hands it to record.
+func (h *LedgerHandler) recordCredit(w http.ResponseWriter, r *http.Request, record func(ctx context.Context, e internal.LedgerEntry) (internal.Response[internal.LedgerEntry], error)) {
+	ctx := r.Context()
+
+	var entry internal.LedgerEntry
+
+	if err :=
+		json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		writeError(w, r, badRequest("malformed_body", err))
+		return
+	}
+
+	memberId, err := resourceID(r, "memberId", entry.MemberID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	entry.MemberID = memberId
+
+	response, err := record(ctx, entry)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusCreated, response)
+}
+
+// ListBalances lists the members who owe at least the min query parameter,
+// an amount such as 5.00.
+func (h *LedgerHandler) ListBalances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	values := r.URL.Query()
+	var query internal.BalanceQuery
+
+	var err error
+	if raw := values.Get("min"); raw != internal.EMPTY {
+		if query.MinOutstanding, err = decimal.NewFromString(raw); err != nil {
+			err = fmt.Errorf("%w: min must be an amount", internal.ErrInvalidBalanceQuery)
+		}
+	}
+	if err == nil {
+		query.PageSize, err = balanceQueryInt(values.Get("page_size"), "page_size")
+	}
+	if err == nil {
+		query.Offset, err = balanceQueryInt(values.Get("offset"), "offset")
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.ListBalances(ctx, query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func balanceQueryInt(raw, name string) (int, error) {
+	if raw == internal.EMPTY {
+		return internal.ZERO, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return internal.ZERO, fmt.Errorf("%w: %s must be an integer", internal.ErrInvalidBalanceQuery, name)
+	}
+	return v, nil
+}
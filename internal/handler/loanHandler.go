@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/circulation"
+)
+
+type LoanHandler struct {
+	Service circulation.ILoanService
+}
+
+func NewLoanHandler(service circulation.ILoanService) *LoanHandler {
+	return &LoanHandler{Service: service}
+}
+
+func (h *LoanHandler) CheckoutCopy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var loan internal.Loan
+
+	if err :=
+		json.NewDecoder(r.Body).Decode(&loan); err != nil {
+		writeError(w, r, badRequest("malformed_body", err))
+		return
+	}
+
+	response, err := h.Service.CheckoutCopy(ctx, loan)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusCreated, response)
+}
+
+// ListLoans lists loans, filtered by the member_id and state query
+// parameters. Under /members/{memberId}/loans the member comes from the path.
+func (h *LoanHandler) ListLoans(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	values := r.URL.Query()
+	query := internal.LoanQuery{State: internal.LoanState(values.Get("state"))}
+
+	var err error
+	if r.PathValue("memberId") != internal.EMPTY {
+		query.MemberID, err = idParam(r, "memberId")
+	} else if raw := values.Get("member_id"); raw != internal.EMPTY {
+		if query.MemberID, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			err = fmt.Errorf("%w: member_id must be an integer", internal.ErrInvalidLoanQuery)
+		}
+	}
+	if err == nil {
+		query.PageSize, err = loanQueryInt(values.Get("page_size"), "page_size")
+	}
+	if err == nil {
+		query.Offset, err = loanQueryInt(values.Get("offset"), "offset")
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.ListLoans(ctx, query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func loanQueryInt(raw, name string) (int, error) {
+	if raw == internal.EMPTY {
+		return internal.ZERO, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return internal.ZERO, fmt.Errorf("%w: %s must be an integer", internal.ErrInvalidLoanQuery, name)
+	}
+	return v, nil
+}
+
+func (h *LoanHandler) GetLoanById(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	loanId, err := idParam(r, "loanId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.GetLoanById(ctx, loanId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *LoanHandler) RenewLoan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	loanId, err := idParam(r, "loanId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.RenewLoan(ctx, loanId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *LoanHandler) ReturnLoan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	loanId, err := idParam(r, "loanId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.ReturnLoan(ctx, loanId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *LoanHandler) DeclareLost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	loanId, err := idParam(r, "loanId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.DeclareLost(ctx, loanId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/member"
+)
+
+type MemberHandler struct {
+	Service member.IMemberService
+}
+
+func NewMemberHandler(service member.IMemberService) *MemberHandler {
+	return &MemberHandler{Service: service}
+}
+
+func (h *MemberHandler) RegisterMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var member internal.Member
+
+	if err :=
+		json.NewDecoder(r.Body).Decode(&member); err != nil {
+		writeError(w, r, badRequest("malformed_body", err))
+		return
+	}
+
+	response, err := h.Service.RegisterMember(ctx, member)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusCreated, response)
+}
+
+// ListMembers pages through the members by name. The q query parameter
+// keeps the members whose name contains it or whose card number starts with
+// it.
+func (h *MemberHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	values := r.URL.Query()
+	query := internal.MemberQuery{
+		Query:  values.Get("q"),
+		Status: internal.MemberStatus(values.Get("status")),
+	}
+
+	var err error
+	if query.PageSize, err = memberQueryInt(values.Get("page_size"), "page_size"); err == nil {
+		query.Offset, err = memberQueryInt(values.Get("offset"), "offset")
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.ListMembers(ctx, query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func memberQueryInt(raw, name string) (int, error) {
+	if raw == internal.EMPTY {
+		return internal.ZERO, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return internal.ZERO, fmt.Errorf("%w: %s must be an integer", internal.ErrInvalidMemberQuery, name)
+	}
+	return v, nil
+}
+
+func (h *MemberHandler) GetMemberById(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	memberId, err := idParam(r, "memberId")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.GetMemberById(ctx, memberId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *MemberHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var member internal.Member
+
+	if err :=
+		json.NewDecoder(r.Body).Decode(&member); err != nil {
+		writeError(w, r, badRequest("malformed_body", err))
+		return
+	}
+
+	id, err := resourceID(r, "memberId", member.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	member.ID = id
+
+	response, err := h.Service.UpdateMember(ctx, member)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, response)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/amarantec/box/internal"
+)
+
+// idParam reads the numeric path value name, reporting a malformed value as
+// an invalid_parameter error.
+func idParam(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		return internal.ZERO, internal.NewError(internal.ErrBadRequest, "invalid_parameter", name+" must be an integer")
+	}
+	return id, nil
+}
+
+// resourceID returns the ID of the resource being written. REST routes carry
+// it in the {name} path value, which a non-zero ID in the body must match;
+// legacy routes only have the body ID.
+func resourceID(r *http.Request, name string, bodyID int64) (int64, error) {
+	if r.PathValue(name) == internal.EMPTY {
+		return bodyID, nil
+	}
+
+	id, err := idParam(r, name)
+	if err != nil {
+		return internal.ZERO, err
+	}
+	if bodyID != internal.ZERO && bodyID != id {
+		return internal.ZERO, internal.NewError(internal.ErrBadRequest, "id_mismatch", "ID in the body does not match "+name)
+	}
+	return id, nil
+}
+
+// requiredIDQuery reads a numeric query parameter that must be present.
+func requiredIDQuery(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(r.URL.Query().Get(name), 10, 64)
+	if err != nil {
+		return internal.ZERO, internal.NewError(internal.ErrBadRequest, "invalid_parameter", name+" is required and must be an integer")
+	}
+	return id, nil
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/amarantec/box/internal/reference"
+)
+
+// ReferenceHandler serves the genres, authors or publishers books refer to.
+// Param names the path value holding the ID of one of them.
+type ReferenceHandler[T reference.Named] struct {
+	Service reference.IService[T]
+	Param   string
+}
+
+func NewReferenceHandler[T reference.Named](service reference.IService[T], param string) *ReferenceHandler[T] {
+	return &ReferenceHandler[T]{Service: service, Param: param}
+}
+
+// referenceBody is the request body of a reference, decoded apart from T
+// since the fields of a type parameter cannot be read.
+type referenceBody struct {
+	ID   int64
+	Name string
+}
+
+func (h *ReferenceHandler[T]) Register(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body referenceBody
+
+	if err :=
+		json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, badRequest("malformed_body", err))
+		return
+	}
+
+	response, err := h.Service.Register(ctx, T(body))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusCreated, response)
+}
+
+func (h *ReferenceHandler[T]) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	response, err := h.Service.List(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *ReferenceHandler[T]) GetById(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := idParam(r, h.Param)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.GetById(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *ReferenceHandler[T]) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body referenceBody
+
+	if err :=
+		json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, badRequest("malformed_body", err))
+		return
+	}
+
+	id, err := resourceID(r, h.Param, body.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	body.ID = id
+
+	response, err := h.Service.Update(ctx, T(body))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *ReferenceHandler[T]) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := idParam(r, h.Param)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response, err := h.Service.Delete(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/auth"
+)
+
+type RoleHandler struct {
+	Service auth.IRoleService
+}
+
+func NewRoleHandler(service auth.IRoleService) *RoleHandler {
+	return &RoleHandler{Service: service}
+}
+
+func (h *RoleHandler) ListRoleAssignments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	response, err := h.Service.ListRoleAssignments(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+// AssignRole gives the role in the body to the {subject} of the path, the
+// sub claim of a JWT or "key:" and the name of an API key.
+func (h *RoleHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body struct {
+		Role internal.Role
+	}
+
+	if err :=
+		json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, badRequest("malformed_body", err))
+		return
+	}
+
+	response, err := h.Service.AssignRole(ctx, r.PathValue("subject"), body.Role)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (h *RoleHandler) RemoveRoleAssignment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	response, err := h.Service.RemoveRoleAssignment(ctx, r.PathValue("subject"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/amarantec/box/internal/handler"
+)
+
+func authRoutes(mux *http.ServeMux, handler *handler.AuthHandler) {
+	mux.HandleFunc("GET /me", handler.Me)
+}
@@ -0,0 +1,227 @@
+package routes_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/auth"
+	"github.com/amarantec/box/internal/handler/routes"
+	"github.com/amarantec/box/internal/health"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var jwtSecret = []byte("routes-test-secret")
+
+// newAuthRouter returns a router over memory repositories that requires a
+// JWT signed with jwtSecret, treating admins as admins.
+func newAuthRouter(admins ...string) (http.Handler, routes.Repositories) {
+	repos := routes.NewMemoryRepositories()
+	authenticator := auth.NewAuthenticator(repos.APIKeys, repos.Roles, admins, auth.JWTConfig{HMACSecret: jwtSecret})
+	return routes.Router(repos, routes.Config{Health: health.NewChecker(nil, time.Second), Authenticator: authenticator}), repos
+}
+
+// token mints an HS256 JWT for subject, valid for an hour.
+func token(t *testing.T, subject string) string {
+	t.Helper()
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": subject,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(jwtSecret)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return signed
+}
+
+// serveAs serves a request authenticated as subject, or an anonymous one
+// when subject is empty.
+func serveAs(t *testing.T, h http.Handler, subject, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if subject != "" {
+		r.Header.Set("Authorization", "Bearer "+token(t, subject))
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHealthRoutesAuthentication(t *testing.T) {
+	h, _ := newAuthRouter()
+
+	tests := []struct {
+		subject, target string
+		want            int
+	}{
+		{"", "/healthz", http.StatusOK},
+		{"", "/status", http.StatusUnauthorized},
+		{"zoe", "/status", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := serveAs(t, h, tt.subject, http.MethodGet, tt.target, ""); w.Code != tt.want {
+			t.Errorf("GET %s as %q = %d, want %d; body %s", tt.target, tt.subject, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+const dune = `{
+	"Title": "Dune",
+	"Description": "A desert planet.",
+	"Genres": [{"Name": "Science Fiction"}],
+	"Authors": [{"Name": "Frank Herbert"}],
+	"PublishDate": "1965-08-01T00:00:00Z",
+	"Publisher": {"Name": "Chilton"},
+	"Pages": 412
+}`
+
+// newRoleRouter returns a router requiring JWTs, with root as an admin,
+// lena as a librarian and the book Dune registered as 1. Anyone else is a
+// reader.
+func newRoleRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	h, repos := newAuthRouter("root")
+	if _, err := repos.Roles.AssignRole(context.Background(), internal.RoleAssignment{
+		Subject:    "lena",
+		Role:       internal.RoleLibrarian,
+		AssignedBy: "root",
+		AssignedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("AssignRole(lena): %v", err)
+	}
+	if w := serveAs(t, h, "lena", http.MethodPost, "/books", dune); w.Code != http.StatusCreated {
+		t.Fatalf("POST /books as lena = %d, want %d; body %s", w.Code, http.StatusCreated, w.Body)
+	}
+	return h
+}
+
+// forbidden reports whether a request was turned away for its role.
+func forbidden(w *httptest.ResponseRecorder) bool {
+	return w.Code == http.StatusForbidden || w.Code == http.StatusUnauthorized
+}
+
+func TestCatalogPermissions(t *testing.T) {
+	h := newRoleRouter(t)
+
+	tests := []struct {
+		method, target, body string
+		reader               bool
+	}{
+		{http.MethodGet, "/books", "", true},
+		{http.MethodGet, "/books/1", "", true},
+		{http.MethodGet, "/books/search?q=dune", "", true},
+		{http.MethodGet, "/books/export", "", false},
+		{http.MethodGet, "/books/1/history", "", false},
+		{http.MethodGet, "/books/1/history/diff?from=1&to=1", "", false},
+		{http.MethodGet, "/books/1/history/1", "", false},
+		{http.MethodPost, "/books", dune, false},
+		{http.MethodPut, "/books/1", dune, false},
+		{http.MethodDelete, "/books/1", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			if w := serveAs(t, h, "rita", tt.method, tt.target, tt.body); forbidden(w) == tt.reader {
+				t.Fatalf("as a reader = %d, want it let through: %v; body %s", w.Code, tt.reader, w.Body)
+			}
+			if w := serveAs(t, h, "lena", tt.method, tt.target, tt.body); forbidden(w) {
+				t.Fatalf("as a librarian = %d, want it let through; body %s", w.Code, w.Body)
+			}
+		})
+	}
+}
+
+// TestCirculationPermissions checks that holds, members, loans and the
+// ledger are kept from readers.
+func TestCirculationPermissions(t *testing.T) {
+	h := newRoleRouter(t)
+
+	tests := []struct {
+		method, target, body string
+	}{
+		{http.MethodGet, "/books/1/holds", ""},
+		{http.MethodPost, "/books/1/holds", `{"MemberID": 1}`},
+		{http.MethodGet, "/holds/1", ""},
+		{http.MethodGet, "/members", ""},
+		{http.MethodPost, "/members", `{"Name": "Rita", "CardNumber": "R-1"}`},
+		{http.MethodGet, "/members/1", ""},
+		{http.MethodGet, "/loans", ""},
+		{http.MethodPost, "/loans", `{"CopyID": 1, "MemberID": 1}`},
+		{http.MethodPost, "/loans/1/renew", ""},
+		{http.MethodGet, "/members/1/balance", ""},
+		{http.MethodPost, "/members/1/payments", `{"Amount": "1.00"}`},
+		{http.MethodGet, "/fines/outstanding", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			if w := serveAs(t, h, "rita", tt.method, tt.target, tt.body); w.Code != http.StatusForbidden {
+				t.Fatalf("as a reader = %d, want %d; body %s", w.Code, http.StatusForbidden, w.Body)
+			}
+			if w := serveAs(t, h, "lena", tt.method, tt.target, tt.body); forbidden(w) {
+				t.Fatalf("as a librarian = %d, want it let through; body %s", w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	h := newRoleRouter(t)
+
+	for _, subject := range []string{"rita", "lena"} {
+		if w := serveAs(t, h, subject, http.MethodGet, "/admin/roles", ""); w.Code != http.StatusForbidden {
+			t.Errorf("GET /admin/roles as %s = %d, want %d", subject, w.Code, http.StatusForbidden)
+		}
+		if w := serveAs(t, h, subject, http.MethodPut, "/admin/roles/rita", `{"Role": "admin"}`); w.Code != http.StatusForbidden {
+			t.Errorf("PUT /admin/roles/rita as %s = %d, want %d", subject, w.Code, http.StatusForbidden)
+		}
+	}
+
+	w := serveAs(t, h, "root", http.MethodPut, "/admin/roles/rita", `{"Role": "librarian"}`)
+	var assigned struct {
+		Response internal.Response[internal.RoleAssignment] `json:"response"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &assigned); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("PUT /admin/roles/rita as root = %d, %v; body %s", w.Code, err, w.Body)
+	}
+	if a := assigned.Response.Data; a.Subject != "rita" || a.Role != internal.RoleLibrarian || a.AssignedBy != "root" {
+		t.Fatalf("PUT /admin/roles/rita as root = %+v, want rita made a librarian by root", a)
+	}
+	if w := serveAs(t, h, "rita", http.MethodPost, "/books", dune); w.Code != http.StatusCreated {
+		t.Fatalf("POST /books as rita the librarian = %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	w = serveAs(t, h, "root", http.MethodPut, "/admin/roles/root", `{"Role": "reader"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"code":"own_role_change"`) {
+		t.Fatalf("PUT /admin/roles/root as root = %d, want %d own_role_change; body %s", w.Code, http.StatusConflict, w.Body)
+	}
+	if w := serveAs(t, h, "root", http.MethodGet, "/admin/roles", ""); w.Code != http.StatusOK {
+		t.Fatalf("GET /admin/roles as root = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestNoAuthenticatorLeavesRoutesOpen(t *testing.T) {
+	h := newRouter()
+
+	for _, tt := range []struct{ method, target, body string }{
+		{http.MethodPost, "/books", dune},
+		{http.MethodGet, "/books/1/history", ""},
+		{http.MethodGet, "/books/export", ""},
+		{http.MethodGet, "/members", ""},
+		{http.MethodGet, "/admin/roles", ""},
+		{http.MethodGet, "/status", ""},
+	} {
+		if w := serve(h, tt.method, tt.target, tt.body); forbidden(w) {
+			t.Errorf("%s %s without credentials = %d, want it let through", tt.method, tt.target, w.Code)
+		}
+	}
+}
@@ -3,19 +3,43 @@ package routes
 import (
 	"net/http"
 
+	"github.com/amarantec/box/internal"
 	"github.com/amarantec/box/internal/handler"
 )
 
-func bookRoutes(handler *handler.BookHandler) *http.ServeMux {
-	bookMux := http.NewServeMux()
+func bookRoutes(mux, priorityMux *http.ServeMux, guard *handler.AuthHandler, handler *handler.BookHandler) {
+	read := guard.Require(internal.PermCatalogRead)
+	export := guard.Require(internal.PermCatalogExport)
+	history := guard.Require(internal.PermCatalogHistory)
+	write := guard.Require(internal.PermCatalogWrite)
+	purge := guard.Require(internal.PermCatalogPurge)
 
-	bookMux.HandleFunc("/register-book", handler.RegisterBook)
-	bookMux.HandleFunc("/list-books", handler.ListBooks)
-	bookMux.HandleFunc("/get-book/{bookId}", handler.GetBookById)
-	bookMux.HandleFunc("/update-book", handler.UpdateBook)
-	bookMux.HandleFunc("/delete-book/{bookId}", handler.DeleteBook)
-	bookMux.HandleFunc("/list-books-by-genre/{bookGenre}", handler.ListBooksByGenre)
-	bookMux.HandleFunc("/list-books-by-author/{bookAtuhor}", handler.ListBooksByAuthor)
+	mux.HandleFunc("GET /books", read(handler.ListBooks))
+	mux.HandleFunc("POST /books", write(handler.RegisterBook))
+	mux.HandleFunc("GET /books/search", read(handler.SearchBooks))
+	mux.HandleFunc("POST /books/import", write(handler.ImportBooks))
+	mux.HandleFunc("GET /books/export", export(handler.ExportBooks))
+	mux.HandleFunc("GET /books/{bookId}", read(handler.GetBookById))
+	mux.HandleFunc("PUT /books/{bookId}", write(handler.UpdateBook))
+	mux.HandleFunc("PATCH /books/{bookId}", write(handler.PatchBook))
+	mux.HandleFunc("DELETE /books/{bookId}", write(handler.DeleteBook))
+	mux.HandleFunc("GET /books/{bookId}/history", history(handler.ListBookHistory))
+	mux.HandleFunc("GET /books/{bookId}/history/diff", history(handler.DiffBookRevisions))
+	mux.HandleFunc("GET /books/{bookId}/history/{revisionId}", history(handler.GetBookRevision))
 
-	return bookMux
+	mux.HandleFunc("GET /admin/trash/books", write(handler.ListDeletedBooks))
+	mux.HandleFunc("POST /admin/trash/books/{bookId}/restore", write(handler.RestoreBook))
+	mux.HandleFunc("DELETE /admin/trash/books/{bookId}", purge(handler.PurgeBook))
+
+	// Registered on mux, this route would conflict with
+	// "GET /books/{bookId}/history".
+	priorityMux.HandleFunc("GET /books/isbn/{isbn}", read(handler.GetBookByISBN))
+
+	legacy(priorityMux, "POST /books/register-book", "/books", write(handler.RegisterBook))
+	legacy(priorityMux, "GET /books/list-books", "/books", read(handler.ListBooks))
+	legacy(priorityMux, "GET /books/get-book/{bookId}", "/books/{bookId}", read(handler.GetBookById))
+	legacyUpdate(priorityMux, "PUT /books/update-book", "/books/{bookId}", "bookId", write(handler.UpdateBook))
+	legacy(priorityMux, "DELETE /books/delete-book/{bookId}", "/books/{bookId}", write(handler.DeleteBook))
+	legacy(priorityMux, "GET /books/list-books-by-genre/{bookGenre}", "/books?genre={bookGenre}", read(handler.ListBooksByGenre))
+	legacy(priorityMux, "GET /books/list-books-by-author/{bookAuthor}", "/books?author={bookAuthor}", read(handler.ListBooksByAuthor))
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/handler"
+)
+
+func copyRoutes(mux *http.ServeMux, guard *handler.AuthHandler, handler *handler.CopyHandler) {
+	read := guard.Require(internal.PermCatalogRead)
+	write := guard.Require(internal.PermCatalogWrite)
+
+	mux.HandleFunc("GET /books/{bookId}/copies", read(handler.ListCopies))
+	mux.HandleFunc("POST /books/{bookId}/copies", write(handler.AddCopy))
+	mux.HandleFunc("GET /copies/{copyId}", read(handler.GetCopyById))
+	mux.HandleFunc("PUT /copies/{copyId}", write(handler.UpdateCopy))
+	mux.HandleFunc("POST /copies/{copyId}/withdraw", write(handler.WithdrawCopy))
+}
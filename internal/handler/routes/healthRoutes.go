@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/amarantec/box/internal/handler"
+)
+
+// healthRoutes registers the liveness and readiness probes on public, for
+// orchestrators without credentials, and the status report, which tells
+// about the database, on mux.
+func healthRoutes(public, mux *http.ServeMux, handler *handler.HealthHandler) {
+	public.HandleFunc("GET /healthz", handler.Healthz)
+	public.HandleFunc("GET /readyz", handler.Readyz)
+	mux.HandleFunc("GET /status", handler.Status)
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/handler"
+)
+
+func holdRoutes(mux *http.ServeMux, guard *handler.AuthHandler, handler *handler.HoldHandler) {
+	circulation := guard.Require(internal.PermCirculation)
+
+	mux.HandleFunc("GET /books/{bookId}/holds", circulation(handler.ListBookHolds))
+	mux.HandleFunc("POST /books/{bookId}/holds", circulation(handler.PlaceHold))
+	mux.HandleFunc("GET /members/{memberId}/holds", circulation(handler.ListMemberHolds))
+	mux.HandleFunc("GET /holds/{holdId}", circulation(handler.GetHoldById))
+	mux.HandleFunc("POST /holds/{holdId}/cancel", circulation(handler.CancelHold))
+}
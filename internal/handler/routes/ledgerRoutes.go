@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/handler"
+)
+
+func ledgerRoutes(mux *http.ServeMux, guard *handler.AuthHandler, handler *handler.LedgerHandler) {
+	circulation := guard.Require(internal.PermCirculation)
+
+	mux.HandleFunc("GET /members/{memberId}/ledger", circulation(handler.ListEntries))
+	mux.HandleFunc("GET /members/{memberId}/balance", circulation(handler.GetBalance))
+	mux.HandleFunc("POST /members/{memberId}/payments", circulation(handler.RecordPayment))
+	mux.HandleFunc("POST /members/{memberId}/waivers", circulation(handler.RecordWaiver))
+	mux.HandleFunc("GET /fines/outstanding", circulation(handler.ListBalances))
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/middleware"
+)
+
+// The verb-style book routes, such as /books/register-book, predate the
+// REST routes. They keep working until legacySunset but answer with
+// deprecation headers pointing at their replacement. Authors, genres and
+// publishers only ever had REST routes.
+var (
+	legacyDeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	legacySunset       = time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)
+)
+
+func legacy(mux *http.ServeMux, pattern, successor string, handler http.HandlerFunc) {
+	mux.Handle(pattern, middleware.DeprecationMiddleware(handler, legacyDeprecatedAt, legacySunset, successor))
+}
+
+// legacyUpdate registers a legacy update route, such as
+// "PUT /books/update-book", whose resource ID is only sent in the body. The
+// ID is copied into the param path value so that the successor, such as
+// "/books/{bookId}", names the resource being updated.
+func legacyUpdate(mux *http.ServeMux, pattern, successor, param string, handler http.HandlerFunc) {
+	deprecated := middleware.DeprecationMiddleware(handler, legacyDeprecatedAt, legacySunset, successor)
+
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		// The handler reports a body that could not be read in full.
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var resource struct {
+			ID int64
+		}
+		if err == nil && json.Unmarshal(body, &resource) == nil && resource.ID > internal.ZERO {
+			r.SetPathValue(param, strconv.FormatInt(resource.ID, 10))
+		}
+
+		deprecated.ServeHTTP(w, r)
+	})
+}
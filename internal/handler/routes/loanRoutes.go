@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/handler"
+)
+
+func loanRoutes(mux *http.ServeMux, guard *handler.AuthHandler, handler *handler.LoanHandler) {
+	circulation := guard.Require(internal.PermCirculation)
+
+	mux.HandleFunc("GET /loans", circulation(handler.ListLoans))
+	mux.HandleFunc("POST /loans", circulation(handler.CheckoutCopy))
+	mux.HandleFunc("GET /loans/{loanId}", circulation(handler.GetLoanById))
+	mux.HandleFunc("POST /loans/{loanId}/renew", circulation(handler.RenewLoan))
+	mux.HandleFunc("POST /loans/{loanId}/return", circulation(handler.ReturnLoan))
+	mux.HandleFunc("POST /loans/{loanId}/lost", circulation(handler.DeclareLost))
+	mux.HandleFunc("GET /members/{memberId}/loans", circulation(handler.ListLoans))
+}
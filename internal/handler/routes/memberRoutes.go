@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/handler"
+)
+
+func memberRoutes(mux *http.ServeMux, guard *handler.AuthHandler, handler *handler.MemberHandler) {
+	circulation := guard.Require(internal.PermCirculation)
+
+	mux.HandleFunc("GET /members", circulation(handler.ListMembers))
+	mux.HandleFunc("POST /members", circulation(handler.RegisterMember))
+	mux.HandleFunc("GET /members/{memberId}", circulation(handler.GetMemberById))
+	mux.HandleFunc("PUT /members/{memberId}", circulation(handler.UpdateMember))
+}
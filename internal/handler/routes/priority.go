@@ -0,0 +1,41 @@
+package routes
+
+import "net/http"
+
+// withPriority serves the requests whose path is matched by a route on
+// priorityMux from it and everything else from mux. priorityMux holds the
+// routes whose literal segments stand where a REST route has a wildcard and
+// would conflict with it on the same ServeMux: the legacy routes, such as
+// "GET /books/get-book/{bookId}", and others such as "GET /books/isbn/{isbn}",
+// which conflicts with "GET /books/{bookId}/history". Matching by path rather
+// than by method and path lets priorityMux answer a wrong method with 405
+// instead of handing the request to a REST route.
+func withPriority(mux, priorityMux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if matchesPath(priorityMux, r) {
+			priorityMux.ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// routeMethods are the methods the routes are registered with.
+var routeMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// matchesPath reports whether a route on mux matches the path of r with any
+// method.
+func matchesPath(mux *http.ServeMux, r *http.Request) bool {
+	if _, pattern := mux.Handler(r); pattern != "" {
+		return true
+	}
+
+	probe := *r
+	for _, method := range routeMethods {
+		probe.Method = method
+		if _, pattern := mux.Handler(&probe); pattern != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/handler"
+	"github.com/amarantec/box/internal/reference"
+)
+
+// referenceRoutes serves the genres, authors or publishers handled by h
+// under /{plural}.
+func referenceRoutes[T reference.Named](mux *http.ServeMux, guard *handler.AuthHandler, h *handler.ReferenceHandler[T], plural string) {
+	read := guard.Require(internal.PermCatalogRead)
+	write := guard.Require(internal.PermCatalogWrite)
+
+	collection := "/" + plural
+	item := collection + "/{" + h.Param + "}"
+
+	mux.HandleFunc("GET "+collection, read(h.List))
+	mux.HandleFunc("POST "+collection, write(h.Register))
+	mux.HandleFunc("GET "+item, read(h.GetById))
+	mux.HandleFunc("PUT "+item, write(h.Update))
+	mux.HandleFunc("DELETE "+item, write(h.Delete))
+}
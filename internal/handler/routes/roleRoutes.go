@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/handler"
+)
+
+func roleRoutes(mux *http.ServeMux, guard *handler.AuthHandler, handler *handler.RoleHandler) {
+	manage := guard.Require(internal.PermManageRoles)
+
+	mux.HandleFunc("GET /admin/roles", manage(handler.ListRoleAssignments))
+	mux.HandleFunc("PUT /admin/roles/{subject}", manage(handler.AssignRole))
+	mux.HandleFunc("DELETE /admin/roles/{subject}", manage(handler.RemoveRoleAssignment))
+}
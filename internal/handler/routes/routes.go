@@ -2,20 +2,137 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/auth"
 	"github.com/amarantec/box/internal/book"
+	"github.com/amarantec/box/internal/circulation"
 	"github.com/amarantec/box/internal/handler"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/amarantec/box/internal/health"
+	"github.com/amarantec/box/internal/inventory"
+	"github.com/amarantec/box/internal/ledger"
+	"github.com/amarantec/box/internal/member"
+	"github.com/amarantec/box/internal/reference"
 )
 
-func Router(conn *pgxpool.Pool) *http.ServeMux {
-	mux := http.NewServeMux()
+type Repositories struct {
+	Books      book.IBookRepository
+	Authors    reference.IRepository[internal.Author]
+	Genres     reference.IRepository[internal.Genre]
+	Publishers reference.IRepository[internal.Publisher]
+	Copies     inventory.ICopyRepository
+	Members    member.IMemberRepository
+	Loans      circulation.ILoanRepository
+	Holds      circulation.IHoldRepository
+	Ledger     ledger.ILedgerRepository
+	APIKeys    auth.IAPIKeyRepository
+	Roles      auth.IRoleRepository
+}
+
+// NewMemoryRepositories returns empty repositories kept in process memory,
+// for running the server without a database and for tests.
+func NewMemoryRepositories() Repositories {
+	copies := inventory.NewMemoryCopyRepository()
+	holds := circulation.NewMemoryHoldRepository(copies)
+	members := member.NewMemoryMemberRepository()
+	accounts := ledger.NewMemoryLedgerRepository(members)
+	authors := reference.NewMemoryRepository(reference.Authors)
+	genres := reference.NewMemoryRepository(reference.Genres)
+	publishers := reference.NewMemoryRepository(reference.Publishers)
+	return Repositories{
+		Books:      book.NewMemoryBookRepository(authors, genres, publishers, copies),
+		Authors:    authors,
+		Genres:     genres,
+		Publishers: publishers,
+		Copies:     copies,
+		Members:    members,
+		Loans:      circulation.NewMemoryLoanRepository(members, copies, holds, accounts),
+		Holds:      holds,
+		Ledger:     accounts,
+		APIKeys:    auth.NewMemoryAPIKeyRepository(),
+		Roles:      auth.NewMemoryRoleRepository(),
+	}
+}
+
+// Config holds the server options handlers depend on. LoanPolicies replace
+// the default loan policy of their member type and FineRules the default
+// fine rule of their item category; a zero HoldPolicy stands for the default
+// one. Every route but the liveness and readiness probes requires the
+// credential checked by Authenticator, and most a role granting their
+// permission; a nil Authenticator leaves them all open. Book imports and
+// exports get BulkTimeout in place of the server's request timeout.
+type Config struct {
+	Health         *health.Checker
+	Authenticator  *auth.Authenticator
+	RequireIfMatch bool
+	BulkTimeout    time.Duration
+	LoanPolicies   []internal.LoanPolicy
+	HoldPolicy     internal.HoldPolicy
+	FineRules      []internal.FineRule
+}
 
-	bookRepository := book.NewBookRepository(conn)
-	bookService := book.NewBookService(bookRepository)
+func Router(repos Repositories, cfg Config) http.Handler {
+	public, mux, priorityMux := http.NewServeMux(), http.NewServeMux(), http.NewServeMux()
+
+	bookService := book.NewBookService(repos.Books, repos.Copies)
 	bookHandler := handler.NewBookHandler(bookService)
+	bookHandler.RequireIfMatch = cfg.RequireIfMatch
+	bookHandler.BulkTimeout = cfg.BulkTimeout
+
+	authorService := reference.NewService(repos.Authors, reference.Authors)
+	authorHandler := handler.NewReferenceHandler(authorService, "authorId")
+
+	genreService := reference.NewService(repos.Genres, reference.Genres)
+	genreHandler := handler.NewReferenceHandler(genreService, "genreId")
+
+	publisherService := reference.NewService(repos.Publishers, reference.Publishers)
+	publisherHandler := handler.NewReferenceHandler(publisherService, "publisherId")
+
+	holdPolicy := cfg.HoldPolicy
+	if holdPolicy == (internal.HoldPolicy{}) {
+		holdPolicy = circulation.DefaultHoldPolicy()
+	}
+
+	copyService := inventory.NewCopyService(repos.Copies, repos.Books, repos.Holds, holdPolicy)
+	copyHandler := handler.NewCopyHandler(copyService)
+
+	memberService := member.NewMemberService(repos.Members)
+	memberHandler := handler.NewMemberHandler(memberService)
+
+	loanService := circulation.NewLoanService(repos.Loans, repos.Members, cfg.LoanPolicies, cfg.HoldPolicy, cfg.FineRules)
+	loanHandler := handler.NewLoanHandler(loanService)
+
+	holdService := circulation.NewHoldService(repos.Holds, repos.Members, repos.Books, repos.Copies, cfg.HoldPolicy)
+	holdHandler := handler.NewHoldHandler(holdService)
+
+	ledgerService := ledger.NewLedgerService(repos.Ledger, repos.Members)
+	ledgerHandler := handler.NewLedgerHandler(ledgerService)
+
+	roleService := auth.NewRoleService(repos.Roles)
+	roleHandler := handler.NewRoleHandler(roleService)
+
+	authHandler := handler.NewAuthHandler(cfg.Authenticator)
+
+	healthRoutes(public, mux, handler.NewHealthHandler(cfg.Health))
+	authRoutes(mux, authHandler)
+	roleRoutes(mux, authHandler, roleHandler)
+
+	bookRoutes(mux, priorityMux, authHandler, bookHandler)
+	referenceRoutes(mux, authHandler, authorHandler, "authors")
+	referenceRoutes(mux, authHandler, genreHandler, "genres")
+	referenceRoutes(mux, authHandler, publisherHandler, "publishers")
+	copyRoutes(mux, authHandler, copyHandler)
+	memberRoutes(mux, authHandler, memberHandler)
+	loanRoutes(mux, authHandler, loanHandler)
+	holdRoutes(mux, authHandler, holdHandler)
+	ledgerRoutes(mux, authHandler, ledgerHandler)
 
-	mux.Handle("/books/", http.StripPrefix("/books", bookRoutes(bookHandler)))
+	protected := withPriority(mux, priorityMux)
+	if cfg.Authenticator != nil {
+		protected = authHandler.Authenticate(protected)
+	}
+	public.Handle("/", protected)
 
-	return mux
+	return public
 }
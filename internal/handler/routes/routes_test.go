@@ -0,0 +1,120 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal/handler/routes"
+	"github.com/amarantec/box/internal/health"
+)
+
+func newRouter() http.Handler {
+	return routes.Router(routes.NewMemoryRepositories(), routes.Config{Health: health.NewChecker(nil, time.Second)})
+}
+
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	h := newRouter()
+
+	tests := []struct {
+		method, target, wantAllow string
+	}{
+		{http.MethodPatch, "/authors", "GET, HEAD, POST"},
+		{http.MethodPost, "/books/1", "DELETE, GET, HEAD, PATCH, PUT"},
+		{http.MethodDelete, "/books", "GET, HEAD, POST"},
+		{http.MethodDelete, "/books/register-book", "POST"},
+		{http.MethodPost, "/books/get-book/1", "GET, HEAD"},
+		{http.MethodDelete, "/books/isbn/9780441172718", "GET, HEAD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w := serve(h, tt.method, tt.target, "")
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Fatalf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestLegacyRoutes(t *testing.T) {
+	h := newRouter()
+
+	w := serve(h, http.MethodPost, "/books/register-book", `{
+		"Title": "Dune",
+		"Description": "A desert planet.",
+		"Genres": [{"Name": "Science Fiction"}],
+		"Authors": [{"Name": "Frank Herbert"}],
+		"PublishDate": "1965-08-01T00:00:00Z",
+		"Publisher": {"Name": "Chilton"},
+		"Pages": 412
+	}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register-book status = %d, want %d; body %s", w.Code, http.StatusCreated, w.Body)
+	}
+
+	tests := []struct {
+		method, target, body string
+		wantLink             string
+	}{
+		{http.MethodPost, "/books/register-book", `{}`, "</books>"},
+		{http.MethodGet, "/books/get-book/1", "", "</books/1>"},
+		{http.MethodPut, "/books/update-book", `{"ID": 1, "Title": ""}`, "</books/1>"},
+		{http.MethodPut, "/books/update-book", `{"Title": ""}`, "</books>"},
+		{http.MethodGet, "/books/list-books-by-genre/Science%20Fiction", "", "</books?genre=Science+Fiction>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w := serve(h, tt.method, tt.target, tt.body)
+
+			if w.Header().Get("Deprecation") == "" || w.Header().Get("Sunset") == "" {
+				t.Fatalf("headers %v lack Deprecation or Sunset", w.Header())
+			}
+			if want := tt.wantLink + `; rel="successor-version"`; w.Header().Get("Link") != want {
+				t.Fatalf("Link = %s, want %s", w.Header().Get("Link"), want)
+			}
+		})
+	}
+
+	if w := serve(h, http.MethodGet, "/books/1", ""); w.Header().Get("Deprecation") != "" {
+		t.Fatalf("REST route answered with Deprecation %s", w.Header().Get("Deprecation"))
+	}
+}
+
+func TestNoLegacyReferenceRoutes(t *testing.T) {
+	h := newRouter()
+
+	tests := []struct {
+		method, target, body string
+	}{
+		{http.MethodPost, "/genres/register-genre", `{"Name": "Poetry"}`},
+		{http.MethodGet, "/authors/list-authors", ""},
+		{http.MethodGet, "/publishers/get-publisher/1", ""},
+		{http.MethodPut, "/genres/update-genre", `{"ID": 1, "Name": "Poetry"}`},
+		{http.MethodDelete, "/authors/delete-author/1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w := serve(h, tt.method, tt.target, tt.body)
+			if w.Code < http.StatusBadRequest || w.Header().Get("Deprecation") != "" {
+				t.Fatalf("status = %d with Deprecation %q, want an error without it", w.Code, w.Header().Get("Deprecation"))
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check is the outcome of one readiness check. Error says what failed
+// without the cause, which is logged instead since probes are unauthenticated.
+type Check struct {
+	Name   string
+	Status string
+	Error  string
+}
+
+type Readiness struct {
+	Ready  bool
+	Checks []Check
+}
+
+// PoolStats are the pgxpool.Stat figures reported by /status. Durations are
+// encoded in nanoseconds.
+type PoolStats struct {
+	AcquiredConns        int32
+	IdleConns            int32
+	TotalConns           int32
+	MaxConns             int32
+	ConstructingConns    int32
+	AcquireCount         int64
+	EmptyAcquireCount    int64
+	CanceledAcquireCount int64
+	AcquireDuration      time.Duration
+	EmptyAcquireWaitTime time.Duration
+}
+
+// Status describes the running server. Pool is nil with in-memory storage.
+type Status struct {
+	Version   string
+	Storage   string
+	StartedAt time.Time
+	Uptime    string
+	Pool      *PoolStats
+}
+
+// Checker answers liveness, readiness and status probes. Conn is nil when the
+// server runs with in-memory storage, in which case it is always ready.
+type Checker struct {
+	Conn        *pgxpool.Pool
+	StartedAt   time.Time
+	PingTimeout time.Duration
+}
+
+func NewChecker(conn *pgxpool.Pool, pingTimeout time.Duration) *Checker {
+	return &Checker{Conn: conn, StartedAt: time.Now(), PingTimeout: pingTimeout}
+}
+
+// Ready pings the pool within PingTimeout and checks that every migration
+// has been applied.
+func (c *Checker) Ready(ctx context.Context) Readiness {
+	if c.Conn == nil {
+		return Readiness{Ready: true, Checks: []Check{}}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.PingTimeout)
+	defer cancel()
+
+	checks := []Check{
+		check("database", "Database is unreachable.", c.Conn.Ping(ctx)),
+		check("migrations", "Migrations are not up to date.", c.migrationsApplied(ctx)),
+	}
+
+	ready := true
+	for _, ch := range checks {
+		ready = ready && ch.Status == StatusOK
+	}
+	return Readiness{Ready: ready, Checks: checks}
+}
+
+func (c *Checker) migrationsApplied(ctx context.Context) error {
+	pending, err := database.PendingMigrations(ctx, c.Conn)
+	if err != nil {
+		return err
+	}
+	if pending > internal.ZERO {
+		return fmt.Errorf("%d migrations pending", pending)
+	}
+	return nil
+}
+
+func check(name, failure string, err error) Check {
+	if err != nil {
+		log.Printf("Readiness check %s failed: %v", name, err)
+		return Check{Name: name, Status: StatusFail, Error: failure}
+	}
+	return Check{Name: name, Status: StatusOK}
+}
+
+func (c *Checker) Status() Status {
+	status := Status{
+		Version:   internal.BuildVersion(),
+		Storage:   "memory",
+		StartedAt: c.StartedAt,
+		Uptime:    time.Since(c.StartedAt).Round(time.Second).String(),
+	}
+
+	if c.Conn != nil {
+		stat := c.Conn.Stat()
+		status.Storage = "postgres"
+		status.Pool = &PoolStats{
+			AcquiredConns:        stat.AcquiredConns(),
+			IdleConns:            stat.IdleConns(),
+			TotalConns:           stat.TotalConns(),
+			MaxConns:             stat.MaxConns(),
+			ConstructingConns:    stat.ConstructingConns(),
+			AcquireCount:         stat.AcquireCount(),
+			EmptyAcquireCount:    stat.EmptyAcquireCount(),
+			CanceledAcquireCount: stat.CanceledAcquireCount(),
+			AcquireDuration:      stat.AcquireDuration(),
+			EmptyAcquireWaitTime: stat.EmptyAcquireWaitTime(),
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,68 @@
+package internal
+
+import "time"
+
+// HoldStatus tells where a hold is in its life. A hold waits in the queue of
+// its book until a returned copy is set aside for it, then is ready until the
+// member collects the copy or the pickup window ends.
+type HoldStatus string
+
+const (
+	HoldWaiting   HoldStatus = "waiting"
+	HoldReady     HoldStatus = "ready"
+	HoldFulfilled HoldStatus = "fulfilled"
+	HoldCancelled HoldStatus = "cancelled"
+	HoldExpired   HoldStatus = "expired"
+)
+
+// Hold is a member's place in the queue for a book. CopyID is the copy set
+// aside once the hold is ready. ExpiresOn is the last day a waiting hold is
+// still wanted, or the last day to collect the copy of a ready hold.
+// Position is the place of a waiting hold in its book's queue, starting at 1,
+// and zero for any other hold.
+type Hold struct {
+	ID        int64
+	BookID    int64
+	MemberID  int64
+	CopyID    int64
+	Status    HoldStatus
+	Position  int
+	PlacedAt  time.Time
+	ReadyAt   *time.Time
+	ExpiresOn time.Time
+	ClosedAt  *time.Time
+}
+
+// Open reports whether h is still waiting or ready.
+func (h Hold) Open() bool {
+	return h.Status == HoldWaiting || h.Status == HoldReady
+}
+
+// Expired reports whether h is open and past its ExpiresOn day at now.
+func (h Hold) Expired(now time.Time) bool {
+	return h.Open() && Day(now).After(h.ExpiresOn)
+}
+
+// HoldPolicy sets how long a hold may wait for a copy and how long a copy
+// set aside for it is kept.
+type HoldPolicy struct {
+	MaxWaitDays int
+	PickupDays  int
+}
+
+// WaitUntil is the last day a hold placed at placedAt is wanted.
+func (p HoldPolicy) WaitUntil(placedAt time.Time) time.Time {
+	return Day(placedAt).AddDate(0, 0, p.MaxWaitDays)
+}
+
+// PickupBy is the last day to collect a copy set aside at readyAt.
+func (p HoldPolicy) PickupBy(readyAt time.Time) time.Time {
+	return Day(readyAt).AddDate(0, 0, p.PickupDays)
+}
+
+// HoldQuery selects the open holds of a book, in queue order, or of a
+// member, oldest first. One of BookID and MemberID is set.
+type HoldQuery struct {
+	BookID   int64
+	MemberID int64
+}
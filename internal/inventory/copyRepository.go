@@ -0,0 +1,240 @@
+package inventory
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ICopyRepository interface {
+	AddCopy(ctx context.Context, c internal.BookCopy) (int64, error)
+	ListCopies(ctx context.Context, bookId int64) ([]internal.BookCopy, error)
+	GetCopyById(ctx context.Context, copyId int64) (internal.BookCopy, error)
+	UpdateCopy(ctx context.Context, c internal.BookCopy) (bool, error)
+	WithdrawCopy(ctx context.Context, copyId int64) (bool, error)
+	ChangeCopyStatus(ctx context.Context, copyId int64, from, to internal.CopyStatus) (bool, error)
+	CountCopies(ctx context.Context, bookId int64) (internal.BookAvailability, error)
+}
+
+// copiesBarcodeKey is the unique index on the copy barcodes.
+const copiesBarcodeKey = "copies_barcode_key"
+
+const copyColumns = `id, book_id, barcode, condition, category, acquired_on, price, status, created_at, updated_at, withdrawn_at`
+
+func scanCopy(row pgx.Row) (internal.BookCopy, error) {
+	var c internal.BookCopy
+	if err := row.Scan(
+		&c.ID,
+		&c.BookID,
+		&c.Barcode,
+		&c.Condition,
+		&c.Category,
+		&c.AcquiredOn,
+		&c.Price,
+		&c.Status,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&c.WithdrawnAt,
+	); err != nil {
+		return internal.BookCopy{}, err
+	}
+	return c, nil
+}
+
+// lockCopy reads a copy inside tx and locks its row until tx ends.
+func lockCopy(ctx context.Context, tx pgx.Tx, copyId int64) (internal.BookCopy, error) {
+	c, err := scanCopy(
+		tx.QueryRow(
+			ctx,
+			`SELECT `+copyColumns+` FROM copies WHERE id = $1 FOR UPDATE;`, copyId))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.BookCopy{}, internal.ErrCopyNotFound
+		}
+		return internal.BookCopy{}, err
+	}
+	return c, nil
+}
+
+type copyRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewCopyRepository(conn *pgxpool.Pool) ICopyRepository {
+	return &copyRepository{Conn: conn}
+}
+
+// AddCopy stores a copy of a book that is not deleted.
+func (r *copyRepository) AddCopy(ctx context.Context, c internal.BookCopy) (int64, error) {
+	err :=
+		r.Conn.QueryRow(
+			ctx,
+			`INSERT INTO copies (book_id, barcode, condition, category, acquired_on, price, status)
+            SELECT $1, $2, $3, $4, $5, $6, $7 WHERE EXISTS (SELECT 1 FROM books WHERE id = $1 AND deleted_at IS NULL)
+            RETURNING id;`, c.BookID, c.Barcode, c.Condition, c.Category, c.AcquiredOn, c.Price, internal.CopyAvailable).Scan(&c.ID)
+
+	if err != nil {
+		switch {
+		case err == pgx.ErrNoRows, database.IsForeignKeyViolation(err):
+			return internal.ZERO, internal.ErrBookNotFound
+		case database.IsUniqueViolationOf(err, copiesBarcodeKey):
+			return internal.ZERO, internal.ErrBarcodeAlreadyExists
+		}
+		return internal.ZERO, err
+	}
+
+	return c.ID, nil
+}
+
+func (r *copyRepository) ListCopies(ctx context.Context, bookId int64) ([]internal.BookCopy, error) {
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			`SELECT `+copyColumns+` FROM copies WHERE book_id = $1 ORDER BY id;`, bookId)
+
+	if err != nil {
+		return []internal.BookCopy{}, err
+	}
+
+	defer rows.Close()
+
+	copies := []internal.BookCopy{}
+	for rows.Next() {
+		c, err := scanCopy(rows)
+		if err != nil {
+			return []internal.BookCopy{}, err
+		}
+		copies = append(copies, c)
+	}
+
+	return copies, rows.Err()
+}
+
+func (r *copyRepository) GetCopyById(ctx context.Context, copyId int64) (internal.BookCopy, error) {
+	c, err := scanCopy(
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT `+copyColumns+` FROM copies WHERE id = $1;`, copyId))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.BookCopy{}, internal.ErrCopyNotFound
+		}
+		return internal.BookCopy{}, err
+	}
+
+	return c, nil
+}
+
+// UpdateCopy rewrites the barcode, condition, category, acquisition date and
+// price of a copy and, when c.Status is set, its status.
+func (r *copyRepository) UpdateCopy(ctx context.Context, c internal.BookCopy) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		current, err := lockCopy(ctx, tx, c.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkStatusChange(current, c.Status); err != nil {
+			return err
+		}
+		if c.Status == internal.EMPTY {
+			c.Status = current.Status
+		}
+
+		_, err =
+			tx.Exec(
+				ctx,
+				`UPDATE copies SET barcode = $2, condition = $3, category = $4, acquired_on = $5, price = $6, status = $7, updated_at = $8 WHERE id = $1;`,
+				c.ID, c.Barcode, c.Condition, c.Category, c.AcquiredOn, c.Price, c.Status, time.Now())
+		return err
+	})
+
+	if err != nil {
+		if database.IsUniqueViolationOf(err, copiesBarcodeKey) {
+			return false, internal.ErrBarcodeAlreadyExists
+		}
+		return false, err
+	}
+
+	log.Printf("Copy with ID %d updated.\n", c.ID)
+	return true, nil
+}
+
+func (r *copyRepository) WithdrawCopy(ctx context.Context, copyId int64) (bool, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		current, err := lockCopy(ctx, tx, copyId)
+		if err != nil {
+			return err
+		}
+		if err := checkWithdraw(current); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		_, err =
+			tx.Exec(
+				ctx,
+				`UPDATE copies SET status = $2, updated_at = $3, withdrawn_at = $3 WHERE id = $1;`, copyId, internal.CopyWithdrawn, now)
+		return err
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("Copy with ID %d withdrawn.\n", copyId)
+	return true, nil
+}
+
+// ChangeCopyStatus moves a copy from status from to status to, as lending
+// and returning it does. It reports false when the copy no longer has status
+// from.
+func (r *copyRepository) ChangeCopyStatus(ctx context.Context, copyId int64, from, to internal.CopyStatus) (bool, error) {
+	tag, err :=
+		r.Conn.Exec(
+			ctx,
+			`UPDATE copies SET status = $3, updated_at = $4 WHERE id = $1 AND status = $2;`, copyId, from, to, time.Now())
+
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == internal.ZERO {
+		if _, err := r.GetCopyById(ctx, copyId); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (r *copyRepository) CountCopies(ctx context.Context, bookId int64) (internal.BookAvailability, error) {
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			`SELECT status, COUNT(*) FROM copies WHERE book_id = $1 GROUP BY status;`, bookId)
+
+	if err != nil {
+		return internal.BookAvailability{}, err
+	}
+
+	defer rows.Close()
+
+	var availability internal.BookAvailability
+	for rows.Next() {
+		var status internal.CopyStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return internal.BookAvailability{}, err
+		}
+		availability.Add(status, count)
+	}
+
+	return availability, rows.Err()
+}
@@ -0,0 +1,94 @@
+package inventory_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database/fixturetest"
+	"github.com/shopspring/decimal"
+)
+
+func TestCopies(t *testing.T) {
+	fixturetest.Run(t, "inventory_test", testCopies)
+}
+
+func testCopies(t *testing.T, r fixturetest.Repositories) {
+	ctx := context.Background()
+
+	bookId := fixturetest.RegisterBook(t, r, "Shelved")
+	otherId := fixturetest.RegisterBook(t, r, "Elsewhere")
+
+	var copyIds []int64
+	for _, barcode := range []string{"B-001", "B-002", "B-003"} {
+		copyId, err := r.Copies.AddCopy(ctx, fixturetest.NewCopy(bookId, barcode))
+		if err != nil {
+			t.Fatalf("AddCopy(%s): %v", barcode, err)
+		}
+		copyIds = append(copyIds, copyId)
+	}
+	if _, err := r.Copies.AddCopy(ctx, fixturetest.NewCopy(otherId, "B-004")); err != nil {
+		t.Fatalf("AddCopy(B-004): %v", err)
+	}
+	if _, err := r.Copies.AddCopy(ctx, fixturetest.NewCopy(otherId, "B-001")); !errors.Is(err, internal.ErrBarcodeAlreadyExists) {
+		t.Fatalf("AddCopy(duplicate barcode) error = %v, want %v", err, internal.ErrBarcodeAlreadyExists)
+	}
+
+	got, err := r.Copies.GetCopyById(ctx, copyIds[0])
+	if err != nil {
+		t.Fatalf("GetCopyById: %v", err)
+	}
+	if got.BookID != bookId || got.Status != internal.CopyAvailable || !got.Price.Equal(decimal.RequireFromString("24.9")) || got.CreatedAt.IsZero() {
+		t.Fatalf("GetCopyById = %+v, want an available copy of book %d priced 24.90", got, bookId)
+	}
+	if _, err := r.Copies.GetCopyById(ctx, 4242); !errors.Is(err, internal.ErrCopyNotFound) {
+		t.Fatalf("GetCopyById(missing) error = %v, want %v", err, internal.ErrCopyNotFound)
+	}
+
+	lost := got
+	lost.Status = internal.CopyLost
+	lost.Condition = internal.ConditionPoor
+	if _, err := r.Copies.UpdateCopy(ctx, lost); err != nil {
+		t.Fatalf("UpdateCopy: %v", err)
+	}
+	if _, err := r.Copies.WithdrawCopy(ctx, copyIds[1]); err != nil {
+		t.Fatalf("WithdrawCopy: %v", err)
+	}
+	if _, err := r.Copies.WithdrawCopy(ctx, copyIds[1]); !errors.Is(err, internal.ErrCopyWithdrawn) {
+		t.Fatalf("WithdrawCopy(withdrawn) error = %v, want %v", err, internal.ErrCopyWithdrawn)
+	}
+
+	withdrawn, err := r.Copies.GetCopyById(ctx, copyIds[1])
+	if err != nil {
+		t.Fatalf("GetCopyById: %v", err)
+	}
+	withdrawn.Status = internal.CopyAvailable
+	if _, err := r.Copies.UpdateCopy(ctx, withdrawn); !errors.Is(err, internal.ErrCopyWithdrawn) {
+		t.Fatalf("UpdateCopy(withdrawn) error = %v, want %v", err, internal.ErrCopyWithdrawn)
+	}
+
+	copies, err := r.Copies.ListCopies(ctx, bookId)
+	if err != nil {
+		t.Fatalf("ListCopies: %v", err)
+	}
+	var statuses []internal.CopyStatus
+	for _, c := range copies {
+		statuses = append(statuses, c.Status)
+	}
+	if want := []internal.CopyStatus{internal.CopyLost, internal.CopyWithdrawn, internal.CopyAvailable}; !slices.Equal(statuses, want) {
+		t.Fatalf("ListCopies statuses = %v, want %v", statuses, want)
+	}
+	if copies[0].Condition != internal.ConditionPoor || copies[1].WithdrawnAt == nil {
+		t.Fatalf("ListCopies = %+v, want the update and withdrawal recorded", copies)
+	}
+
+	availability, err := r.Copies.CountCopies(ctx, bookId)
+	if err != nil {
+		t.Fatalf("CountCopies: %v", err)
+	}
+	if want := (internal.BookAvailability{Total: 2, Available: 1, Lost: 1, Withdrawn: 1}); availability != want {
+		t.Fatalf("CountCopies = %+v, want %+v", availability, want)
+	}
+}
@@ -0,0 +1,263 @@
+package inventory
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/amarantec/box/internal"
+)
+
+const maxBarcodeLength = 64
+
+type ICopyService interface {
+	AddCopy(ctx context.Context, c internal.BookCopy) (internal.Response[int64], error)
+	ListCopies(ctx context.Context, bookId int64) (internal.Response[[]internal.BookCopy], error)
+	GetCopyById(ctx context.Context, copyId int64) (internal.Response[internal.BookCopy], error)
+	UpdateCopy(ctx context.Context, c internal.BookCopy) (internal.Response[bool], error)
+	WithdrawCopy(ctx context.Context, copyId int64) (internal.Response[bool], error)
+}
+
+// IBookReader finds the book copies belong to. book.IBookRepository
+// satisfies it; it is declared here because the book package depends on
+// this one for availability counts.
+type IBookReader interface {
+	GetBookById(ctx context.Context, bookId int64) (internal.Book, error)
+}
+
+// IHoldAllocator sets available copies aside for waiting holds.
+// circulation.IHoldRepository satisfies it; it is declared here because the
+// circulation package depends on this one.
+type IHoldAllocator interface {
+	AllocateCopy(ctx context.Context, copyId int64, policy internal.HoldPolicy, now time.Time) (bool, error)
+}
+
+type copyService struct {
+	copyRepo   ICopyRepository
+	bookRepo   IBookReader
+	holdRepo   IHoldAllocator
+	holdPolicy internal.HoldPolicy
+}
+
+// NewCopyService manages the copies of books, setting every copy that
+// becomes available aside for the first waiting hold on its book under
+// holdPolicy.
+func NewCopyService(repository ICopyRepository, bookRepository IBookReader, holdRepository IHoldAllocator, holdPolicy internal.HoldPolicy) ICopyService {
+	return &copyService{
+		copyRepo:   repository,
+		bookRepo:   bookRepository,
+		holdRepo:   holdRepository,
+		holdPolicy: holdPolicy,
+	}
+}
+
+// allocate sets an available copy aside for the first waiting hold on its
+// book. The copy itself has already been saved, so a failure is only
+// logged: the hold keeps its place for the next copy returned.
+func (s *copyService) allocate(ctx context.Context, copyId int64) {
+	allocated, err := s.holdRepo.AllocateCopy(ctx, copyId, s.holdPolicy, time.Now())
+	if err != nil {
+		log.Printf("Could not set copy %d aside for a hold. Error: %v", copyId, err)
+		return
+	}
+	if allocated {
+		log.Printf("Copy with ID %d set aside for a hold.\n", copyId)
+	}
+}
+
+// AddCopy registers a new, available copy of a book that is not deleted,
+// and sets it aside if members are waiting for the book.
+func (s *copyService) AddCopy(ctx context.Context, c internal.BookCopy) (internal.Response[int64], error) {
+	var response internal.Response[int64]
+
+	normalizeCopy(&c)
+	if err := validateCopy(c, false, time.Now()); err != nil {
+		response.Data = internal.ZERO
+		response.Success = false
+		return response, err
+	}
+
+	if _, err := s.bookRepo.GetBookById(ctx, c.BookID); err != nil {
+		response.Data = internal.ZERO
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.copyRepo.AddCopy(ctx, c)
+	if err != nil {
+		response.Data = internal.ZERO
+		response.Success = false
+		return response, err
+	}
+	s.allocate(ctx, data)
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Copy added successfully."
+	return response, nil
+}
+
+func (s *copyService) ListCopies(ctx context.Context, bookId int64) (internal.Response[[]internal.BookCopy], error) {
+	var response internal.Response[[]internal.BookCopy]
+
+	if _, err := s.bookRepo.GetBookById(ctx, bookId); err != nil {
+		response.Data = []internal.BookCopy{}
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.copyRepo.ListCopies(ctx, bookId)
+	if err != nil {
+		response.Data = []internal.BookCopy{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "All copies of the book."
+	return response, nil
+}
+
+func (s *copyService) GetCopyById(ctx context.Context, copyId int64) (internal.Response[internal.BookCopy], error) {
+	var response internal.Response[internal.BookCopy]
+
+	data, err := s.copyRepo.GetCopyById(ctx, copyId)
+	if err != nil {
+		response.Data = internal.BookCopy{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Copy found successfully."
+	return response, nil
+}
+
+// UpdateCopy rewrites the details of a copy. Its status may be left empty to
+// keep it, or moved between available and lost. A copy left available is
+// set aside if members are waiting for its book.
+func (s *copyService) UpdateCopy(ctx context.Context, c internal.BookCopy) (internal.Response[bool], error) {
+	var response internal.Response[bool]
+
+	normalizeCopy(&c)
+	if err := validateCopy(c, true, time.Now()); err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.copyRepo.UpdateCopy(ctx, c)
+	if err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+	if c.Status != internal.CopyLost {
+		s.allocate(ctx, c.ID)
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Copy updated successfully."
+	return response, nil
+}
+
+// WithdrawCopy takes a copy out of the collection for good. A copy on loan
+// has to be returned first, and a copy set aside for a hold collected or
+// released.
+func (s *copyService) WithdrawCopy(ctx context.Context, copyId int64) (internal.Response[bool], error) {
+	var response internal.Response[bool]
+
+	data, err := s.copyRepo.WithdrawCopy(ctx, copyId)
+	if err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Copy withdrawn successfully."
+	return response, nil
+}
+
+func normalizeCopy(c *internal.BookCopy) {
+	c.Barcode = strings.TrimSpace(c.Barcode)
+	c.Condition = internal.CopyCondition(strings.ToLower(strings.TrimSpace(string(c.Condition))))
+	c.Category = internal.ItemCategory(strings.ToLower(strings.TrimSpace(string(c.Category))))
+	if c.Category == internal.EMPTY {
+		c.Category = internal.CategoryGeneral
+	}
+	c.Status = internal.CopyStatus(strings.ToLower(strings.TrimSpace(string(c.Status))))
+}
+
+// validateCopy checks a normalized copy sent by a client. The book and the
+// status of a new copy are not the client's to set: the book comes from the
+// route and every copy starts available.
+func validateCopy(c internal.BookCopy, isUpdate bool, now time.Time) error {
+	v := &internal.ValidationError{}
+
+	if isUpdate {
+		v.Check(c.ID > internal.ZERO, "ID", "is required")
+	} else {
+		v.Check(c.ID == internal.ZERO, "ID", "is assigned by the server and must not be set")
+		v.Check(c.Status == internal.EMPTY, "Status", "is managed by the server and must not be set")
+	}
+	v.Check(c.CreatedAt.IsZero(), "CreatedAt", "is managed by the server and must not be set")
+	v.Check(c.UpdatedAt == nil, "UpdatedAt", "is managed by the server and must not be set")
+	v.Check(c.WithdrawnAt == nil, "WithdrawnAt", "is managed by the server and must not be set")
+
+	v.Check(c.Barcode != internal.EMPTY, "Barcode", "must not be empty")
+	v.Check(utf8.RuneCountInString(c.Barcode) <= maxBarcodeLength,
+		"Barcode", "must be at most %d characters", maxBarcodeLength)
+	v.Check(slices.Contains(internal.CopyConditions, c.Condition),
+		"Condition", "must be one of new, good, fair, poor or damaged")
+	v.Check(slices.Contains(internal.ItemCategories, c.Category),
+		"Category", "must be one of general, short-loan or audiovisual")
+
+	if c.AcquiredOn.IsZero() {
+		v.Add("AcquiredOn", "is required")
+	} else {
+		v.Check(!c.AcquiredOn.After(now), "AcquiredOn", "must not be in the future")
+	}
+	v.Check(!c.Price.IsNegative(), "Price", "must not be negative")
+	v.Check(c.Price.Equal(c.Price.Round(2)), "Price", "must have at most two decimal places")
+
+	return v.Err()
+}
+
+// checkStatusChange reports why a copy in current cannot be updated to
+// status next. An empty next keeps the current status.
+func checkStatusChange(current internal.BookCopy, next internal.CopyStatus) error {
+	switch {
+	case current.Status == internal.CopyWithdrawn:
+		return internal.ErrCopyWithdrawn
+	case next == internal.EMPTY || next == current.Status:
+		return nil
+	case current.Status == internal.CopyOnLoan:
+		return internal.ErrCopyOnLoan
+	case current.Status == internal.CopyOnHold:
+		return internal.ErrCopyOnHold
+	case next != internal.CopyAvailable && next != internal.CopyLost:
+		return internal.ErrCopyStatusChange
+	}
+	return nil
+}
+
+// checkWithdraw reports why a copy in current cannot be withdrawn.
+func checkWithdraw(current internal.BookCopy) error {
+	switch current.Status {
+	case internal.CopyWithdrawn:
+		return internal.ErrCopyWithdrawn
+	case internal.CopyOnLoan:
+		return internal.ErrCopyOnLoan
+	case internal.CopyOnHold:
+		return internal.ErrCopyOnHold
+	}
+	return nil
+}
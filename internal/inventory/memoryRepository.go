@@ -0,0 +1,194 @@
+package inventory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/amarantec/box/internal"
+)
+
+// memoryCopyRepository is an ICopyRepository kept in process memory. It does
+// not know about books, so unlike copyRepository it accepts copies of any
+// book ID and keeps the copies of a purged book.
+type memoryCopyRepository struct {
+	mu     sync.RWMutex
+	copies map[int64]internal.BookCopy
+	nextID int64
+}
+
+func NewMemoryCopyRepository() ICopyRepository {
+	return &memoryCopyRepository{copies: map[int64]internal.BookCopy{}}
+}
+
+// barcodeTaken reports whether a copy other than copyId has barcode. The
+// caller must hold r.mu.
+func (r *memoryCopyRepository) barcodeTaken(barcode string, copyId int64) bool {
+	for _, c := range r.copies {
+		if c.ID != copyId && c.Barcode == barcode {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryCopyRepository) AddCopy(ctx context.Context, c internal.BookCopy) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.barcodeTaken(c.Barcode, internal.ZERO) {
+		return internal.ZERO, internal.ErrBarcodeAlreadyExists
+	}
+
+	r.nextID++
+	c.ID = r.nextID
+	c.Status = internal.CopyAvailable
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = nil
+	c.WithdrawnAt = nil
+	r.copies[c.ID] = c
+
+	return c.ID, nil
+}
+
+func (r *memoryCopyRepository) ListCopies(ctx context.Context, bookId int64) ([]internal.BookCopy, error) {
+	if err := ctx.Err(); err != nil {
+		return []internal.BookCopy{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	copies := []internal.BookCopy{}
+	for _, c := range r.copies {
+		if c.BookID == bookId {
+			copies = append(copies, c)
+		}
+	}
+
+	slices.SortFunc(copies, func(a, b internal.BookCopy) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return copies, nil
+}
+
+func (r *memoryCopyRepository) GetCopyById(ctx context.Context, copyId int64) (internal.BookCopy, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.BookCopy{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.copies[copyId]
+	if !ok {
+		return internal.BookCopy{}, internal.ErrCopyNotFound
+	}
+	return c, nil
+}
+
+func (r *memoryCopyRepository) UpdateCopy(ctx context.Context, c internal.BookCopy) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.copies[c.ID]
+	if !ok {
+		return false, internal.ErrCopyNotFound
+	}
+	if err := checkStatusChange(current, c.Status); err != nil {
+		return false, err
+	}
+	if r.barcodeTaken(c.Barcode, c.ID) {
+		return false, internal.ErrBarcodeAlreadyExists
+	}
+
+	now := time.Now()
+	current.Barcode = c.Barcode
+	current.Condition = c.Condition
+	current.Category = c.Category
+	current.AcquiredOn = c.AcquiredOn
+	current.Price = c.Price
+	if c.Status != internal.EMPTY {
+		current.Status = c.Status
+	}
+	current.UpdatedAt = &now
+	r.copies[c.ID] = current
+
+	return true, nil
+}
+
+func (r *memoryCopyRepository) WithdrawCopy(ctx context.Context, copyId int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.copies[copyId]
+	if !ok {
+		return false, internal.ErrCopyNotFound
+	}
+	if err := checkWithdraw(c); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	c.Status = internal.CopyWithdrawn
+	c.UpdatedAt = &now
+	c.WithdrawnAt = &now
+	r.copies[copyId] = c
+
+	return true, nil
+}
+
+func (r *memoryCopyRepository) ChangeCopyStatus(ctx context.Context, copyId int64, from, to internal.CopyStatus) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.copies[copyId]
+	if !ok {
+		return false, internal.ErrCopyNotFound
+	}
+	if c.Status != from {
+		return false, nil
+	}
+
+	now := time.Now()
+	c.Status = to
+	c.UpdatedAt = &now
+	r.copies[copyId] = c
+
+	return true, nil
+}
+
+func (r *memoryCopyRepository) CountCopies(ctx context.Context, bookId int64) (internal.BookAvailability, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.BookAvailability{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var availability internal.BookAvailability
+	for _, c := range r.copies {
+		if c.BookID == bookId {
+			availability.Add(c.Status, 1)
+		}
+	}
+	return availability, nil
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ISBNs are stored and compared in their normalized form: digits only, with
+// an upper-case X as the check digit of an ISBN-10 worth ten. Books are
+// unique by ISBN-13; the ISBN-10, when there is one, is derived from it.
+const (
+	ISBN10Length = 10
+	ISBN13Length = 13
+
+	// isbnPrefix is the only ISBN-13 prefix with ISBN-10 equivalents.
+	isbnPrefix = "978"
+)
+
+// NormalizeISBN removes the hyphens and spaces an ISBN is usually printed
+// with and upper-cases its check digit.
+func NormalizeISBN(isbn string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, isbn))
+}
+
+// ValidISBN10 reports whether a normalized isbn is an ISBN-10 with a correct
+// check digit.
+func ValidISBN10(isbn string) bool {
+	return len(isbn) == ISBN10Length && isDigits(isbn[:ISBN10Length-1]) &&
+		isbn10CheckDigit(isbn[:ISBN10Length-1]) == isbn[ISBN10Length-1]
+}
+
+// ValidISBN13 reports whether a normalized isbn is an ISBN-13 with a correct
+// check digit.
+func ValidISBN13(isbn string) bool {
+	return len(isbn) == ISBN13Length && isDigits(isbn) &&
+		isbn13CheckDigit(isbn[:ISBN13Length-1]) == isbn[ISBN13Length-1]
+}
+
+// ISBN10To13 converts a valid ISBN-10 to its ISBN-13.
+func ISBN10To13(isbn10 string) string {
+	body := isbnPrefix + isbn10[:ISBN10Length-1]
+	return body + string(isbn13CheckDigit(body))
+}
+
+// ISBN13To10 converts a valid ISBN-13 to its ISBN-10. Only ISBN-13s starting
+// with 978 have one.
+func ISBN13To10(isbn13 string) (string, bool) {
+	if !strings.HasPrefix(isbn13, isbnPrefix) {
+		return EMPTY, false
+	}
+	body := isbn13[len(isbnPrefix) : ISBN13Length-1]
+	return body + string(isbn10CheckDigit(body)), true
+}
+
+// ParseISBN reads an ISBN-10 or ISBN-13 as a client may write it and returns
+// the ISBN-13 books are looked up by.
+func ParseISBN(isbn string) (string, error) {
+	isbn = NormalizeISBN(isbn)
+	switch {
+	case ValidISBN13(isbn):
+		return isbn, nil
+	case ValidISBN10(isbn):
+		return ISBN10To13(isbn), nil
+	}
+	return EMPTY, ErrInvalidISBN
+}
+
+// SetISBN stores an ISBN of either form in the matching field of b, going by
+// its normalized length. Any other length is kept as an ISBN-13 for
+// validation to report.
+func (b *Book) SetISBN(isbn string) {
+	if len(NormalizeISBN(isbn)) == ISBN10Length {
+		b.ISBN10 = isbn
+		return
+	}
+	b.ISBN13 = isbn
+}
+
+// isbn10CheckDigit computes the check digit following the first nine digits
+// of an ISBN-10: their sum weighted 10 down to 2 plus the check digit must
+// be a multiple of 11.
+func isbn10CheckDigit(body string) byte {
+	sum := ZERO
+	for i := range len(body) {
+		sum += (ISBN10Length - i) * int(body[i]-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// isbn13CheckDigit computes the check digit following the first twelve
+// digits of an ISBN-13, weighted alternately 1 and 3.
+func isbn13CheckDigit(body string) byte {
+	sum := ZERO
+	for i := range len(body) {
+		digit := int(body[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for i := range len(s) {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestISBNCheckDigits(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		fn   func(string) byte
+		want byte
+	}{
+		{name: "isbn-10 digit", body: "030640615", fn: isbn10CheckDigit, want: '2'},
+		{name: "isbn-10 X", body: "080442957", fn: isbn10CheckDigit, want: 'X'},
+		{name: "isbn-13", body: "978030640615", fn: isbn13CheckDigit, want: '7'},
+		{name: "isbn-13 zero", body: "979888645174", fn: isbn13CheckDigit, want: '0'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.body); got != tt.want {
+				t.Fatalf("check digit of %s = %c, want %c", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestISBNConversion(t *testing.T) {
+	tests := []struct {
+		name   string
+		isbn10 string
+		isbn13 string
+	}{
+		{name: "digit check", isbn10: "0306406152", isbn13: "9780306406157"},
+		{name: "X check", isbn10: "080442957X", isbn13: "9780804429573"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ISBN10To13(tt.isbn10); got != tt.isbn13 {
+				t.Fatalf("ISBN10To13(%s) = %s, want %s", tt.isbn10, got, tt.isbn13)
+			}
+			got, ok := ISBN13To10(tt.isbn13)
+			if !ok || got != tt.isbn10 {
+				t.Fatalf("ISBN13To10(%s) = %s, %t, want %s, true", tt.isbn13, got, ok, tt.isbn10)
+			}
+		})
+	}
+
+	if got, ok := ISBN13To10("9798886451740"); ok {
+		t.Fatalf("ISBN13To10 of a 979 ISBN = %s, true, want no ISBN-10", got)
+	}
+}
+
+func TestParseISBN(t *testing.T) {
+	tests := []struct {
+		name    string
+		isbn    string
+		want    string
+		wantErr error
+	}{
+		{name: "isbn-13", isbn: "9780306406157", want: "9780306406157"},
+		{name: "hyphenated isbn-13", isbn: "978-0-306-40615-7", want: "9780306406157"},
+		{name: "isbn-13 without isbn-10", isbn: "979-8-88645-174-0", want: "9798886451740"},
+		{name: "isbn-10", isbn: "0306406152", want: "9780306406157"},
+		{name: "hyphenated isbn-10", isbn: "0-306-40615-2", want: "9780306406157"},
+		{name: "X check digit", isbn: "080442957X", want: "9780804429573"},
+		{name: "lower-case x check digit", isbn: "0-8044-2957-x", want: "9780804429573"},
+		{name: "wrong isbn-13 checksum", isbn: "9780306406158", wantErr: ErrInvalidISBN},
+		{name: "wrong isbn-10 checksum", isbn: "0306406153", wantErr: ErrInvalidISBN},
+		{name: "X in an isbn-13", isbn: "978080442957X", wantErr: ErrInvalidISBN},
+		{name: "too short", isbn: "030640615", wantErr: ErrInvalidISBN},
+		{name: "too long", isbn: "97803064061570", wantErr: ErrInvalidISBN},
+		{name: "empty", isbn: "", wantErr: ErrInvalidISBN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseISBN(tt.isbn)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseISBN(%q) error = %v, want %v", tt.isbn, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseISBN(%q) = %q, want %q", tt.isbn, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LedgerEntryKind tells whether a ledger entry adds to what a member owes,
+// as a charge does, or takes from it, as payments and waivers do.
+type LedgerEntryKind string
+
+const (
+	EntryCharge  LedgerEntryKind = "charge"
+	EntryPayment LedgerEntryKind = "payment"
+	EntryWaiver  LedgerEntryKind = "waiver"
+)
+
+// ChargeReason tells what a charge is for.
+type ChargeReason string
+
+const (
+	ChargeOverdue ChargeReason = "overdue"
+	ChargeLost    ChargeReason = "lost"
+)
+
+// LedgerEntry is one line of a member's account. Amount is always positive;
+// Kind gives its sign. Charges are made by circulation for a loan and have a
+// Reason; payments and waivers are recorded at the desk, with an optional
+// Note, and LoanID left zero. RecordedBy is the actor who made the entry.
+type LedgerEntry struct {
+	ID         int64
+	MemberID   int64
+	LoanID     int64
+	Kind       LedgerEntryKind
+	Reason     ChargeReason
+	Amount     decimal.Decimal
+	Note       string
+	RecordedBy string
+	CreatedAt  time.Time
+}
+
+// MemberBalance sums up the ledger of a member. Outstanding is what they
+// still owe: their charges less their payments and waivers. Loans are only
+// charged when they are returned or lost, so the fine still accruing on an
+// open overdue loan is not part of it.
+type MemberBalance struct {
+	MemberID    int64
+	Name        string
+	CardNumber  string
+	Charged     decimal.Decimal
+	Paid        decimal.Decimal
+	Waived      decimal.Decimal
+	Outstanding decimal.Decimal
+}
+
+// Add counts e in b.
+func (b *MemberBalance) Add(e LedgerEntry) {
+	switch e.Kind {
+	case EntryCharge:
+		b.Charged = b.Charged.Add(e.Amount)
+		b.Outstanding = b.Outstanding.Add(e.Amount)
+	case EntryPayment:
+		b.Paid = b.Paid.Add(e.Amount)
+		b.Outstanding = b.Outstanding.Sub(e.Amount)
+	case EntryWaiver:
+		b.Waived = b.Waived.Add(e.Amount)
+		b.Outstanding = b.Outstanding.Sub(e.Amount)
+	}
+}
+
+// BalanceQuery pages through the members who owe at least MinOutstanding,
+// largest balance first. A zero MinOutstanding lists everyone who owes
+// anything.
+type BalanceQuery struct {
+	MinOutstanding decimal.Decimal
+	PageSize       int
+	Offset         int
+}
+
+func (q *BalanceQuery) Normalize() error {
+	if q.MinOutstanding.IsNegative() {
+		return fmt.Errorf("%w: minimum outstanding amount must not be negative", ErrInvalidBalanceQuery)
+	}
+
+	if q.PageSize == ZERO {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize < 1 || q.PageSize > MaxPageSize {
+		return fmt.Errorf("%w: page size must be between 1 and %d", ErrInvalidBalanceQuery, MaxPageSize)
+	}
+
+	if q.Offset < ZERO {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidBalanceQuery)
+	}
+
+	return nil
+}
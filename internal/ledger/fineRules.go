@@ -0,0 +1,33 @@
+package ledger
+
+import (
+	"github.com/amarantec/box/internal"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultFineRules are the fine rules of the item categories a fine rules
+// file leaves out.
+func DefaultFineRules() []internal.FineRule {
+	return []internal.FineRule{
+		{Category: internal.CategoryGeneral, DailyRate: decimal.RequireFromString("0.25"), GraceDays: 2, MaxFine: decimal.RequireFromString("10.00"), LostFee: decimal.RequireFromString("5.00")},
+		{Category: internal.CategoryShortLoan, DailyRate: decimal.RequireFromString("1.00"), GraceDays: 0, MaxFine: decimal.RequireFromString("20.00"), LostFee: decimal.RequireFromString("5.00")},
+		{Category: internal.CategoryAudiovisual, DailyRate: decimal.RequireFromString("0.50"), GraceDays: 1, MaxFine: decimal.RequireFromString("15.00"), LostFee: decimal.RequireFromString("5.00")},
+	}
+}
+
+var fineRulesFile = internal.ConfigList[internal.FineRule, internal.ItemCategory]{
+	Name:  "fine rules",
+	Key:   "item category",
+	Entry: "rule",
+	Keys:  internal.ItemCategories,
+	KeyOf: func(r internal.FineRule) internal.ItemCategory { return r.Category },
+}
+
+// LoadFineRules reads a JSON array of fine rules from path, such as
+//
+//	[{"Category": "short-loan", "DailyRate": "2.00", "GraceDays": 0, "MaxFine": "30.00", "LostFee": "7.50"}]
+//
+// and checks each of them.
+func LoadFineRules(path string) ([]internal.FineRule, error) {
+	return fineRulesFile.Load(path)
+}
@@ -0,0 +1,201 @@
+package ledger
+
+import (
+	"context"
+	"log"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ILedgerRepository keeps the accounts of members. Loans are not charged
+// through it in PostgreSQL: circulation charges them in the transaction that
+// returns or loses their copy.
+type ILedgerRepository interface {
+	AddEntry(ctx context.Context, e internal.LedgerEntry) (int64, error)
+	ListEntries(ctx context.Context, memberId int64) ([]internal.LedgerEntry, error)
+	GetEntryById(ctx context.Context, entryId int64) (internal.LedgerEntry, error)
+	GetBalance(ctx context.Context, memberId int64) (internal.MemberBalance, error)
+	ListBalances(ctx context.Context, q internal.BalanceQuery) ([]internal.MemberBalance, internal.Pagination, error)
+}
+
+const entryColumns = `id, member_id, COALESCE(loan_id, 0), kind, COALESCE(reason, ''), amount, note, recorded_by, created_at`
+
+// balanceSums are the charged, paid and waived sums of the entries of a
+// member.
+const balanceSums = `COALESCE(SUM(amount) FILTER (WHERE kind = 'charge'), 0) AS charged,
+    COALESCE(SUM(amount) FILTER (WHERE kind = 'payment'), 0) AS paid,
+    COALESCE(SUM(amount) FILTER (WHERE kind = 'waiver'), 0) AS waived`
+
+func scanEntry(row pgx.Row) (internal.LedgerEntry, error) {
+	var e internal.LedgerEntry
+	if err := row.Scan(
+		&e.ID,
+		&e.MemberID,
+		&e.LoanID,
+		&e.Kind,
+		&e.Reason,
+		&e.Amount,
+		&e.Note,
+		&e.RecordedBy,
+		&e.CreatedAt,
+	); err != nil {
+		return internal.LedgerEntry{}, err
+	}
+	return e, nil
+}
+
+func scanBalance(row pgx.Row, b *internal.MemberBalance) error {
+	if err := row.Scan(&b.Charged, &b.Paid, &b.Waived); err != nil {
+		return err
+	}
+	b.Outstanding = b.Charged.Sub(b.Paid).Sub(b.Waived)
+	return nil
+}
+
+type ledgerRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewLedgerRepository(conn *pgxpool.Pool) ILedgerRepository {
+	return &ledgerRepository{Conn: conn}
+}
+
+// AddEntry records an entry on the account of a member. A payment or a
+// waiver may not take more than the member owes; the member is locked while
+// it is checked, so two of them cannot both pass.
+func (r *ledgerRepository) AddEntry(ctx context.Context, e internal.LedgerEntry) (int64, error) {
+	err := pgx.BeginFunc(ctx, r.Conn, func(tx pgx.Tx) error {
+		if err :=
+			tx.QueryRow(
+				ctx,
+				`SELECT id FROM members WHERE id = $1 FOR UPDATE;`, e.MemberID).Scan(&e.MemberID); err != nil {
+			if err == pgx.ErrNoRows {
+				return internal.ErrMemberNotFound
+			}
+			return err
+		}
+
+		if e.Kind != internal.EntryCharge {
+			balance := internal.MemberBalance{MemberID: e.MemberID}
+			if err := scanBalance(
+				tx.QueryRow(
+					ctx,
+					`SELECT `+balanceSums+` FROM ledger_entries WHERE member_id = $1;`, e.MemberID), &balance); err != nil {
+				return err
+			}
+			if e.Amount.GreaterThan(balance.Outstanding) {
+				return internal.ErrCreditExceedsBalance
+			}
+		}
+
+		return tx.QueryRow(
+			ctx,
+			`INSERT INTO ledger_entries (member_id, loan_id, kind, reason, amount, note, recorded_by, created_at)
+            VALUES ($1, NULLIF($2::BIGINT, 0), $3, NULLIF($4::TEXT, ''), $5, $6, $7, $8) RETURNING id;`,
+			e.MemberID, e.LoanID, e.Kind, e.Reason, e.Amount, e.Note, e.RecordedBy, e.CreatedAt).Scan(&e.ID)
+	})
+
+	if err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return internal.ZERO, internal.ErrLoanNotFound
+		}
+		return internal.ZERO, err
+	}
+
+	log.Printf("Ledger entry with ID %d recorded for member with ID %d.\n", e.ID, e.MemberID)
+	return e.ID, nil
+}
+
+func (r *ledgerRepository) ListEntries(ctx context.Context, memberId int64) ([]internal.LedgerEntry, error) {
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			`SELECT `+entryColumns+` FROM ledger_entries WHERE member_id = $1 ORDER BY id;`, memberId)
+
+	if err != nil {
+		return []internal.LedgerEntry{}, err
+	}
+
+	defer rows.Close()
+
+	entries := []internal.LedgerEntry{}
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return []internal.LedgerEntry{}, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *ledgerRepository) GetEntryById(ctx context.Context, entryId int64) (internal.LedgerEntry, error) {
+	e, err := scanEntry(
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT `+entryColumns+` FROM ledger_entries WHERE id = $1;`, entryId))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.LedgerEntry{}, internal.ErrLedgerEntryNotFound
+		}
+		return internal.LedgerEntry{}, err
+	}
+
+	return e, nil
+}
+
+func (r *ledgerRepository) GetBalance(ctx context.Context, memberId int64) (internal.MemberBalance, error) {
+	balance := internal.MemberBalance{MemberID: memberId}
+	if err := scanBalance(
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT `+balanceSums+` FROM ledger_entries WHERE member_id = $1;`, memberId), &balance); err != nil {
+		return internal.MemberBalance{}, err
+	}
+	return balance, nil
+}
+
+// ListBalances sums up the ledgers of the members who owe at least
+// q.MinOutstanding, with their names and card numbers.
+func (r *ledgerRepository) ListBalances(ctx context.Context, q internal.BalanceQuery) ([]internal.MemberBalance, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+
+	const outstanding = `SELECT sums.member_id, m.name, m.card_number, charged, paid, waived, charged - paid - waived AS outstanding
+        FROM (SELECT member_id, ` + balanceSums + ` FROM ledger_entries GROUP BY member_id) sums
+        JOIN members m ON m.id = sums.member_id
+        WHERE charged - paid - waived > 0 AND charged - paid - waived >= $1`
+
+	if err :=
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT COUNT(*) FROM (`+outstanding+`) owing;`, q.MinOutstanding).Scan(&pagination.TotalCount); err != nil {
+		return []internal.MemberBalance{}, pagination, err
+	}
+
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			outstanding+` ORDER BY outstanding DESC, sums.member_id LIMIT $2 OFFSET $3;`, q.MinOutstanding, q.PageSize, q.Offset)
+
+	if err != nil {
+		return []internal.MemberBalance{}, pagination, err
+	}
+
+	defer rows.Close()
+
+	result := []internal.MemberBalance{}
+	for rows.Next() {
+		var b internal.MemberBalance
+		if err := rows.Scan(&b.MemberID, &b.Name, &b.CardNumber, &b.Charged, &b.Paid, &b.Waived, &b.Outstanding); err != nil {
+			return []internal.MemberBalance{}, pagination, err
+		}
+		result = append(result, b)
+	}
+
+	return result, pagination, rows.Err()
+}
@@ -0,0 +1,184 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database/fixturetest"
+	"github.com/shopspring/decimal"
+)
+
+func assertBalance(t *testing.T, r fixturetest.Repositories, memberId int64, charged, outstanding string) {
+	t.Helper()
+
+	b, err := r.Ledger.GetBalance(context.Background(), memberId)
+	if err != nil {
+		t.Fatalf("GetBalance(%d): %v", memberId, err)
+	}
+	if !b.Charged.Equal(decimal.RequireFromString(charged)) || !b.Outstanding.Equal(decimal.RequireFromString(outstanding)) {
+		t.Fatalf("GetBalance(%d) = %s charged, %s outstanding, want %s and %s", memberId, b.Charged, b.Outstanding, charged, outstanding)
+	}
+}
+
+func credit(memberId int64, kind internal.LedgerEntryKind, amount string, at time.Time) internal.LedgerEntry {
+	return internal.LedgerEntry{
+		MemberID:   memberId,
+		Kind:       kind,
+		Amount:     decimal.RequireFromString(amount),
+		RecordedBy: "desk",
+		CreatedAt:  at,
+	}
+}
+
+func TestFines(t *testing.T) {
+	fixturetest.Run(t, "ledger_test", testFines)
+}
+
+func testFines(t *testing.T, r fixturetest.Repositories) {
+	ctx := context.Background()
+	start := time.Date(2026, time.May, 1, 10, 0, 0, 0, time.UTC)
+
+	bookId := fixturetest.RegisterBook(t, r, "Overdue")
+	copyId, err := r.Copies.AddCopy(ctx, fixturetest.NewCopy(bookId, "F-001"))
+	if err != nil {
+		t.Fatalf("AddCopy: %v", err)
+	}
+	shortLoan := fixturetest.NewCopy(bookId, "F-002")
+	shortLoan.Category = internal.CategoryShortLoan
+	shortLoan.Price = decimal.RequireFromString("12.00")
+	shortLoanId, err := r.Copies.AddCopy(ctx, shortLoan)
+	if err != nil {
+		t.Fatalf("AddCopy(short loan): %v", err)
+	}
+	memberId := fixturetest.RegisterMember(t, r, "F-1")
+	otherId := fixturetest.RegisterMember(t, r, "F-2")
+
+	// Back within the grace period, the loan is not charged.
+	loanId, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, memberId, start), 5)
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if _, err := r.Loans.ReturnLoan(ctx, loanId, fixturetest.HoldPolicy, fixturetest.FineRules, fixturetest.Date(2026, time.May, 17).Add(12*time.Hour)); err != nil {
+		t.Fatalf("ReturnLoan: %v", err)
+	}
+	assertBalance(t, r, memberId, "0", "0")
+
+	// Past it, every day since the due date is charged.
+	lateId, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, memberId, start.AddDate(0, 0, 17)), 5)
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if _, err := r.Loans.ReturnLoan(ctx, lateId, fixturetest.HoldPolicy, fixturetest.FineRules, fixturetest.Date(2026, time.June, 5)); err != nil {
+		t.Fatalf("ReturnLoan: %v", err)
+	}
+	assertBalance(t, r, memberId, "1.00", "1.00")
+
+	// A lost copy is charged its price with the lost item fee, on top of
+	// the capped overdue fine.
+	lostId, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(shortLoanId, memberId, start.AddDate(0, 0, 17)), 5)
+	if err != nil {
+		t.Fatalf("Checkout(short loan): %v", err)
+	}
+	lostAt := fixturetest.Date(2026, time.June, 11)
+	if _, err := r.Loans.DeclareLost(ctx, lostId, fixturetest.FineRules, lostAt); err != nil {
+		t.Fatalf("DeclareLost: %v", err)
+	}
+	if _, err := r.Loans.DeclareLost(ctx, lostId, fixturetest.FineRules, lostAt); !errors.Is(err, internal.ErrLoanLost) {
+		t.Fatalf("DeclareLost(lost) error = %v, want %v", err, internal.ErrLoanLost)
+	}
+	if _, err := r.Loans.ReturnLoan(ctx, lostId, fixturetest.HoldPolicy, fixturetest.FineRules, lostAt); !errors.Is(err, internal.ErrLoanLost) {
+		t.Fatalf("ReturnLoan(lost) error = %v, want %v", err, internal.ErrLoanLost)
+	}
+	if _, err := r.Loans.DeclareLost(ctx, 4242, fixturetest.FineRules, lostAt); !errors.Is(err, internal.ErrLoanNotFound) {
+		t.Fatalf("DeclareLost(missing) error = %v, want %v", err, internal.ErrLoanNotFound)
+	}
+	fixturetest.AssertCopyStatus(t, r, shortLoanId, internal.CopyLost)
+	assertBalance(t, r, memberId, "18.50", "18.50")
+
+	q := internal.LoanQuery{State: internal.LoansLost, Today: lostAt}
+	if err := q.Normalize(); err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if loans, _, err := r.Loans.ListLoans(ctx, q); err != nil || len(loans) != 1 || loans[0].ID != lostId || loans[0].LostAt == nil {
+		t.Fatalf("ListLoans(lost) = %+v, %v, want loan %d", loans, err, lostId)
+	}
+
+	entries, err := r.Ledger.ListEntries(ctx, memberId)
+	if err != nil {
+		t.Fatalf("ListEntries: %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, fmt.Sprintf("%d %s %s %s", e.LoanID, e.Kind, e.Reason, e.Amount.StringFixed(2)))
+	}
+	want := []string{
+		fmt.Sprintf("%d charge overdue 1.00", lateId),
+		fmt.Sprintf("%d charge overdue 3.00", lostId),
+		fmt.Sprintf("%d charge lost 14.50", lostId),
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("ListEntries = %q, want %q", got, want)
+	}
+
+	otherLoanId, err := r.Loans.Checkout(ctx, fixturetest.NewLoan(copyId, otherId, fixturetest.Date(2026, time.June, 6)), 5)
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if _, err := r.Loans.ReturnLoan(ctx, otherLoanId, fixturetest.HoldPolicy, fixturetest.FineRules, fixturetest.Date(2026, time.July, 20)); err != nil {
+		t.Fatalf("ReturnLoan: %v", err)
+	}
+	assertBalance(t, r, otherId, "7.50", "7.50")
+
+	owing := func(q internal.BalanceQuery) []int64 {
+		t.Helper()
+		if err := q.Normalize(); err != nil {
+			t.Fatalf("Normalize: %v", err)
+		}
+		balances, _, err := r.Ledger.ListBalances(ctx, q)
+		if err != nil {
+			t.Fatalf("ListBalances: %v", err)
+		}
+		var memberIds []int64
+		for _, b := range balances {
+			memberIds = append(memberIds, b.MemberID)
+		}
+		return memberIds
+	}
+	if got := owing(internal.BalanceQuery{}); !slices.Equal(got, []int64{memberId, otherId}) {
+		t.Fatalf("ListBalances = %v, want %v", got, []int64{memberId, otherId})
+	}
+	if got := owing(internal.BalanceQuery{MinOutstanding: decimal.RequireFromString("10")}); !slices.Equal(got, []int64{memberId}) {
+		t.Fatalf("ListBalances(at least 10) = %v, want %v", got, []int64{memberId})
+	}
+
+	if balances, _, err := r.Ledger.ListBalances(ctx, internal.BalanceQuery{PageSize: 1}); err != nil ||
+		len(balances) != 1 || balances[0].Name != "Member F-1" || balances[0].CardNumber != "F-1" {
+		t.Fatalf("ListBalances = %+v, %v, want member F-1 by name", balances, err)
+	}
+
+	paidAt := fixturetest.Date(2026, time.July, 21)
+	if _, err := r.Ledger.AddEntry(ctx, credit(memberId, internal.EntryPayment, "10.00", paidAt)); err != nil {
+		t.Fatalf("AddEntry(payment): %v", err)
+	}
+	assertBalance(t, r, memberId, "18.50", "8.50")
+	if _, err := r.Ledger.AddEntry(ctx, credit(otherId, internal.EntryPayment, "7.51", paidAt)); !errors.Is(err, internal.ErrCreditExceedsBalance) {
+		t.Fatalf("AddEntry(overpayment) error = %v, want %v", err, internal.ErrCreditExceedsBalance)
+	}
+
+	waiverId, err := r.Ledger.AddEntry(ctx, credit(memberId, internal.EntryWaiver, "8.50", paidAt))
+	if err != nil {
+		t.Fatalf("AddEntry(waiver): %v", err)
+	}
+	if e, err := r.Ledger.GetEntryById(ctx, waiverId); err != nil || e.Kind != internal.EntryWaiver || e.RecordedBy != "desk" || e.LoanID != internal.ZERO {
+		t.Fatalf("GetEntryById = %+v, %v, want the waiver", e, err)
+	}
+	assertBalance(t, r, memberId, "18.50", "0")
+	if got := owing(internal.BalanceQuery{}); !slices.Equal(got, []int64{otherId}) {
+		t.Fatalf("ListBalances after settling = %v, want %v", got, []int64{otherId})
+	}
+}
@@ -0,0 +1,170 @@
+package ledger
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/member"
+)
+
+const maxNoteLength = 500
+
+type ILedgerService interface {
+	ListEntries(ctx context.Context, memberId int64) (internal.Response[[]internal.LedgerEntry], error)
+	GetBalance(ctx context.Context, memberId int64) (internal.Response[internal.MemberBalance], error)
+	RecordPayment(ctx context.Context, e internal.LedgerEntry) (internal.Response[internal.LedgerEntry], error)
+	RecordWaiver(ctx context.Context, e internal.LedgerEntry) (internal.Response[internal.LedgerEntry], error)
+	ListBalances(ctx context.Context, q internal.BalanceQuery) (internal.Response[[]internal.MemberBalance], error)
+}
+
+type ledgerService struct {
+	ledgerRepo ILedgerRepository
+	memberRepo member.IMemberRepository
+}
+
+func NewLedgerService(repository ILedgerRepository, memberRepository member.IMemberRepository) ILedgerService {
+	return &ledgerService{ledgerRepo: repository, memberRepo: memberRepository}
+}
+
+// ListEntries lists the account of a member, oldest entry first.
+func (s *ledgerService) ListEntries(ctx context.Context, memberId int64) (internal.Response[[]internal.LedgerEntry], error) {
+	var response internal.Response[[]internal.LedgerEntry]
+
+	_, err := s.memberRepo.GetMemberById(ctx, memberId)
+	var data []internal.LedgerEntry
+	if err == nil {
+		data, err = s.ledgerRepo.ListEntries(ctx, memberId)
+	}
+	if err != nil {
+		response.Data = []internal.LedgerEntry{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Ledger of the member, oldest entry first."
+	return response, nil
+}
+
+func (s *ledgerService) GetBalance(ctx context.Context, memberId int64) (internal.Response[internal.MemberBalance], error) {
+	var response internal.Response[internal.MemberBalance]
+
+	m, err := s.memberRepo.GetMemberById(ctx, memberId)
+	var data internal.MemberBalance
+	if err == nil {
+		data, err = s.ledgerRepo.GetBalance(ctx, memberId)
+	}
+	if err != nil {
+		response.Data = internal.MemberBalance{}
+		response.Success = false
+		return response, err
+	}
+
+	data.Name, data.CardNumber = m.Name, m.CardNumber
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Balance of the member."
+	return response, nil
+}
+
+// RecordPayment takes a payment from a member, up to what they owe.
+func (s *ledgerService) RecordPayment(ctx context.Context, e internal.LedgerEntry) (internal.Response[internal.LedgerEntry], error) {
+	return s.recordCredit(ctx, e, internal.EntryPayment, "Payment recorded successfully.")
+}
+
+// RecordWaiver lets a member off part or all of what they owe. Unlike a
+// payment, a waiver must say why in its Note.
+func (s *ledgerService) RecordWaiver(ctx context.Context, e internal.LedgerEntry) (internal.Response[internal.LedgerEntry], error) {
+	return s.recordCredit(ctx, e, internal.EntryWaiver, "Waiver recorded successfully.")
+}
+
+func (s *ledgerService) recordCredit(ctx context.Context, e internal.LedgerEntry, kind internal.LedgerEntryKind, message string) (internal.Response[internal.LedgerEntry], error) {
+	var response internal.Response[internal.LedgerEntry]
+
+	e.Note = strings.TrimSpace(e.Note)
+	if err := validateCredit(e, kind); err != nil {
+		response.Data = internal.LedgerEntry{}
+		response.Success = false
+		return response, err
+	}
+
+	e.Kind = kind
+	e.RecordedBy = internal.ActorFromContext(ctx)
+	e.CreatedAt = time.Now()
+
+	_, err := s.memberRepo.GetMemberById(ctx, e.MemberID)
+	var entryId int64
+	if err == nil {
+		entryId, err = s.ledgerRepo.AddEntry(ctx, e)
+	}
+	var data internal.LedgerEntry
+	if err == nil {
+		data, err = s.ledgerRepo.GetEntryById(ctx, entryId)
+	}
+	if err != nil {
+		response.Data = internal.LedgerEntry{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = message
+	return response, nil
+}
+
+// ListBalances reports the members who owe at least q.MinOutstanding,
+// largest balance first. Fines still accruing on open overdue loans are not
+// counted until the loans are returned or lost.
+func (s *ledgerService) ListBalances(ctx context.Context, q internal.BalanceQuery) (internal.Response[[]internal.MemberBalance], error) {
+	var response internal.Response[[]internal.MemberBalance]
+
+	if err := q.Normalize(); err != nil {
+		response.Data = []internal.MemberBalance{}
+		response.Success = false
+		return response, err
+	}
+
+	data, pagination, err := s.ledgerRepo.ListBalances(ctx, q)
+	if err != nil {
+		response.Data = []internal.MemberBalance{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Outstanding balances, largest first."
+	response.Pagination = &pagination
+	return response, nil
+}
+
+// validateCredit checks a payment or waiver sent by a client. The member
+// comes from the route; only the amount and the note are the client's to
+// set.
+func validateCredit(e internal.LedgerEntry, kind internal.LedgerEntryKind) error {
+	v := &internal.ValidationError{}
+
+	v.Check(e.ID == internal.ZERO, "ID", "is assigned by the server and must not be set")
+	v.Check(e.MemberID > internal.ZERO, "MemberID", "is required")
+	v.Check(e.LoanID == internal.ZERO, "LoanID", "is only set on charges")
+	v.Check(e.Kind == internal.EMPTY || e.Kind == kind, "Kind", "must be %s or left empty", kind)
+	v.Check(e.Reason == internal.EMPTY, "Reason", "is only set on charges")
+	v.Check(e.RecordedBy == internal.EMPTY, "RecordedBy", "is managed by the server and must not be set")
+	v.Check(e.CreatedAt.IsZero(), "CreatedAt", "is managed by the server and must not be set")
+
+	v.Check(e.Amount.IsPositive(), "Amount", "must be positive")
+	v.Check(e.Amount.Equal(e.Amount.Round(2)), "Amount", "must have at most two decimal places")
+	v.Check(utf8.RuneCountInString(e.Note) <= maxNoteLength,
+		"Note", "must be at most %d characters", maxNoteLength)
+	if kind == internal.EntryWaiver {
+		v.Check(e.Note != internal.EMPTY, "Note", "must say why the amount is waived")
+	}
+
+	return v.Err()
+}
@@ -0,0 +1,145 @@
+package ledger
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/member"
+)
+
+// memoryLedgerRepository is an ILedgerRepository kept in process memory. It
+// only reads members to name the balances it lists: the service checks the
+// member of an entry exists, and circulation charges loans through AddEntry.
+type memoryLedgerRepository struct {
+	mu      sync.RWMutex
+	entries []internal.LedgerEntry
+	members member.IMemberRepository
+}
+
+func NewMemoryLedgerRepository(members member.IMemberRepository) ILedgerRepository {
+	return &memoryLedgerRepository{members: members}
+}
+
+// balance sums up the entries of a member. The caller must hold r.mu.
+func (r *memoryLedgerRepository) balance(memberId int64) internal.MemberBalance {
+	b := internal.MemberBalance{MemberID: memberId}
+	for _, e := range r.entries {
+		if e.MemberID == memberId {
+			b.Add(e)
+		}
+	}
+	return b
+}
+
+func (r *memoryLedgerRepository) AddEntry(ctx context.Context, e internal.LedgerEntry) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e.Kind != internal.EntryCharge && e.Amount.GreaterThan(r.balance(e.MemberID).Outstanding) {
+		return internal.ZERO, internal.ErrCreditExceedsBalance
+	}
+
+	e.ID = int64(len(r.entries)) + 1
+	r.entries = append(r.entries, e)
+
+	return e.ID, nil
+}
+
+func (r *memoryLedgerRepository) ListEntries(ctx context.Context, memberId int64) ([]internal.LedgerEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return []internal.LedgerEntry{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []internal.LedgerEntry{}
+	for _, e := range r.entries {
+		if e.MemberID == memberId {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (r *memoryLedgerRepository) GetEntryById(ctx context.Context, entryId int64) (internal.LedgerEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.LedgerEntry{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if entryId < 1 || entryId > int64(len(r.entries)) {
+		return internal.LedgerEntry{}, internal.ErrLedgerEntryNotFound
+	}
+	return r.entries[entryId-1], nil
+}
+
+func (r *memoryLedgerRepository) GetBalance(ctx context.Context, memberId int64) (internal.MemberBalance, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.MemberBalance{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.balance(memberId), nil
+}
+
+func (r *memoryLedgerRepository) ListBalances(ctx context.Context, q internal.BalanceQuery) ([]internal.MemberBalance, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+	if err := ctx.Err(); err != nil {
+		return []internal.MemberBalance{}, pagination, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sums := map[int64]*internal.MemberBalance{}
+	for _, e := range r.entries {
+		b, ok := sums[e.MemberID]
+		if !ok {
+			b = &internal.MemberBalance{MemberID: e.MemberID}
+			sums[e.MemberID] = b
+		}
+		b.Add(e)
+	}
+
+	balances := []internal.MemberBalance{}
+	for _, b := range sums {
+		if b.Outstanding.IsPositive() && b.Outstanding.GreaterThanOrEqual(q.MinOutstanding) {
+			balances = append(balances, *b)
+		}
+	}
+
+	slices.SortFunc(balances, func(a, b internal.MemberBalance) int {
+		if c := b.Outstanding.Cmp(a.Outstanding); c != internal.ZERO {
+			return c
+		}
+		return cmp.Compare(a.MemberID, b.MemberID)
+	})
+
+	pagination.TotalCount = int64(len(balances))
+	balances = balances[min(q.Offset, len(balances)):]
+	if len(balances) > q.PageSize {
+		balances = balances[:q.PageSize]
+	}
+
+	for i := range balances {
+		m, err := r.members.GetMemberById(ctx, balances[i].MemberID)
+		if err != nil {
+			return []internal.MemberBalance{}, pagination, err
+		}
+		balances[i].Name, balances[i].CardNumber = m.Name, m.CardNumber
+	}
+
+	return balances, pagination, nil
+}
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Loan is a copy lent to a member. DueDate is a calendar day, at midnight
+// UTC: the copy may be returned any time on that day. A loan stays open until
+// ReturnedAt is set, or LostAt when the copy is declared lost instead.
+type Loan struct {
+	ID           int64
+	CopyID       int64
+	BookID       int64
+	MemberID     int64
+	CheckedOutAt time.Time
+	DueDate      time.Time
+	Renewals     int
+	ReturnedAt   *time.Time
+	LostAt       *time.Time
+}
+
+// Open reports whether l has been neither returned nor declared lost.
+func (l Loan) Open() bool {
+	return l.ReturnedAt == nil && l.LostAt == nil
+}
+
+// CheckOpen reports why l is no longer open, if it is not.
+func (l Loan) CheckOpen() error {
+	switch {
+	case l.ReturnedAt != nil:
+		return ErrLoanReturned
+	case l.LostAt != nil:
+		return ErrLoanLost
+	}
+	return nil
+}
+
+// Overdue reports whether l is open and its due date is before the day of
+// now.
+func (l Loan) Overdue(now time.Time) bool {
+	return l.Open() && Day(now).After(l.DueDate)
+}
+
+// Day is the calendar day of t, as a date at midnight UTC.
+func Day(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// LoanPolicy sets the terms of the loans of members of MemberType: how many
+// days a copy is lent for, how many times a loan may be renewed and how many
+// copies the member may have out at once.
+type LoanPolicy struct {
+	MemberType  MemberType
+	LoanDays    int
+	MaxRenewals int
+	MaxLoans    int
+}
+
+// DueDate is the day a copy checked out at from is due back.
+func (p LoanPolicy) DueDate(from time.Time) time.Time {
+	return Day(from).AddDate(0, 0, p.LoanDays)
+}
+
+// Renew returns l renewed at now, or why it cannot be. The new due date
+// counts LoanDays from the current due date, or from today when the loan is
+// overdue, so renewing early never shortens a loan.
+func (p LoanPolicy) Renew(l Loan, now time.Time) (Loan, error) {
+	if err := l.CheckOpen(); err != nil {
+		return Loan{}, err
+	}
+	if l.Renewals >= p.MaxRenewals {
+		return Loan{}, ErrRenewalLimitReached
+	}
+
+	from := l.DueDate
+	if today := Day(now); today.After(from) {
+		from = today
+	}
+	l.DueDate = p.DueDate(from)
+	l.Renewals++
+	return l, nil
+}
+
+func (p LoanPolicy) Validate() error {
+	switch {
+	case p.LoanDays < 1:
+		return fmt.Errorf("loan policy %q: LoanDays must be at least 1", p.MemberType)
+	case p.MaxRenewals < ZERO:
+		return fmt.Errorf("loan policy %q: MaxRenewals must not be negative", p.MemberType)
+	case p.MaxLoans < 1:
+		return fmt.Errorf("loan policy %q: MaxLoans must be at least 1", p.MemberType)
+	}
+	return nil
+}
+
+// LoanState selects loans by whether they are still open.
+type LoanState string
+
+const (
+	LoansOpen     LoanState = "open"
+	LoansOverdue  LoanState = "overdue"
+	LoansReturned LoanState = "returned"
+	LoansLost     LoanState = "lost"
+	LoansAll      LoanState = "all"
+)
+
+var LoanStates = []LoanState{LoansOpen, LoansOverdue, LoansReturned, LoansLost, LoansAll}
+
+// LoanQuery pages through loans, most recent checkout first. A zero MemberID
+// matches every member. Today is the day overdue loans are counted from; the
+// service sets it.
+type LoanQuery struct {
+	MemberID int64
+	State    LoanState
+	Today    time.Time
+	PageSize int
+	Offset   int
+}
+
+func (q *LoanQuery) Normalize() error {
+	if q.State == EMPTY {
+		q.State = LoansOpen
+	}
+	if !slices.Contains(LoanStates, q.State) {
+		return fmt.Errorf("%w: state must be open, overdue, returned, lost or all", ErrInvalidLoanQuery)
+	}
+
+	if q.PageSize == ZERO {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize < 1 || q.PageSize > MaxPageSize {
+		return fmt.Errorf("%w: page size must be between 1 and %d", ErrInvalidLoanQuery, MaxPageSize)
+	}
+
+	if q.Offset < ZERO {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidLoanQuery)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MemberType decides the loan policy that applies to a member.
+type MemberType string
+
+const (
+	MemberStandard MemberType = "standard"
+	MemberStudent  MemberType = "student"
+	MemberStaff    MemberType = "staff"
+)
+
+var MemberTypes = []MemberType{MemberStandard, MemberStudent, MemberStaff}
+
+// MemberStatus tells whether a member may use the library. A suspended
+// member keeps their loans, holds and balance but cannot borrow until
+// reinstated.
+type MemberStatus string
+
+const (
+	MemberActive    MemberStatus = "active"
+	MemberSuspended MemberStatus = "suspended"
+)
+
+var MemberStatuses = []MemberStatus{MemberActive, MemberSuspended}
+
+// Member is a person who borrows copies, identified at the desk by the
+// number printed on their library card. ExpiresOn is the last day of their
+// membership, at midnight UTC. MaxLoans, when set, replaces the number of
+// copies the loan policy of their type lets them have out at once.
+type Member struct {
+	ID         int64
+	Name       string
+	CardNumber string
+	Type       MemberType
+	Email      string
+	Phone      string
+	Status     MemberStatus
+	ExpiresOn  time.Time
+	MaxLoans   *int
+	CreatedAt  time.Time
+	UpdatedAt  *time.Time
+}
+
+// Expired reports whether the membership of m ended before the day of now.
+func (m Member) Expired(now time.Time) bool {
+	return Day(now).After(m.ExpiresOn)
+}
+
+// CheckStanding reports why m may not borrow or place holds at now, if they
+// may not.
+func (m Member) CheckStanding(now time.Time) error {
+	switch {
+	case m.Status == MemberSuspended:
+		return ErrMemberSuspended
+	case m.Expired(now):
+		return ErrMembershipExpired
+	}
+	return nil
+}
+
+// MemberQuery pages through members by name. Query, when set, keeps the
+// members whose name contains it or whose card number starts with it, both
+// ignoring case. An empty Status matches every member.
+type MemberQuery struct {
+	Query    string
+	Status   MemberStatus
+	PageSize int
+	Offset   int
+}
+
+func (q *MemberQuery) Normalize() error {
+	q.Query = strings.TrimSpace(q.Query)
+
+	if q.Status != EMPTY && !slices.Contains(MemberStatuses, q.Status) {
+		return fmt.Errorf("%w: status must be active or suspended", ErrInvalidMemberQuery)
+	}
+
+	if q.PageSize == ZERO {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize < 1 || q.PageSize > MaxPageSize {
+		return fmt.Errorf("%w: page size must be between 1 and %d", ErrInvalidMemberQuery, MaxPageSize)
+	}
+
+	if q.Offset < ZERO {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidMemberQuery)
+	}
+
+	return nil
+}
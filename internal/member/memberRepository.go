@@ -0,0 +1,179 @@
+package member
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IMemberRepository interface {
+	RegisterMember(ctx context.Context, m internal.Member) (int64, error)
+	ListMembers(ctx context.Context, q internal.MemberQuery) ([]internal.Member, internal.Pagination, error)
+	GetMemberById(ctx context.Context, memberId int64) (internal.Member, error)
+	UpdateMember(ctx context.Context, m internal.Member) (bool, error)
+}
+
+// membersCardNumberKey is the unique index on the card numbers.
+const membersCardNumberKey = "members_card_number_key"
+
+const memberColumns = `id, name, card_number, type, email, phone, status, expires_on, max_loans, created_at, updated_at`
+
+func scanMember(row pgx.Row) (internal.Member, error) {
+	var m internal.Member
+	if err := row.Scan(
+		&m.ID,
+		&m.Name,
+		&m.CardNumber,
+		&m.Type,
+		&m.Email,
+		&m.Phone,
+		&m.Status,
+		&m.ExpiresOn,
+		&m.MaxLoans,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	); err != nil {
+		return internal.Member{}, err
+	}
+	return m, nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type memberRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewMemberRepository(conn *pgxpool.Pool) IMemberRepository {
+	return &memberRepository{Conn: conn}
+}
+
+func (r *memberRepository) RegisterMember(ctx context.Context, m internal.Member) (int64, error) {
+	err :=
+		r.Conn.QueryRow(
+			ctx,
+			`INSERT INTO members (name, card_number, type, email, phone, status, expires_on, max_loans)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id;`,
+			m.Name, m.CardNumber, m.Type, m.Email, m.Phone, m.Status, m.ExpiresOn, m.MaxLoans).Scan(&m.ID)
+
+	if err != nil {
+		if database.IsUniqueViolationOf(err, membersCardNumberKey) {
+			return internal.ZERO, internal.ErrCardNumberAlreadyExists
+		}
+		return internal.ZERO, err
+	}
+
+	log.Printf("Member with ID %d registered.\n", m.ID)
+	return m.ID, nil
+}
+
+func (r *memberRepository) ListMembers(ctx context.Context, q internal.MemberQuery) ([]internal.Member, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if q.Query != internal.EMPTY {
+		where(`(name ILIKE '%%' || $%[1]d || '%%' OR card_number ILIKE $%[1]d || '%%')`, likeEscaper.Replace(q.Query))
+	}
+	if q.Status != internal.EMPTY {
+		where("status = $%d", q.Status)
+	}
+
+	filter := internal.EMPTY
+	if len(conditions) > internal.ZERO {
+		filter = ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	if err :=
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT COUNT(*) FROM members`+filter+`;`, args...).Scan(&pagination.TotalCount); err != nil {
+		return []internal.Member{}, pagination, err
+	}
+
+	args = append(args, q.PageSize, q.Offset)
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			fmt.Sprintf(`SELECT `+memberColumns+` FROM members`+filter+`
+            ORDER BY name, id
+            LIMIT $%d OFFSET $%d;`, len(args)-1, len(args)), args...)
+
+	if err != nil {
+		return []internal.Member{}, pagination, err
+	}
+
+	defer rows.Close()
+
+	members := []internal.Member{}
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return []internal.Member{}, pagination, err
+		}
+		members = append(members, m)
+	}
+
+	return members, pagination, rows.Err()
+}
+
+func (r *memberRepository) GetMemberById(ctx context.Context, memberId int64) (internal.Member, error) {
+	m, err := scanMember(
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT `+memberColumns+` FROM members WHERE id = $1;`, memberId))
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return internal.Member{}, internal.ErrMemberNotFound
+		}
+		return internal.Member{}, err
+	}
+
+	return m, nil
+}
+
+// UpdateMember replaces the details of a member. An empty Status, a zero
+// ExpiresOn or a nil MaxLoans keeps the current one.
+func (r *memberRepository) UpdateMember(ctx context.Context, m internal.Member) (bool, error) {
+	var expiresOn *time.Time
+	if !m.ExpiresOn.IsZero() {
+		expiresOn = &m.ExpiresOn
+	}
+
+	tag, err :=
+		r.Conn.Exec(
+			ctx,
+			`UPDATE members SET name = $2, card_number = $3, type = $4, email = $5, phone = $6,
+                status = COALESCE(NULLIF($7::TEXT, ''), status),
+                expires_on = COALESCE($8::DATE, expires_on),
+                max_loans = COALESCE($9::INTEGER, max_loans), updated_at = $10
+            WHERE id = $1;`,
+			m.ID, m.Name, m.CardNumber, m.Type, m.Email, m.Phone, m.Status, expiresOn, m.MaxLoans, time.Now())
+
+	if err != nil {
+		if database.IsUniqueViolationOf(err, membersCardNumberKey) {
+			return false, internal.ErrCardNumberAlreadyExists
+		}
+		return false, err
+	}
+	if tag.RowsAffected() == internal.ZERO {
+		return false, internal.ErrMemberNotFound
+	}
+
+	log.Printf("Member with ID %d updated.\n", m.ID)
+	return true, nil
+}
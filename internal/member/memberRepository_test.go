@@ -0,0 +1,128 @@
+package member_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database/fixturetest"
+)
+
+func memberIds(members []internal.Member) []int64 {
+	ids := make([]int64, len(members))
+	for i, m := range members {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestMembers(t *testing.T) {
+	fixturetest.Run(t, "member_test", testMembers)
+}
+
+func testMembers(t *testing.T, r fixturetest.Repositories) {
+	ctx := context.Background()
+
+	maxLoans := 3
+	ann := fixturetest.NewMember("Ann Lee", "A-100")
+	ann.Email = "ann@example.com"
+	ann.Phone = "+1 555 0100"
+	ann.MaxLoans = &maxLoans
+	annId, err := r.Members.RegisterMember(ctx, ann)
+	if err != nil {
+		t.Fatalf("RegisterMember(Ann): %v", err)
+	}
+	bobId := fixturetest.RegisterMember(t, r, "B-200")
+	dannId := fixturetest.RegisterMember(t, r, "D_300")
+
+	got, err := r.Members.GetMemberById(ctx, annId)
+	if err != nil {
+		t.Fatalf("GetMemberById(%d): %v", annId, err)
+	}
+	if got.Email != ann.Email || got.Phone != ann.Phone || got.Status != internal.MemberActive ||
+		!got.ExpiresOn.Equal(ann.ExpiresOn) || got.MaxLoans == nil || *got.MaxLoans != maxLoans {
+		t.Fatalf("GetMemberById(%d) = %+v, want the details of %+v", annId, got, ann)
+	}
+	if got.CreatedAt.IsZero() || got.UpdatedAt != nil {
+		t.Fatalf("GetMemberById(%d) CreatedAt = %v, UpdatedAt = %v, want set and nil", annId, got.CreatedAt, got.UpdatedAt)
+	}
+	if _, err := r.Members.GetMemberById(ctx, 9999); !errors.Is(err, internal.ErrMemberNotFound) {
+		t.Fatalf("GetMemberById(missing) error = %v, want %v", err, internal.ErrMemberNotFound)
+	}
+
+	queries := []struct {
+		name  string
+		query internal.MemberQuery
+		want  []int64
+		total int64
+	}{
+		{"all by name", internal.MemberQuery{}, []int64{annId, bobId, dannId}, 3},
+		{"name ignoring case", internal.MemberQuery{Query: "lee"}, []int64{annId}, 1},
+		{"card number prefix", internal.MemberQuery{Query: "b-2"}, []int64{bobId}, 1},
+		{"card number is not matched inside", internal.MemberQuery{Query: "100"}, []int64{}, 0},
+		{"underscore is literal", internal.MemberQuery{Query: "B_2"}, []int64{}, 0},
+		{"underscore matches itself", internal.MemberQuery{Query: "d_3"}, []int64{dannId}, 1},
+		{"percent matches nothing", internal.MemberQuery{Query: "%"}, []int64{}, 0},
+		{"page", internal.MemberQuery{PageSize: 1, Offset: 1}, []int64{bobId}, 3},
+	}
+	for _, tt := range queries {
+		q := tt.query
+		if err := q.Normalize(); err != nil {
+			t.Fatalf("%s: Normalize: %v", tt.name, err)
+		}
+		members, pagination, err := r.Members.ListMembers(ctx, q)
+		if err != nil {
+			t.Fatalf("%s: ListMembers: %v", tt.name, err)
+		}
+		if got := memberIds(members); !slices.Equal(got, tt.want) || pagination.TotalCount != tt.total {
+			t.Fatalf("%s: ListMembers = %v (total %d), want %v (total %d)", tt.name, got, pagination.TotalCount, tt.want, tt.total)
+		}
+	}
+
+	update := fixturetest.NewMember("Ann Lee-Park", "A-101")
+	update.ID = annId
+	update.Status = internal.MemberSuspended
+	update.ExpiresOn = time.Time{}
+	if _, err := r.Members.UpdateMember(ctx, update); err != nil {
+		t.Fatalf("UpdateMember(%d): %v", annId, err)
+	}
+	got, err = r.Members.GetMemberById(ctx, annId)
+	if err != nil {
+		t.Fatalf("GetMemberById(%d): %v", annId, err)
+	}
+	if got.Name != update.Name || got.CardNumber != update.CardNumber || got.Email != internal.EMPTY ||
+		got.Status != internal.MemberSuspended || !got.ExpiresOn.Equal(ann.ExpiresOn) ||
+		got.MaxLoans == nil || *got.MaxLoans != maxLoans || got.UpdatedAt == nil {
+		t.Fatalf("GetMemberById(%d) after update = %+v, want %+v keeping the expiry date and loan limit", annId, got, update)
+	}
+
+	fewerLoans := 1
+	update.MaxLoans = &fewerLoans
+	if _, err := r.Members.UpdateMember(ctx, update); err != nil {
+		t.Fatalf("UpdateMember(%d): %v", annId, err)
+	}
+	if got, err := r.Members.GetMemberById(ctx, annId); err != nil || got.MaxLoans == nil || *got.MaxLoans != fewerLoans {
+		t.Fatalf("GetMemberById(%d) after update = %+v, %v, want a loan limit of %d", annId, got, err, fewerLoans)
+	}
+
+	q := internal.MemberQuery{Status: internal.MemberSuspended}
+	if err := q.Normalize(); err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if members, _, err := r.Members.ListMembers(ctx, q); err != nil || !slices.Equal(memberIds(members), []int64{annId}) {
+		t.Fatalf("ListMembers(suspended) = %v, %v, want [%d]", memberIds(members), err, annId)
+	}
+
+	update.CardNumber = "B-200"
+	if _, err := r.Members.UpdateMember(ctx, update); !errors.Is(err, internal.ErrCardNumberAlreadyExists) {
+		t.Fatalf("UpdateMember(taken card) error = %v, want %v", err, internal.ErrCardNumberAlreadyExists)
+	}
+	update.ID = 9999
+	update.CardNumber = "Z-999"
+	if _, err := r.Members.UpdateMember(ctx, update); !errors.Is(err, internal.ErrMemberNotFound) {
+		t.Fatalf("UpdateMember(missing) error = %v, want %v", err, internal.ErrMemberNotFound)
+	}
+}
@@ -0,0 +1,195 @@
+package member
+
+import (
+	"context"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/utils"
+)
+
+const (
+	maxCardNumberLength = 32
+	maxEmailLength      = 254
+	maxPhoneLength      = 32
+
+	// membershipYears is how long a membership lasts when it is registered
+	// without an expiry date.
+	membershipYears = 1
+)
+
+type IMemberService interface {
+	RegisterMember(ctx context.Context, m internal.Member) (internal.Response[int64], error)
+	ListMembers(ctx context.Context, q internal.MemberQuery) (internal.Response[[]internal.Member], error)
+	GetMemberById(ctx context.Context, memberId int64) (internal.Response[internal.Member], error)
+	UpdateMember(ctx context.Context, m internal.Member) (internal.Response[bool], error)
+}
+
+type memberService struct {
+	memberRepo IMemberRepository
+}
+
+func NewMemberService(repository IMemberRepository) IMemberService {
+	return &memberService{memberRepo: repository}
+}
+
+// RegisterMember enrols a member. The type defaults to standard, the status
+// to active and the membership lasts a year unless ExpiresOn says otherwise.
+func (s *memberService) RegisterMember(ctx context.Context, m internal.Member) (internal.Response[int64], error) {
+	var response internal.Response[int64]
+
+	normalizeMember(&m)
+	if m.Status == internal.EMPTY {
+		m.Status = internal.MemberActive
+	}
+	if m.ExpiresOn.IsZero() {
+		m.ExpiresOn = internal.Day(time.Now()).AddDate(membershipYears, 0, 0)
+	}
+	if err := validateMember(m, false); err != nil {
+		response.Data = internal.ZERO
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.memberRepo.RegisterMember(ctx, m)
+	if err != nil {
+		response.Data = internal.ZERO
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Member registered successfully."
+	return response, nil
+}
+
+// ListMembers pages through the members by name, keeping the ones whose name
+// or card number match q.Query.
+func (s *memberService) ListMembers(ctx context.Context, q internal.MemberQuery) (internal.Response[[]internal.Member], error) {
+	var response internal.Response[[]internal.Member]
+
+	if err := q.Normalize(); err != nil {
+		response.Data = []internal.Member{}
+		response.Success = false
+		return response, err
+	}
+
+	data, pagination, err := s.memberRepo.ListMembers(ctx, q)
+	if err != nil {
+		response.Data = []internal.Member{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Members, by name."
+	response.Pagination = &pagination
+	return response, nil
+}
+
+func (s *memberService) GetMemberById(ctx context.Context, memberId int64) (internal.Response[internal.Member], error) {
+	var response internal.Response[internal.Member]
+
+	data, err := s.memberRepo.GetMemberById(ctx, memberId)
+	if err != nil {
+		response.Data = internal.Member{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Member found successfully."
+	return response, nil
+}
+
+// UpdateMember replaces the details of a member. Suspending a member, or
+// letting their membership expire, stops them borrowing and placing holds
+// but leaves their loans and holds as they are. An empty Status, a zero
+// ExpiresOn or a nil MaxLoans keeps the current one.
+func (s *memberService) UpdateMember(ctx context.Context, m internal.Member) (internal.Response[bool], error) {
+	var response internal.Response[bool]
+
+	normalizeMember(&m)
+	if err := validateMember(m, true); err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.memberRepo.UpdateMember(ctx, m)
+	if err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "Member updated successfully."
+	return response, nil
+}
+
+func normalizeMember(m *internal.Member) {
+	m.Name = utils.NormalizeName(m.Name)
+	m.CardNumber = strings.ToUpper(strings.TrimSpace(m.CardNumber))
+	m.Type = internal.MemberType(strings.ToLower(strings.TrimSpace(string(m.Type))))
+	if m.Type == internal.EMPTY {
+		m.Type = internal.MemberStandard
+	}
+	m.Email = strings.TrimSpace(m.Email)
+	m.Phone = strings.Join(strings.Fields(m.Phone), " ")
+	m.Status = internal.MemberStatus(strings.ToLower(strings.TrimSpace(string(m.Status))))
+	if !m.ExpiresOn.IsZero() {
+		m.ExpiresOn = internal.Day(m.ExpiresOn)
+	}
+}
+
+// validateMember checks a normalized member sent by a client. Contact
+// details are optional, but must look right when given.
+func validateMember(m internal.Member, isUpdate bool) error {
+	v := &internal.ValidationError{}
+
+	if isUpdate {
+		v.Check(m.ID > internal.ZERO, "ID", "is required")
+	} else {
+		v.Check(m.ID == internal.ZERO, "ID", "is assigned by the server and must not be set")
+	}
+	v.Check(m.CreatedAt.IsZero(), "CreatedAt", "is managed by the server and must not be set")
+	v.Check(m.UpdatedAt == nil, "UpdatedAt", "is managed by the server and must not be set")
+
+	v.Check(m.Name != internal.EMPTY, "Name", "must not be empty")
+	v.Check(utf8.RuneCountInString(m.Name) <= internal.MaxNameLength,
+		"Name", "must be at most %d characters", internal.MaxNameLength)
+	v.Check(m.CardNumber != internal.EMPTY, "CardNumber", "must not be empty")
+	v.Check(utf8.RuneCountInString(m.CardNumber) <= maxCardNumberLength,
+		"CardNumber", "must be at most %d characters", maxCardNumberLength)
+	v.Check(slices.Contains(internal.MemberTypes, m.Type),
+		"Type", "must be one of standard, student or staff")
+
+	if m.Email != internal.EMPTY {
+		address, err := mail.ParseAddress(m.Email)
+		v.Check(err == nil && address.Address == m.Email, "Email", "must be an email address")
+		v.Check(utf8.RuneCountInString(m.Email) <= maxEmailLength,
+			"Email", "must be at most %d characters", maxEmailLength)
+	}
+	if m.Phone != internal.EMPTY {
+		v.Check(strings.Trim(m.Phone, "+0123456789 ()-.") == internal.EMPTY,
+			"Phone", "must only have digits, spaces and + ( ) - .")
+		v.Check(utf8.RuneCountInString(m.Phone) <= maxPhoneLength,
+			"Phone", "must be at most %d characters", maxPhoneLength)
+	}
+
+	v.Check(m.Status == internal.EMPTY || slices.Contains(internal.MemberStatuses, m.Status),
+		"Status", "must be active or suspended")
+	v.Check(m.MaxLoans == nil || *m.MaxLoans >= internal.ZERO,
+		"MaxLoans", "must not be negative")
+
+	return v.Err()
+}
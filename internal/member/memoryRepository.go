@@ -0,0 +1,152 @@
+package member
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amarantec/box/internal"
+)
+
+// memoryMemberRepository is an IMemberRepository kept in process memory.
+type memoryMemberRepository struct {
+	mu      sync.RWMutex
+	members map[int64]internal.Member
+	nextID  int64
+}
+
+func NewMemoryMemberRepository() IMemberRepository {
+	return &memoryMemberRepository{members: map[int64]internal.Member{}}
+}
+
+// cardNumberTaken reports whether a member other than memberId has
+// cardNumber. The caller must hold r.mu.
+func (r *memoryMemberRepository) cardNumberTaken(cardNumber string, memberId int64) bool {
+	for _, m := range r.members {
+		if m.ID != memberId && m.CardNumber == cardNumber {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryMemberRepository) RegisterMember(ctx context.Context, m internal.Member) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cardNumberTaken(m.CardNumber, internal.ZERO) {
+		return internal.ZERO, internal.ErrCardNumberAlreadyExists
+	}
+
+	r.nextID++
+	m.ID = r.nextID
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = nil
+	r.members[m.ID] = m
+
+	return m.ID, nil
+}
+
+// matches reports whether m is one of the members q asks for.
+func matches(m internal.Member, q internal.MemberQuery) bool {
+	if q.Status != internal.EMPTY && m.Status != q.Status {
+		return false
+	}
+	if q.Query == internal.EMPTY {
+		return true
+	}
+	query := strings.ToLower(q.Query)
+	return strings.Contains(strings.ToLower(m.Name), query) ||
+		strings.HasPrefix(strings.ToLower(m.CardNumber), query)
+}
+
+func (r *memoryMemberRepository) ListMembers(ctx context.Context, q internal.MemberQuery) ([]internal.Member, internal.Pagination, error) {
+	pagination := internal.Pagination{PageSize: q.PageSize}
+	if err := ctx.Err(); err != nil {
+		return []internal.Member{}, pagination, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := []internal.Member{}
+	for _, m := range r.members {
+		if matches(m, q) {
+			members = append(members, m)
+		}
+	}
+
+	slices.SortFunc(members, func(a, b internal.Member) int {
+		if c := cmp.Compare(a.Name, b.Name); c != internal.ZERO {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	pagination.TotalCount = int64(len(members))
+	members = members[min(q.Offset, len(members)):]
+	if len(members) > q.PageSize {
+		members = members[:q.PageSize]
+	}
+
+	return members, pagination, nil
+}
+
+func (r *memoryMemberRepository) GetMemberById(ctx context.Context, memberId int64) (internal.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.Member{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.members[memberId]
+	if !ok {
+		return internal.Member{}, internal.ErrMemberNotFound
+	}
+	return m, nil
+}
+
+func (r *memoryMemberRepository) UpdateMember(ctx context.Context, m internal.Member) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.members[m.ID]
+	if !ok {
+		return false, internal.ErrMemberNotFound
+	}
+	if r.cardNumberTaken(m.CardNumber, m.ID) {
+		return false, internal.ErrCardNumberAlreadyExists
+	}
+
+	now := time.Now()
+	current.Name = m.Name
+	current.CardNumber = m.CardNumber
+	current.Type = m.Type
+	current.Email = m.Email
+	current.Phone = m.Phone
+	if m.Status != internal.EMPTY {
+		current.Status = m.Status
+	}
+	if !m.ExpiresOn.IsZero() {
+		current.ExpiresOn = m.ExpiresOn
+	}
+	if m.MaxLoans != nil {
+		current.MaxLoans = m.MaxLoans
+	}
+	current.UpdatedAt = &now
+	r.members[m.ID] = current
+
+	return true, nil
+}
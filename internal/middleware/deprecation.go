@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeprecationMiddleware marks responses from a deprecated endpoint with the
+// Deprecation (RFC 9745) and Sunset (RFC 8594) headers, and links to the
+// successor endpoint. Wildcards in successor, such as "/books/{bookId}" or
+// "/books?genre={bookGenre}", are filled in from the request's path values.
+// A successor whose wildcard has no value is cut short before it, so that
+// "/books/{bookId}" links to "/books" rather than "/books/".
+func DeprecationMiddleware(next http.Handler, deprecatedAt, sunset time.Time, successor string) http.Handler {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		w.Header().Set("Sunset", sunsetDate)
+		w.Header().Set("Link", "<"+expandPath(successor, r)+`>; rel="successor-version"`)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// expandPath fills in the wildcards of pattern, escaping each value for the
+// path or the query it is part of.
+func expandPath(pattern string, r *http.Request) string {
+	var sb strings.Builder
+	inQuery := false
+	for {
+		start := strings.IndexByte(pattern, '{')
+		end := strings.IndexByte(pattern, '}')
+		if start < 0 || end < start {
+			sb.WriteString(pattern)
+			return sb.String()
+		}
+
+		sb.WriteString(pattern[:start])
+		inQuery = inQuery || strings.ContainsRune(pattern[:start], '?')
+
+		value := r.PathValue(pattern[start+1 : end])
+		if value == "" {
+			prefix, _, _ := strings.Cut(sb.String(), "?")
+			return strings.TrimSuffix(prefix, "/")
+		}
+		if inQuery {
+			sb.WriteString(url.QueryEscape(value))
+		} else {
+			sb.WriteString(url.PathEscape(value))
+		}
+		pattern = pattern[end+1:]
+	}
+}
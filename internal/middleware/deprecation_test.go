@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeprecationMiddleware(t *testing.T) {
+	deprecatedAt := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		successor string
+		values    map[string]string
+		wantLink  string
+	}{
+		{"fixed", "/books", nil, `</books>; rel="successor-version"`},
+		{"path", "/books/{bookId}", map[string]string{"bookId": "42"}, `</books/42>; rel="successor-version"`},
+		{"path escaping", "/authors/{name}", map[string]string{"name": "Le Guin/Ursula"}, `</authors/Le%20Guin%2FUrsula>; rel="successor-version"`},
+		{"query escaping", "/books?genre={bookGenre}", map[string]string{"bookGenre": "Sci-Fi & Fantasy"}, `</books?genre=Sci-Fi+%26+Fantasy>; rel="successor-version"`},
+		{"missing value", "/books/{bookId}", nil, `</books>; rel="successor-version"`},
+		{"missing query value", "/books?author={bookAuthor}", nil, `</books>; rel="successor-version"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := DeprecationMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), deprecatedAt, sunset, tt.successor)
+
+			r := httptest.NewRequest(http.MethodGet, "/legacy", nil)
+			for name, value := range tt.values {
+				r.SetPathValue(name, value)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Link"); got != tt.wantLink {
+				t.Fatalf("Link = %s, want %s", got, tt.wantLink)
+			}
+			if got := w.Header().Get("Deprecation"); got != "@1792281600" {
+				t.Fatalf("Deprecation = %s, want @1792281600", got)
+			}
+			if got := w.Header().Get("Sunset"); got != "Sun, 18 Apr 2027 00:00:00 GMT" {
+				t.Fatalf("Sunset = %s, want Sun, 18 Apr 2027 00:00:00 GMT", got)
+			}
+		})
+	}
+}
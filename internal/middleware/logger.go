@@ -4,6 +4,8 @@ import (
 	"log"
 	"net/http"
 	"time"
+
+	"github.com/amarantec/box/internal"
 )
 
 type responseWriterWrapper struct {
@@ -16,14 +18,21 @@ func (rw *responseWriterWrapper) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// LoggerMiddleware logs each request once served, with the actor it was
+// authenticated as, or anonymous.
 func LoggerMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		wrappedWritter := &responseWriterWrapper{ResponseWriter: w, statusCode: http.StatusOK}
+		ctx, principal := internal.WithPrincipalHolder(r.Context())
 
-		next.ServeHTTP(wrappedWritter, r)
+		next.ServeHTTP(wrappedWritter, r.WithContext(ctx))
 		duration := time.Since(start)
 
-		log.Printf(`[HTTP] %s "%s" |%d| - %s\n`, r.Method, r.RequestURI, wrappedWritter.statusCode, duration)
+		actor := principal.Actor()
+		if actor == internal.EMPTY {
+			actor = internal.AnonymousActor
+		}
+		log.Printf(`[HTTP] %s "%s" |%d| %s - %s`, r.Method, r.RequestURI, wrappedWritter.statusCode, actor, duration)
 	})
 }
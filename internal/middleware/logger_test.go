@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amarantec/box/internal"
+)
+
+func TestLoggerMiddlewareLogsActor(t *testing.T) {
+	var out bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&out)
+	t.Cleanup(func() { log.SetOutput(previous) })
+
+	h := LoggerMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != internal.EMPTY {
+			internal.HoldPrincipal(r.Context(), internal.Principal{Subject: "scanner", Method: internal.AuthAPIKey})
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		authorization, want string
+	}{
+		{"Bearer box_key", `[HTTP] GET "/books" |204| key:scanner - `},
+		{"", `[HTTP] GET "/books" |204| anonymous - `},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		r := httptest.NewRequest(http.MethodGet, "/books", nil)
+		if tt.authorization != internal.EMPTY {
+			r.Header.Set("Authorization", tt.authorization)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), r)
+
+		if !strings.Contains(out.String(), tt.want) {
+			t.Errorf("log = %q, want it to contain %q", out.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	const timeout = 20 * time.Millisecond
+
+	var err error
+	h := TimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		if !ok || time.Until(deadline) > timeout {
+			t.Errorf("request deadline = %v, %v, want one within %s", deadline, ok, timeout)
+		}
+
+		select {
+		case <-r.Context().Done():
+			err = r.Context().Err()
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case <-time.After(time.Second):
+			w.WriteHeader(http.StatusOK)
+		}
+	}), timeout)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books", nil))
+
+	if !errors.Is(err, context.DeadlineExceeded) || w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("slow handler got %v and answered %d, want %v and 504", err, w.Code, context.DeadlineExceeded)
+	}
+}
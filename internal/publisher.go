@@ -0,0 +1,6 @@
+package internal
+
+type Publisher struct {
+	ID   int64
+	Name string
+}
@@ -0,0 +1,163 @@
+package reference
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/amarantec/box/internal"
+)
+
+// memoryRepository is an IRepository kept in process memory. It does not
+// know about books, so unlike repository it lets an entry that is still
+// referenced be deleted.
+type memoryRepository[T Named] struct {
+	mu      sync.RWMutex
+	kind    Kind[T]
+	entries map[int64]entry
+	nextID  int64
+}
+
+func NewMemoryRepository[T Named](kind Kind[T]) IRepository[T] {
+	return &memoryRepository[T]{kind: kind, entries: map[int64]entry{}}
+}
+
+// findByName returns the entry whose name matches case-insensitively. The
+// caller must hold r.mu.
+func (r *memoryRepository[T]) findByName(name string) (entry, bool) {
+	for _, e := range r.entries {
+		if strings.EqualFold(e.Name, name) {
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+// insert stores e under a new ID. The caller must hold r.mu for writing.
+func (r *memoryRepository[T]) insert(e entry) entry {
+	r.nextID++
+	e.ID = r.nextID
+	r.entries[e.ID] = e
+	return e
+}
+
+func (r *memoryRepository[T]) Register(ctx context.Context, v T) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.ZERO, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.findByName(entry(v).Name); exists {
+		return internal.ZERO, r.kind.AlreadyExists
+	}
+
+	return r.insert(entry(v)).ID, nil
+}
+
+func (r *memoryRepository[T]) List(ctx context.Context) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return []T{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []entry
+	for _, e := range r.entries {
+		list = append(list, e)
+	}
+
+	slices.SortFunc(list, func(a, b entry) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	var out []T
+	for _, e := range list {
+		out = append(out, T(e))
+	}
+	return out, nil
+}
+
+func (r *memoryRepository[T]) GetById(ctx context.Context, id int64) (T, error) {
+	if err := ctx.Err(); err != nil {
+		return T{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.entries[id]
+	if !ok {
+		return T{}, r.kind.NotFound
+	}
+	return T(e), nil
+}
+
+func (r *memoryRepository[T]) GetByName(ctx context.Context, name string) (T, error) {
+	if err := ctx.Err(); err != nil {
+		return T{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.findByName(name)
+	if !ok {
+		return T{}, r.kind.NotFound
+	}
+	return T(e), nil
+}
+
+func (r *memoryRepository[T]) Update(ctx context.Context, v T) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := entry(v)
+	if _, ok := r.entries[e.ID]; !ok {
+		return false, r.kind.NotFound
+	}
+	if other, exists := r.findByName(e.Name); exists && other.ID != e.ID {
+		return false, r.kind.AlreadyExists
+	}
+
+	r.entries[e.ID] = e
+	return true, nil
+}
+
+func (r *memoryRepository[T]) Delete(ctx context.Context, id int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[id]; !ok {
+		return false, r.kind.NotFound
+	}
+
+	delete(r.entries, id)
+	return true, nil
+}
+
+func (r *memoryRepository[T]) Ensure(ctx context.Context, name string) (T, error) {
+	if err := ctx.Err(); err != nil {
+		return T{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e, exists := r.findByName(name); exists {
+		return T(e), nil
+	}
+	return T(r.insert(entry{Name: name})), nil
+}
@@ -0,0 +1,61 @@
+// Package reference stores the genres, authors and publishers books refer
+// to. All three are a name that is unique regardless of case, so they share
+// one implementation, told apart by their Kind.
+package reference
+
+import "github.com/amarantec/box/internal"
+
+// Named is the shape shared by internal.Genre, internal.Author and
+// internal.Publisher.
+type Named interface {
+	~struct {
+		ID   int64
+		Name string
+	}
+}
+
+// entry gives access to the fields of a Named value.
+type entry struct {
+	ID   int64
+	Name string
+}
+
+// Kind describes one kind of reference: the table it is stored in, the
+// nouns used in messages about it and the errors reported for it.
+type Kind[T Named] struct {
+	Table         string
+	Singular      string
+	Plural        string
+	NotFound      *internal.Error
+	AlreadyExists *internal.Error
+	InUse         *internal.Error
+}
+
+var (
+	Genres = Kind[internal.Genre]{
+		Table:         "genres",
+		Singular:      "Genre",
+		Plural:        "genres",
+		NotFound:      internal.ErrGenreNotFound,
+		AlreadyExists: internal.ErrGenreAlreadyExists,
+		InUse:         internal.ErrGenreInUse,
+	}
+
+	Authors = Kind[internal.Author]{
+		Table:         "authors",
+		Singular:      "Author",
+		Plural:        "authors",
+		NotFound:      internal.ErrAuthorNotFound,
+		AlreadyExists: internal.ErrAuthorAlreadyExists,
+		InUse:         internal.ErrAuthorInUse,
+	}
+
+	Publishers = Kind[internal.Publisher]{
+		Table:         "publishers",
+		Singular:      "Publisher",
+		Plural:        "publishers",
+		NotFound:      internal.ErrPublisherNotFound,
+		AlreadyExists: internal.ErrPublisherAlreadyExists,
+		InUse:         internal.ErrPublisherInUse,
+	}
+)
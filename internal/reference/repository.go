@@ -0,0 +1,193 @@
+package reference
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IRepository[T Named] interface {
+	Register(ctx context.Context, v T) (int64, error)
+	List(ctx context.Context) ([]T, error)
+	GetById(ctx context.Context, id int64) (T, error)
+	GetByName(ctx context.Context, name string) (T, error)
+	Update(ctx context.Context, v T) (bool, error)
+	Delete(ctx context.Context, id int64) (bool, error)
+	Ensure(ctx context.Context, name string) (T, error)
+}
+
+// Resolver finds what a book refers to: an entry by ID, which must exist,
+// or by name, which Ensure registers when it is missing.
+type Resolver[T Named] interface {
+	GetById(ctx context.Context, id int64) (T, error)
+	Ensure(ctx context.Context, name string) (T, error)
+}
+
+// querier is what repository needs from either a pool or a transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type repository[T Named] struct {
+	Conn querier
+	kind Kind[T]
+}
+
+func NewRepository[T Named](conn *pgxpool.Pool, kind Kind[T]) IRepository[T] {
+	return &repository[T]{Conn: conn, kind: kind}
+}
+
+// NewTxResolver resolves references within tx, so that the entries it
+// registers are rolled back along with the book that needed them.
+func NewTxResolver[T Named](tx pgx.Tx, kind Kind[T]) Resolver[T] {
+	return &repository[T]{Conn: tx, kind: kind}
+}
+
+func (r *repository[T]) Register(ctx context.Context, v T) (int64, error) {
+	e := entry(v)
+	err :=
+		r.Conn.QueryRow(
+			ctx,
+			`INSERT INTO `+r.kind.Table+` (name) VALUES ($1) RETURNING id;`, e.Name).Scan(&e.ID)
+
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return internal.ZERO, r.kind.AlreadyExists
+		}
+		return internal.ZERO, err
+	}
+
+	return e.ID, nil
+}
+
+func (r *repository[T]) List(ctx context.Context) ([]T, error) {
+	rows, err :=
+		r.Conn.Query(
+			ctx,
+			`SELECT id, name FROM `+r.kind.Table+` ORDER BY name;`)
+
+	if err != nil {
+		return []T{}, err
+	}
+
+	defer rows.Close()
+
+	var list []T
+	for rows.Next() {
+		e := entry{}
+		if err := rows.Scan(&e.ID, &e.Name); err != nil {
+			return []T{}, err
+		}
+		list = append(list, T(e))
+	}
+
+	return list, rows.Err()
+}
+
+func (r *repository[T]) GetById(ctx context.Context, id int64) (T, error) {
+	var e entry
+	if err :=
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT id, name FROM `+r.kind.Table+` WHERE id = $1;`, id).Scan(&e.ID, &e.Name); err != nil {
+		if err == pgx.ErrNoRows {
+			return T{}, r.kind.NotFound
+		}
+		return T{}, err
+	}
+
+	return T(e), nil
+}
+
+func (r *repository[T]) GetByName(ctx context.Context, name string) (T, error) {
+	var e entry
+	if err :=
+		r.Conn.QueryRow(
+			ctx,
+			`SELECT id, name FROM `+r.kind.Table+` WHERE lower(name) = lower($1);`, name).Scan(&e.ID, &e.Name); err != nil {
+		if err == pgx.ErrNoRows {
+			return T{}, r.kind.NotFound
+		}
+		return T{}, err
+	}
+
+	return T(e), nil
+}
+
+func (r *repository[T]) Update(ctx context.Context, v T) (bool, error) {
+	e := entry(v)
+	result, err :=
+		r.Conn.Exec(
+			ctx,
+			`UPDATE `+r.kind.Table+` SET name = $2, updated_at = $3 WHERE id = $1;`, e.ID, e.Name, time.Now())
+
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return false, r.kind.AlreadyExists
+		}
+		return false, err
+	}
+
+	if result.RowsAffected() == internal.ZERO {
+		log.Printf("%s not found, %d rows affected.\n", r.kind.Singular, result.RowsAffected())
+		return false, r.kind.NotFound
+	} else {
+		log.Printf("%s with ID %d updated.\n", r.kind.Singular, e.ID)
+		return true, nil
+	}
+}
+
+func (r *repository[T]) Delete(ctx context.Context, id int64) (bool, error) {
+	result, err :=
+		r.Conn.Exec(
+			ctx,
+			`DELETE FROM `+r.kind.Table+` WHERE id = $1;`, id)
+
+	if err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return false, r.kind.InUse
+		}
+		return false, err
+	}
+
+	if result.RowsAffected() == internal.ZERO {
+		log.Printf("%s not found, %d rows affected.\n", r.kind.Singular, result.RowsAffected())
+		return false, r.kind.NotFound
+	} else {
+		log.Printf("%s with ID %d deleted.\n", r.kind.Singular, id)
+		return true, nil
+	}
+}
+
+// Ensure inserts name unless it is already taken, in which case it returns
+// the entry taking it. A name inserted by a concurrent transaction makes the
+// insert wait for that transaction, then do nothing if it committed.
+func (r *repository[T]) Ensure(ctx context.Context, name string) (T, error) {
+	v, err := r.GetByName(ctx, name)
+	if err != r.kind.NotFound {
+		return v, err
+	}
+
+	e := entry{Name: name}
+	err =
+		r.Conn.QueryRow(
+			ctx,
+			`INSERT INTO `+r.kind.Table+` (name) VALUES ($1) ON CONFLICT DO NOTHING RETURNING id;`, name).Scan(&e.ID)
+
+	if err == pgx.ErrNoRows {
+		return r.GetByName(ctx, name)
+	}
+	if err != nil {
+		return T{}, err
+	}
+
+	return T(e), nil
+}
@@ -0,0 +1,71 @@
+package reference
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/amarantec/box/internal"
+)
+
+// Lookup returns refs with every reference given by ID replaced by the
+// stored entry, and the indexes of the IDs that do not exist. References by
+// name are left as they are.
+func Lookup[T Named](ctx context.Context, r Resolver[T], refs []T) ([]T, []int, error) {
+	out := slices.Clone(refs)
+	var missing []int
+
+	for i, ref := range refs {
+		id := entry(ref).ID
+		if id == internal.ZERO {
+			continue
+		}
+
+		v, err := r.GetById(ctx, id)
+		if errors.Is(err, internal.ErrNotFound) {
+			missing = append(missing, i)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		out[i] = v
+	}
+
+	return out, missing, nil
+}
+
+// EnsureAll returns refs with every reference given by name replaced by the
+// entry called that, registered when missing, and without duplicates.
+func EnsureAll[T Named](ctx context.Context, r Resolver[T], refs []T) ([]T, error) {
+	out := make([]T, 0, len(refs))
+	seen := map[int64]bool{}
+
+	for _, ref := range refs {
+		e := entry(ref)
+		if e.ID == internal.ZERO {
+			v, err := r.Ensure(ctx, e.Name)
+			if err != nil {
+				return nil, err
+			}
+			e = entry(v)
+		}
+
+		if !seen[e.ID] {
+			seen[e.ID] = true
+			out = append(out, T(e))
+		}
+	}
+
+	return out, nil
+}
+
+// Same reports whether ref, given either by ID or by name, refers to the
+// stored entry v.
+func Same[T Named](ref, v T) bool {
+	if id := entry(ref).ID; id != internal.ZERO {
+		return id == entry(v).ID
+	}
+	return strings.EqualFold(entry(ref).Name, entry(v).Name)
+}
@@ -0,0 +1,139 @@
+package reference
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/amarantec/box/internal"
+	"github.com/amarantec/box/internal/utils"
+)
+
+type IService[T Named] interface {
+	Register(ctx context.Context, v T) (internal.Response[int64], error)
+	List(ctx context.Context) (internal.Response[[]T], error)
+	GetById(ctx context.Context, id int64) (internal.Response[T], error)
+	Update(ctx context.Context, v T) (internal.Response[bool], error)
+	Delete(ctx context.Context, id int64) (internal.Response[bool], error)
+}
+
+type service[T Named] struct {
+	repo IRepository[T]
+	kind Kind[T]
+}
+
+func NewService[T Named](repository IRepository[T], kind Kind[T]) IService[T] {
+	return &service[T]{repo: repository, kind: kind}
+}
+
+func (s *service[T]) Register(ctx context.Context, v T) (internal.Response[int64], error) {
+	var response internal.Response[int64]
+
+	e := entry(v)
+	e.Name = utils.NormalizeName(e.Name)
+	if err := validateEntry(e, false); err != nil {
+		response.Data = internal.ZERO
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.repo.Register(ctx, T(e))
+	if err != nil {
+		response.Data = internal.ZERO
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = s.kind.Singular + " registered successfully."
+	return response, nil
+}
+
+func (s *service[T]) List(ctx context.Context) (internal.Response[[]T], error) {
+	var response internal.Response[[]T]
+
+	data, err := s.repo.List(ctx)
+	if err != nil {
+		response.Data = []T{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = "All " + s.kind.Plural + " registered in the system."
+	return response, nil
+}
+
+func (s *service[T]) GetById(ctx context.Context, id int64) (internal.Response[T], error) {
+	var response internal.Response[T]
+
+	data, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		response.Data = T{}
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = s.kind.Singular + " found successfully."
+	return response, nil
+}
+
+func (s *service[T]) Update(ctx context.Context, v T) (internal.Response[bool], error) {
+	var response internal.Response[bool]
+
+	e := entry(v)
+	e.Name = utils.NormalizeName(e.Name)
+	if err := validateEntry(e, true); err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	data, err := s.repo.Update(ctx, T(e))
+	if err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = s.kind.Singular + " updated successfully."
+	return response, nil
+}
+
+func (s *service[T]) Delete(ctx context.Context, id int64) (internal.Response[bool], error) {
+	var response internal.Response[bool]
+
+	data, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		response.Data = false
+		response.Success = false
+		return response, err
+	}
+
+	response.Data = data
+	response.Success = true
+	response.Message = s.kind.Singular + " deleted successfully."
+	return response, nil
+}
+
+// validateEntry checks a normalized entry before it is registered or updated.
+func validateEntry(e entry, isUpdate bool) error {
+	v := &internal.ValidationError{}
+
+	if isUpdate {
+		v.Check(e.ID > internal.ZERO, "ID", "is required")
+	} else {
+		v.Check(e.ID == internal.ZERO, "ID", "is assigned by the server and must not be set")
+	}
+
+	v.Check(e.Name != internal.EMPTY, "Name", "must not be empty")
+	v.Check(utf8.RuneCountInString(e.Name) <= internal.MaxNameLength,
+		"Name", "must be at most %d characters", internal.MaxNameLength)
+
+	return v.Err()
+}
@@ -4,4 +4,6 @@ type Response[T any] struct {
 	Data    T
 	Success bool
 	Message string
+
+	Pagination *Pagination `json:",omitempty"`
 }
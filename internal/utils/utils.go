@@ -5,32 +5,26 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/amarantec/box/internal"
 	"github.com/joho/godotenv"
 )
 
+// findEnvFile looks for the .env file in path and then in each of its
+// parents, so the server finds the repository's .env from any subdirectory.
 func findEnvFile(path string) (string, error) {
-	filePath := filepath.Join(path, internal.ENVFILE)
-	if _, err := os.Stat(filePath); err == nil {
-		return filePath, nil
-	}
-
-	files, err := os.ReadDir(path)
-	if err != nil {
-		return internal.EMPTY, err
-	}
+	for dir := path; ; dir = filepath.Dir(dir) {
+		filePath := filepath.Join(dir, internal.ENVFILE)
+		if _, err := os.Stat(filePath); err == nil {
+			return filePath, nil
+		}
 
-	for _, file := range files {
-		if file.IsDir() {
-			subDirPath := filepath.Join(path, file.Name())
-			subDirFilePath, err := findEnvFile(subDirPath)
-			if err == nil {
-				return subDirFilePath, nil
-			}
+		if filepath.Dir(dir) == dir {
+			break
 		}
 	}
-	return internal.EMPTY, fmt.Errorf("file .env not found in %s or anywhere", path)
+	return internal.EMPTY, fmt.Errorf("file .env not found in %s or any parent directory", path)
 }
 
 func LoadEnv() {
@@ -39,14 +33,21 @@ func LoadEnv() {
 		log.Fatal("error getting actual dir")
 	}
 
-	appPath := filepath.Dir(filepath.Dir(path))
-	envFile, err := findEnvFile(appPath)
+	envFile, err := findEnvFile(path)
 	if err != nil {
-		log.Fatal(".env file not found")
+		log.Println(".env file not found, using the process environment")
+		return
 	}
 	godotenv.Load(envFile)
 }
 
+func LoadEnvFile(envFile string) error {
+	if err := godotenv.Load(envFile); err != nil {
+		return fmt.Errorf("load env file %s: %w", envFile, err)
+	}
+	return nil
+}
+
 func BuildDBConfig() (string, error) {
 	dbHost := os.Getenv("DB_HOST")
 	dbUser := os.Getenv("POSTGRES_USER")
@@ -65,3 +66,9 @@ func BuildDBConfig() (string, error) {
 	return dbConfig, nil
 
 }
+
+// NormalizeName trims a name and collapses inner runs of whitespace, so the
+// same author, genre or publisher typed twice ends up as one row.
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
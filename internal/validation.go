@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists every field of a payload that failed validation.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, f := range e.Errors {
+		messages = append(messages, f.Field+" "+f.Message)
+	}
+	return "Validation failed: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+func (e *ValidationError) Add(field, message string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(message, args...)})
+}
+
+// Check records message for field when ok is false.
+func (e *ValidationError) Check(ok bool, field, message string, args ...any) {
+	if !ok {
+		e.Add(field, message, args...)
+	}
+}
+
+// Err returns e when it holds at least one failure and nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Errors) == ZERO {
+		return nil
+	}
+	return e
+}
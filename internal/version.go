@@ -0,0 +1,31 @@
+package internal
+
+import "runtime/debug"
+
+// Version is the build version, set at build time with
+//
+//	go build -ldflags "-X github.com/amarantec/box/internal.Version=v1.2.3"
+//
+// When unset, BuildVersion falls back to the VCS revision recorded by the Go
+// toolchain.
+var Version = EMPTY
+
+func BuildVersion() string {
+	if Version != EMPTY {
+		return Version
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Version != EMPTY && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value
+		}
+	}
+	return "devel"
+}